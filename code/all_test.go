@@ -1,11 +1,25 @@
 package code
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
 	"go/types"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
+	"golang.org/x/tools/go/packages"
 	"golang.org/x/tools/go/types/typeutil"
 )
 
@@ -49,7 +63,7 @@ func TestID(t *testing.T) {
 		}
 	}
 
-	analyzer.ParsePackages(nil, "builtin", "math")
+	analyzer.ParsePackages(nil, ParseOptions{}, "builtin", "math")
 	stdPkg := analyzer.PackageByPath("builtin")
 	mathPkg := analyzer.PackageByPath("math")
 
@@ -80,13 +94,1170 @@ func TestRegisterType(t *testing.T) {
 	}
 }
 
+func TestCollectTestVariantPackages(t *testing.T) {
+	var newPPkg = func(id, path string, imports ...*packages.Package) *packages.Package {
+		ppkg := &packages.Package{ID: id, PkgPath: path, Imports: map[string]*packages.Package{}}
+		for _, p := range imports {
+			ppkg.Imports[p.PkgPath] = p
+		}
+		return ppkg
+	}
+
+	fmtPkg := newPPkg("fmt", "fmt")
+	a := newPPkg("a", "a", fmtPkg)
+	aTest := newPPkg("a [a.test]", "a", fmtPkg)
+	aXTest := newPPkg("a_test [a.test]", "a_test", aTest)
+	aMain := newPPkg("a.test", "a.test", aTest, aXTest)
+	aMain.Name = "main"
+	b := newPPkg("b", "b", a)
+	bTest := newPPkg("b [b.test]", "b", a)
+
+	allPPkgs := collectPPackages([]*packages.Package{a, aTest, aXTest, aMain, b, bTest})
+	// a is imported by b, so its test variant and the
+	// external test package depending on it are not used.
+	expected := map[string]*packages.Package{"fmt": fmtPkg, "a": a, "b": bTest}
+	if len(allPPkgs) != len(expected) {
+		t.Errorf("collected %d packages, expected %d", len(allPPkgs), len(expected))
+	}
+	for path, ppkg := range expected {
+		if allPPkgs[path] != ppkg {
+			t.Errorf("package %s: expected variant %s", path, ppkg.ID)
+		}
+	}
+}
+
+func TestBuildConfiguration(t *testing.T) {
+	options := ParseOptions{
+		BuildTags:  []string{"foo", "bar"},
+		GOOS:       "windows",
+		CgoEnabled: "0",
+		Env:        []string{"GOARCH=arm64", "CGO_ENABLED=1", "GOFLAGS=-mod=vendor", "GOPRIVATE=secret.example.com"},
+	}
+	expected := "GOOS=windows GOARCH=arm64 -tags=foo,bar CGO_ENABLED=1"
+	if config := options.BuildConfiguration(); config != expected {
+		t.Errorf("build configuration: %s, expected: %s", config, expected)
+	}
+
+	env := options.environ([]string{"HOME=/home/gold"})
+	expectedEnv := []string{"HOME=/home/gold", "CGO_ENABLED=0", "GOOS=windows", "GOARCH=arm64", "CGO_ENABLED=1", "GOFLAGS=-mod=vendor", "GOPRIVATE=secret.example.com"}
+	if strings.Join(env, " ") != strings.Join(expectedEnv, " ") {
+		t.Errorf("environ: %v, expected: %v", env, expectedEnv)
+	}
+}
+
+func TestParseOptionsFlagsAndEnviron(t *testing.T) {
+	var base = []string{"HOME=/home/gold", "GOOS=linux"}
+	var testCases = []struct {
+		options ParseOptions
+		flags   string
+		env     string // "-" means env is returned as is
+		goos    string
+		goarch  string
+	}{
+		{ParseOptions{}, "", "-", "", ""},
+		{ParseOptions{BuildTags: []string{"foo"}}, "-tags=foo", "-", "", ""},
+		{ParseOptions{BuildTags: []string{"foo", "bar"}, Tests: true}, "-tags=foo,bar", "-", "", ""},
+		{ParseOptions{GOOS: "windows"}, "", "HOME=/home/gold GOOS=linux GOOS=windows", "windows", ""},
+		{ParseOptions{GOARCH: "386"}, "", "HOME=/home/gold GOOS=linux GOARCH=386", "", "386"},
+		{ParseOptions{CgoEnabled: "0"}, "", "HOME=/home/gold GOOS=linux CGO_ENABLED=0", "", ""},
+		{ParseOptions{GOOS: "darwin", GOARCH: "arm64", CgoEnabled: "1"}, "", "HOME=/home/gold GOOS=linux CGO_ENABLED=1 GOOS=darwin GOARCH=arm64", "darwin", "arm64"},
+		{ParseOptions{GOARCH: "386", Env: []string{"GOARCH=mips", "GOFLAGS=-mod=mod"}}, "", "HOME=/home/gold GOOS=linux GOARCH=386 GOARCH=mips GOFLAGS=-mod=mod", "", "mips"},
+		{ParseOptions{Env: []string{"GOOS=plan9", "GOOSX=1"}}, "", "HOME=/home/gold GOOS=linux GOOS=plan9 GOOSX=1", "plan9", ""},
+	}
+	for i, tc := range testCases {
+		if flags := strings.Join(tc.options.buildFlags(), " "); flags != tc.flags {
+			t.Errorf("case %d: build flags: %q, expected: %q", i, flags, tc.flags)
+		}
+
+		// The passed env should not be modified.
+		env := append(make([]string, 0, 8), base...)
+		result := tc.options.environ(env)
+		if tc.env == "-" {
+			if len(result) != len(env) || &result[0] != &env[0] {
+				t.Errorf("case %d: environ: %v, expected the passed one", i, result)
+			}
+		} else if r := strings.Join(result, " "); r != tc.env {
+			t.Errorf("case %d: environ: %q, expected: %q", i, r, tc.env)
+		}
+		env = append(env, "X=1")
+		if r := strings.Join(result, " "); tc.env != "-" && r != tc.env {
+			t.Errorf("case %d: the result of environ is changed by appending the passed env: %q", i, r)
+		}
+
+		goos, goarch := tc.options.Target()
+		if tc.goos == "" {
+			tc.goos = build.Default.GOOS
+		}
+		if tc.goarch == "" {
+			tc.goarch = build.Default.GOARCH
+		}
+		if goos != tc.goos || goarch != tc.goarch {
+			t.Errorf("case %d: target: %s/%s, expected: %s/%s", i, goos, goarch, tc.goos, tc.goarch)
+		}
+	}
+
+	// A nil env means the environment of the current process.
+	options := ParseOptions{GOOS: "windows"}
+	if env := options.environ(nil); len(env) != len(os.Environ())+1 || env[len(env)-1] != "GOOS=windows" {
+		t.Errorf("environ(nil): %v", env)
+	}
+	if env := (&ParseOptions{}).environ(nil); env != nil {
+		t.Errorf("environ(nil) of blank options: %v, expected nil", env)
+	}
+}
+
+func TestBuildConstraintOf(t *testing.T) {
+	cases := []struct {
+		src        string
+		constraint string
+	}{
+		{"// Copyright\n\n//go:build linux && !cgo\n// +build linux,!cgo\n\npackage p\n", "//go:build linux && !cgo"},
+		{"/* license\n*/\n// +build linux darwin\n//+build amd64\n\npackage p\n", "// +build linux darwin; // +build amd64"},
+		{"package p\n\n//go:build ignore\n", ""},
+	}
+	for _, c := range cases {
+		if constraint := buildConstraintOf([]byte(c.src)); constraint != c.constraint {
+			t.Errorf("build constraint: %q, expected: %q", constraint, c.constraint)
+		}
+	}
+}
+
+func TestCollectCDeclarations(t *testing.T) {
+	src := []byte(`
+#include <stdlib.h>
+#include "foo.h"
+
+#define MAX_SIZE 64
+#define SQUARE(x) \
+	((x) * (x))
+
+/* A point. */
+struct point { int x, y; };
+
+typedef struct node {
+	struct node *next;
+	void (*visit)(struct node*);
+} node_t;
+
+typedef int (*callback)(void *data);
+
+enum color { RED, GREEN = 2, BLUE };
+
+extern int counter;
+static const char *names[3] = {"a", "b", "c"};
+
+#ifdef __cplusplus
+extern "C" {
+#endif
+
+int add(int a, int b);
+
+static inline double half(double v) {
+	int unused = 0;
+	return v / 2.0;
+}
+
+#ifdef __cplusplus
+}
+#endif
+`)
+	expected := []string{
+		"macro MAX_SIZE", "macro SQUARE",
+		"struct struct_point",
+		"struct struct_node", "type node_t",
+		"type callback",
+		"enum enum_color", "enumerator RED", "enumerator GREEN", "enumerator BLUE",
+		"var counter", "var names",
+		"func add", "func half",
+	}
+
+	var decls []string
+	for _, decl := range collectCDeclarations(src, ScanCTokens(src)) {
+		decls = append(decls, decl.kind+" "+decl.name)
+		name := decl.name
+		switch decl.kind {
+		case "struct", "union", "enum":
+			name = strings.TrimPrefix(name, decl.kind+"_")
+		}
+		if !bytes.HasPrefix(src[decl.offset:], []byte(name)) {
+			t.Errorf("offset of %s is not correct: %d", decl.name, decl.offset)
+		}
+	}
+	if strings.Join(decls, "; ") != strings.Join(expected, "; ") {
+		t.Errorf("declarations:\n%v\nexpected:\n%v", decls, expected)
+	}
+}
+
+func TestCollectCgoSymbols(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	original := filepath.Join(dir, "p.go")
+	generated := filepath.Join(dir, "p.cgo1.go")
+
+	// The first line of the original file is mapped from the 4th line.
+	src := "// Code generated by cmd/cgo; DO NOT EDIT.\n\n//line " + original + ":1:1\n" + `package p
+
+/*
+int foo(void) { return 1; }
+*/
+import _ "unsafe"
+
+var _ = _Cfunc_foo
+`
+	if err := ioutil.WriteFile(generated, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := checkTestPackage(t, generated, src, true)
+	pkg.SourceFiles = []SourceFileInfo{{
+		OriginalFile:  original,
+		GeneratedFile: generated,
+		AstFile:       pkg.PPkg.Syntax[0],
+	}}
+	(&CodeAnalyzer{}).collectCgoSymbols(pkg)
+
+	if len(pkg.CgoSymbols) != 1 || pkg.CgoSymbols[0].Declaration == nil {
+		t.Fatalf("unexpected cgo symbols: %v", pkg.CgoSymbols)
+	}
+	pos := pkg.CgoSymbols[0].Declaration.Position
+	if pos.Filename != original || pos.Line != 4 {
+		t.Errorf("C.foo is declared at %s:%d, expected %s:4", pos.Filename, pos.Line, original)
+	}
+}
+
+func TestScanAsmTokens(t *testing.T) {
+	src := []byte(`#include "textflag.h"
+
+// func add(x, y int) int
+TEXT ·add<ABIInternal>(SB), NOSPLIT, $0-24
+	MOVQ x+0(FP), AX
+loop:	ADDQ y+8(FP), R8
+	CALL runtime·memmove(SB)
+	RET
+`)
+	expected := map[string]AsmTokenKind{
+		"#include":                  AsmToken_Directive,
+		`"textflag.h"`:              AsmToken_String,
+		"// func add(x, y int) int": AsmToken_Comment,
+		"TEXT":                      AsmToken_Instruction,
+		"·add<ABIInternal>":         AsmToken_Symbol,
+		"SB":                        AsmToken_PseudoRegister,
+		"NOSPLIT":                   AsmToken_Ident,
+		"24":                        AsmToken_Number,
+		"AX":                        AsmToken_Register,
+		"R8":                        AsmToken_Register,
+		"loop:":                     AsmToken_Label,
+		"ADDQ":                      AsmToken_Instruction,
+		"runtime·memmove":           AsmToken_Symbol,
+	}
+	for _, token := range ScanAsmTokens(src) {
+		text := string(src[token.Start:token.End])
+		if kind, ok := expected[text]; ok {
+			if kind != token.Kind {
+				t.Errorf("kind of %s: %d, expected: %d", text, token.Kind, kind)
+			}
+			delete(expected, text)
+		}
+	}
+	if len(expected) > 0 {
+		t.Errorf("tokens not found: %v", expected)
+	}
+
+	if pkgPath, name := ParseAsmSymbol([]byte("math∕big·addVV<ABIInternal>")); pkgPath != "math/big" || name != "addVV" {
+		t.Errorf("symbol: %s.%s, expected: math/big.addVV", pkgPath, name)
+	}
+}
+
+func TestCountGoSourceLines(t *testing.T) {
+	cases := []struct {
+		src       string
+		stats     LineStats
+		generated bool
+	}{
+		{"package p\n\n// F does nothing.\nfunc F() {} // comment\n", LineStats{Code: 2, Comment: 1, Blank: 1}, false},
+		{"/*\n license\n*/\n\npackage p\nvar s = `a\n\nb`\n\t\n", LineStats{Code: 4, Comment: 3, Blank: 2}, false},
+		{"// Code generated by stringer. DO NOT EDIT.\n\npackage p", LineStats{Code: 1, Comment: 1, Blank: 1}, true},
+		{"package p\n\n// Code generated by stringer. DO NOT EDIT.\n", LineStats{Code: 1, Comment: 1, Blank: 1}, false},
+	}
+	for _, c := range cases {
+		stats, generated := CountGoSourceLines([]byte(c.src))
+		if stats != c.stats || generated != c.generated {
+			t.Errorf("line stats of %q: %v %v, expected: %v %v", c.src, stats, generated, c.stats, c.generated)
+		}
+	}
+}
+
+func TestStatisticsOf(t *testing.T) {
+	var a, b PackageAnalyzeResult
+	a.stats.Packages, b.stats.Packages = 1, 1
+	a.stats.PackagesByDeps[3], b.stats.PackagesByDeps[3] = 1, 1
+	a.stats.ExportedTypeNamesByKind[reflect.Int] = 2
+	b.stats.ExportedTypeNamesByKind[reflect.Struct] = 3
+	a.stats.roughExportedIdentifierCount = 5
+
+	stats := StatisticsOf([]*Package{{PackageAnalyzeResult: &a}, {PackageAnalyzeResult: &b}, {}})
+	if stats.Packages != 2 || stats.PackagesByDeps[3] != 2 {
+		t.Errorf("package counts are not summed: %d, %d", stats.Packages, stats.PackagesByDeps[3])
+	}
+	if stats.ExportedIntergerTypeNames != 2 || stats.ExportedCompositeTypeNames != 3 || stats.ExportedTypeNames != 5 {
+		t.Errorf("kind sums are not calculated: %d, %d, %d", stats.ExportedIntergerTypeNames, stats.ExportedCompositeTypeNames, stats.ExportedTypeNames)
+	}
+	if stats.roughExportedIdentifierCount != 5 {
+		t.Errorf("rough counts are not summed: %d", stats.roughExportedIdentifierCount)
+	}
+}
+
+// newTestPackage parses and type checks a single-file package.
+// The path of the package is the same as its name.
+func newTestPackage(t *testing.T, src string) *Package {
+	t.Helper()
+	return checkTestPackage(t, "p.go", src, false)
+}
+
+// checkTestPackage parses and type checks a single-file package.
+// If illTyped is true, the type errors in the file are ignored.
+func checkTestPackage(t *testing.T, filename, src string, illTyped bool) *Package {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Defs:       make(map[*ast.Ident]types.Object),
+		Uses:       make(map[*ast.Ident]types.Object),
+		Implicits:  make(map[ast.Node]types.Object),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Scopes:     make(map[ast.Node]*types.Scope),
+	}
+	path := file.Name.Name
+	conf := &types.Config{}
+	if illTyped {
+		conf.Error = func(error) {}
+	}
+	tpkg, err := conf.Check(path, fset, []*ast.File{file}, info)
+	if err != nil && !illTyped {
+		t.Fatal(err)
+	}
+	return &Package{
+		PPkg: &packages.Package{
+			Name: path, PkgPath: path, Fset: fset,
+			Syntax: []*ast.File{file}, Types: tpkg, TypesInfo: info,
+			IllTyped: illTyped,
+		},
+		PackageAnalyzeResult: NewPackageAnalyzeResult(),
+	}
+}
+
+func TestCollectWholeCodeStatistics(t *testing.T) {
+	const src = `package p
+
+var handler = func(int) error { return nil }
+
+func f() {
+	type local struct{}
+	g := func() {}
+	n := 1
+	var h func()
+	_, _, _ = g, n, h
+}
+`
+	pkg := newTestPackage(t, src)
+	(&CodeAnalyzer{}).analyzePackage_CollectWholeCodeStatistics(pkg)
+	stats := pkg.Statistics()
+	if stats.FunctionLiterals != 2 || stats.AllFunctionsByResultCount[1] != 1 {
+		t.Errorf("function literals: %d, with one result: %d, expected: 2, 1", stats.FunctionLiterals, stats.AllFunctionsByResultCount[1])
+	}
+	if stats.LocalTypeNames != 1 || stats.LocalTypeNamesByKind[reflect.Struct] != 1 {
+		t.Errorf("local type names: %d, expected: 1", stats.LocalTypeNames)
+	}
+	if stats.FunctionTypedVariables != 3 {
+		t.Errorf("function-typed variables: %d, expected: 3", stats.FunctionTypedVariables)
+	}
+}
+
+func TestCalculateFunctionMetrics(t *testing.T) {
+	const src = `package p
+
+func f(a, b int, _ string) int {
+	if a > 0 && b > 0 {
+		for i := 0; i < a; i++ {
+			switch i {
+			case 1, 2:
+				b++
+			default:
+				b--
+			}
+		}
+	} else if a < 0 {
+		go func() {}()
+	}
+	return b
+}
+`
+	file, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := CalculateFunctionMetrics(file.Decls[0].(*ast.FuncDecl))
+	expected := FunctionMetrics{Complexity: 6, Statements: 10, Nesting: 3, Parameters: 3}
+	if m != expected {
+		t.Errorf("function metrics: %+v, expected: %+v", m, expected)
+	}
+
+	const labeledSrc = `package p
+
+func g(n int) {
+Outer:
+	for i := 0; i < n; i++ {
+		for {
+			continue Outer
+		}
+	}
+}
+`
+	file, err = parser.ParseFile(token.NewFileSet(), "p.go", labeledSrc, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = CalculateFunctionMetrics(file.Decls[0].(*ast.FuncDecl))
+	expected = FunctionMetrics{Complexity: 3, Statements: 5, Nesting: 2, Parameters: 1}
+	if m != expected {
+		t.Errorf("function metrics (labeled): %+v, expected: %+v", m, expected)
+	}
+}
+
+func TestIdentifierUsesCountFor(t *testing.T) {
+	var uses identifierUses
+	uses[userKind_Test] = 2
+	uses[userKind_Main] = 3
+	cases := []struct {
+		options UnusedIdentifierOptions
+		count   int32
+	}{
+		{UnusedIdentifierOptions{}, 0},
+		{UnusedIdentifierOptions{TestPackagesAsUsers: true}, 2},
+		{UnusedIdentifierOptions{MainPackagesAsUsers: true}, 3},
+		{UnusedIdentifierOptions{TestPackagesAsUsers: true, MainPackagesAsUsers: true}, 5},
+	}
+	for _, c := range cases {
+		if n := uses.countFor(c.options); n != c.count {
+			t.Errorf("uses for %+v: %d, expected: %d", c.options, n, c.count)
+		}
+	}
+	if uses.total() != 5 {
+		t.Errorf("total uses: %d, expected: 5", uses.total())
+	}
+}
+
+// A testImporter imports the packages checked before.
+type testImporter map[string]*types.Package
+
+func (imp testImporter) Import(path string) (*types.Package, error) {
+	if tpkg := imp[path]; tpkg != nil {
+		return tpkg, nil
+	}
+	return nil, errors.New("unknown package: " + path)
+}
+
+// checkTestPackages writes the files (name and source pairs) of the
+// specified packages into dir, then parses and type checks them in
+// order. A package may only import the ones before it.
+func checkTestPackages(t *testing.T, dir string, pkgFiles map[string][][2]string, paths ...string) *CodeAnalyzer {
+	t.Helper()
+	d := &CodeAnalyzer{packageTable: make(map[string]*Package)}
+	fset := token.NewFileSet()
+	imp := make(testImporter)
+	for _, path := range paths {
+		var files []*ast.File
+		var filenames []string
+		for _, f := range pkgFiles[path] {
+			filename := filepath.Join(dir, path, f[0])
+			if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filename, []byte(f[1]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := parser.ParseFile(fset, filename, f[1], parser.ParseComments)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files = append(files, file)
+			filenames = append(filenames, filename)
+		}
+		info := &types.Info{
+			Types:      make(map[ast.Expr]types.TypeAndValue),
+			Defs:       make(map[*ast.Ident]types.Object),
+			Uses:       make(map[*ast.Ident]types.Object),
+			Implicits:  make(map[ast.Node]types.Object),
+			Selections: make(map[*ast.SelectorExpr]*types.Selection),
+			Scopes:     make(map[ast.Node]*types.Scope),
+		}
+		tpkg, err := (&types.Config{Importer: imp}).Check(path, fset, files, info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imp[path] = tpkg
+		pkg := &Package{
+			PPkg: &packages.Package{
+				ID: path, Name: tpkg.Name(), PkgPath: path, Fset: fset,
+				GoFiles: filenames, CompiledGoFiles: filenames,
+				Syntax: files, Types: tpkg, TypesInfo: info,
+			},
+		}
+		for _, dep := range tpkg.Imports() {
+			pkg.Deps = append(pkg.Deps, d.packageTable[dep.Path()])
+		}
+		d.packageList = append(d.packageList, pkg)
+		d.packageTable[path] = pkg
+	}
+	return d
+}
+
+func TestParseModuleVersionArgs(t *testing.T) {
+	cases := []struct {
+		args []string
+		mvs  []ModuleVersion
+		err  bool
+	}{
+		{[]string{"./...", "std"}, nil, false},
+		{[]string{"x.y/z@v1.2.3", "x.y/w@v0.0.0-20200101000000-abcdefabcdef"}, []ModuleVersion{{"x.y/z", "v1.2.3"}, {"x.y/w", "v0.0.0-20200101000000-abcdefabcdef"}}, false},
+		{[]string{"x.y/z@latest"}, nil, true},
+		{[]string{"./z@v1.2.3"}, nil, true},
+		{[]string{"@v1.2.3"}, nil, true},
+		{[]string{"x.y/z@v1.2.3", "./..."}, nil, true},
+	}
+	for _, c := range cases {
+		mvs, err := parseModuleVersionArgs(c.args)
+		if (err != nil) != c.err {
+			t.Errorf("%v: error %v, expected error: %v", c.args, err, c.err)
+			continue
+		}
+		if !reflect.DeepEqual(mvs, c.mvs) {
+			t.Errorf("%v: %v, expected: %v", c.args, mvs, c.mvs)
+		}
+	}
+}
+
+func TestEscapeModulePath(t *testing.T) {
+	cases := []struct {
+		path, escaped string
+	}{
+		{"go101.org/gold", "go101.org/gold"},
+		{"github.com/BurntSushi/toml", "github.com/!burnt!sushi/toml"},
+		{"v1.0.0-RC1", "v1.0.0-!r!c1"},
+	}
+	for _, c := range cases {
+		if escaped := escapeModulePath(c.path); escaped != c.escaped {
+			t.Errorf("escaped %s: %s, expected: %s", c.path, escaped, c.escaped)
+		}
+	}
+}
+
+func TestCachedModuleDir(t *testing.T) {
+	modCache, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(modCache)
+
+	mv := ModuleVersion{Path: "x.y/Z", Version: "v1.2.3"}
+	if _, err := cachedModuleDir(modCache, mv); err == nil {
+		t.Errorf("no errors for a module not downloaded")
+	}
+
+	zipDir := filepath.Join(modCache, "cache", "download", "x.y", "!z", "@v")
+	if err := os.MkdirAll(zipDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(zipDir, "v1.2.3.zip"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := cachedModuleDir(modCache, mv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(modCache, "x.y", "!z@v1.2.3"); dir != expected {
+		t.Errorf("module dir: %s, expected: %s", dir, expected)
+	}
+}
+
+func TestModuleProviding(t *testing.T) {
+	buildList := []goListModule{
+		{Path: "x.y/z", Version: "v1.0.0"},
+		{Path: "x.y/z/w", Version: "v0.1.0"},
+		{Path: "x.y/zz", Version: "v0.2.0"},
+	}
+	cases := []struct {
+		pkgPath, modPath string
+	}{
+		{"x.y/z", "x.y/z"},
+		{"x.y/z/v", "x.y/z"},
+		{"x.y/z/w/u", "x.y/z/w"},
+		{"x.y/zz/u", "x.y/zz"},
+		{"x.y/zzz", ""},
+	}
+	for _, c := range cases {
+		var modPath string
+		if m := moduleProviding(buildList, c.pkgPath); m != nil {
+			modPath = m.Path
+		}
+		if modPath != c.modPath {
+			t.Errorf("module providing %s: %q, expected: %q", c.pkgPath, modPath, c.modPath)
+		}
+	}
+}
+
+func TestModuleDownloadArg(t *testing.T) {
+	cases := []struct {
+		mod goListModule
+		arg string
+	}{
+		{goListModule{Path: "x.y/z", Version: "v1.0.0"}, "x.y/z@v1.0.0"},
+		{goListModule{Path: "x.y/z", Version: "v1.0.0", Replace: &goListModule{Path: "x.y/w", Version: "v0.1.0"}}, "x.y/w@v0.1.0"},
+		{goListModule{Path: "x.y/z", Version: "v1.0.0", Replace: &goListModule{Path: "../w"}}, ""},
+		{goListModule{Path: "x.y/z"}, ""},
+	}
+	for _, c := range cases {
+		if arg := moduleDownloadArg(&c.mod); arg != c.arg {
+			t.Errorf("download argument of %s: %q, expected: %q", c.mod.Path, arg, c.arg)
+		}
+	}
+}
+
+func TestPackageModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := checkTestPackages(t, dir, map[string][][2]string{
+		"example.com/b": {{"b.go", `package b
+
+func B() {}
+`}},
+		"example.com/a": {{"a.go", `package a
+
+import "example.com/b"
+
+func A() { b.B() }
+
+func C() {}
+`}},
+		"example.com/a/x": {{"x.go", `package x
+
+func X() {}
+`}},
+	}, "example.com/b", "example.com/a", "example.com/a/x")
+
+	// As go/packages reports with the NeedModule mode.
+	modA := &packages.Module{Path: "example.com/a", Dir: filepath.Join(dir, "example.com/a"), Main: true}
+	modB := &packages.Module{Path: "example.com/b", Version: "v1.2.3", Dir: filepath.Join(dir, "example.com/b")}
+	d.packageTable["example.com/a"].PPkg.Module = modA
+	d.packageTable["example.com/a/x"].PPkg.Module = modA
+	d.packageTable["example.com/b"].PPkg.Module = modB
+	d.AnalyzePackages(nil)
+
+	cases := []struct {
+		root      string
+		version   string
+		pkgs      []string
+		functions int32
+		codeLines int32
+	}{
+		{"example.com/a", "", []string{"example.com/a", "example.com/a/x"}, 3, 6},
+		{"example.com/b", "v1.2.3", []string{"example.com/b"}, 1, 2},
+	}
+	for _, c := range cases {
+		mod := d.ModuleByRoot(c.root)
+		if mod == nil {
+			t.Errorf("module %s is not found", c.root)
+			continue
+		}
+		if mod.Version != c.version {
+			t.Errorf("version of module %s: %q, expected: %q", c.root, mod.Version, c.version)
+		}
+		var paths []string
+		pkgs := d.ModulePackages(mod)
+		for _, pkg := range pkgs {
+			paths = append(paths, pkg.Path())
+		}
+		sort.Strings(paths)
+		if !reflect.DeepEqual(paths, c.pkgs) {
+			t.Errorf("packages of module %s: %v, expected: %v", c.root, paths, c.pkgs)
+		}
+		if n := StatisticsOf(pkgs).ExportedFunctions; n != c.functions {
+			t.Errorf("exported functions of module %s: %d, expected: %d", c.root, n, c.functions)
+		}
+		if n := mod.Lines.Code; n != c.codeLines {
+			t.Errorf("code lines of module %s: %d, expected: %d", c.root, n, c.codeLines)
+		}
+	}
+}
+
+func TestRankingsOf(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := checkTestPackages(t, dir, map[string][][2]string{
+		"example.com/r": {{"r.go", `package r
+
+type I interface{ M() }
+
+type J interface{ Never() }
+
+type T1 struct{}
+
+func (T1) M()  {}
+func (T1) M2() {}
+func (T1) M3() {}
+
+type T2 struct{}
+
+func (T2) M()  {}
+func (T2) M2() {}
+
+type T3 struct{}
+
+func (T3) M() {}
+
+type T4 struct{}
+
+type P struct{}
+
+func F0()             {}
+func F1(a P)        {}
+func F2(a, b P)     {}
+func F3(a, b, c P)  {}
+func f4(a, b, c, d P) {}
+`}},
+		"example.com/u": {{"u.go", `package u
+
+import "example.com/r"
+
+func U() {
+	r.F1(r.P{})
+	r.F1(r.P{})
+	r.F3(r.P{}, r.P{}, r.P{})
+	var _ r.T4
+}
+`}},
+	}, "example.com/r", "example.com/u")
+	d.AnalyzePackages(nil)
+
+	var format = func(items []RankedItem) string {
+		var ss []string
+		for _, item := range items {
+			name := item.Name
+			if name == "" {
+				name = item.Pkg.Path()
+			}
+			ss = append(ss, fmt.Sprintf("%s:%d", name, item.Count))
+		}
+		return strings.Join(ss, " ")
+	}
+
+	r, u := d.PackageByPath("example.com/r"), d.PackageByPath("example.com/u")
+	r.DepedBys = []*Package{u} // as ParsePackages sets
+	var testCases = []struct {
+		pkgs     []*Package
+		n        int
+		expected [7]string // in the field order of Rankings
+	}{
+		{[]*Package{r}, 10, [7]string{
+			"I:3",
+			"T1:3 T2:2 T3:1",
+			"", "", "",
+			"F3:3 F2:2 F1:1",
+			"P:5 F1:2 F3:1 T4:1",
+		}},
+		{[]*Package{r}, 2, [7]string{
+			"I:3",
+			"T1:3 T2:2",
+			"", "", "",
+			"F3:3 F2:2",
+			"P:5 F1:2",
+		}},
+		{[]*Package{u}, 10, [7]string{
+			"", "", "", "", "", "", "",
+		}},
+		{[]*Package{r, u}, 1, [7]string{
+			"I:3",
+			"T1:3",
+			"example.com/r:18",
+			"example.com/r:30",
+			"example.com/r:1",
+			"F3:3",
+			"P:5",
+		}},
+	}
+	for i, tc := range testCases {
+		rankings := d.RankingsOf(tc.pkgs, tc.n)
+		lists := [7][]RankedItem{
+			rankings.MostImplementedInterfaces,
+			rankings.TypesWithMostMethods,
+			rankings.LargestPackagesByDecls,
+			rankings.LargestPackagesByLines,
+			rankings.MostImportedPackages,
+			rankings.FunctionsWithMostParameters,
+			rankings.MostReferencedIdentifiers,
+		}
+		for k, list := range lists {
+			if s := format(list); s != tc.expected[k] {
+				t.Errorf("case %d, ranking list %d: %q, expected: %q", i, k, s, tc.expected[k])
+			}
+		}
+	}
+}
+
+func TestUnusedExportedIdentifiers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The _test.go file of q is an internal test file,
+	// which is contained in the "q [q.test]" variant.
+	d := checkTestPackages(t, dir, map[string][][2]string{
+		"p": {
+			{"p.go", `package p
+
+type T struct{ F, G int }
+
+func (T) M() {}
+func (T) N() {}
+
+func Unused()   {}
+func ForTests() {}
+`},
+			{"p_test.go", `package p
+
+func TestP(*int) {}
+`},
+		},
+		"q": {
+			{"q.go", `package q
+
+import "p"
+
+var t = p.T{F: 1}
+
+func Q() { t.M() }
+`},
+			{"q_test.go", `package q
+
+import "p"
+
+func TestQ() {
+	p.ForTests()
+	t.N()
+}
+`},
+		},
+	}, "p", "q")
+	d.AnalyzePackages(nil)
+
+	cases := []struct {
+		options UnusedIdentifierOptions
+		unuseds []string
+	}{
+		{UnusedIdentifierOptions{}, []string{"ForTests", "T.G", "T.N", "Unused"}},
+		{UnusedIdentifierOptions{TestPackagesAsUsers: true}, []string{"T.G", "Unused"}},
+	}
+	for _, c := range cases {
+		var unuseds []string
+		for _, item := range d.UnusedExportedIdentifiers(d.packageList[:1], c.options) {
+			unuseds = append(unuseds, item.Name)
+		}
+		if !reflect.DeepEqual(unuseds, c.unuseds) {
+			t.Errorf("unused identifiers for %+v: %v, expected: %v", c.options, unuseds, c.unuseds)
+		}
+	}
+
+	// The uses of the fields and methods of instantiated types are
+	// counted for the generic declarations. (The whole analysis
+	// doesn't support type parameters yet.)
+	d = checkTestPackages(t, dir, map[string][][2]string{
+		"g": {{"g.go", `package g
+
+type G[T any] struct{ F T }
+
+func (G[T]) M() {}
+`}},
+		"h": {{"h.go", `package h
+
+import "g"
+
+func H() {
+	var x g.G[int]
+	x.M()
+	_ = x.F
+}
+`}},
+	}, "g", "h")
+	d.analyzePackage_CountIdentifierUses(d.packageList[1])
+	named := d.packageList[0].PPkg.Types.Scope().Lookup("G").Type().(*types.Named)
+	if n := d.ExportedIdentifierUses(named.Method(0)); n != 1 {
+		t.Errorf("uses of G.M: %d, expected: 1", n)
+	}
+	if n := d.ExportedIdentifierUses(named.Underlying().(*types.Struct).Field(0)); n != 1 {
+		t.Errorf("uses of G.F: %d, expected: 1", n)
+	}
+}
+
+func TestFindUnreachableFunctions(t *testing.T) {
+	const src = `package main
+
+type I interface{ M() }
+
+type T struct{}
+
+func (T) M()      {}
+func (T) unused() {}
+
+var v I = T{}
+
+func helper() {}
+func dead()   { helper() }
+
+func main() {
+	f := helper
+	f()
+	v.M()
+}
+`
+	pkg := newTestPackage(t, src)
+	pkg.Requested = true
+	for _, decl := range pkg.PPkg.Syntax[0].Decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok {
+			f := &Function{Func: pkg.PPkg.TypesInfo.Defs[fd.Name].(*types.Func), Pkg: pkg, AstDecl: fd}
+			pkg.AllFunctions = append(pkg.AllFunctions, f)
+		}
+	}
+
+	d := &CodeAnalyzer{packageList: []*Package{pkg}}
+	scope := pkg.PPkg.Types.Scope()
+	itt := d.RegisterType(scope.Lookup("I").Type())
+	itt.Underlying.ImplementedBys = []*TypeInfo{d.RegisterType(scope.Lookup("T").Type())}
+	d.analyzePackages_FindUnreachableFunctions()
+
+	var unreachables []string
+	for _, f := range d.UnreachableFunctions(d.packageList) {
+		unreachables = append(unreachables, f.Name())
+	}
+	if expected := []string{"unused", "dead"}; !reflect.DeepEqual(unreachables, expected) {
+		t.Errorf("unreachable functions: %v, expected: %v", unreachables, expected)
+	}
+}
+
+func TestDeprecationNote(t *testing.T) {
+	cases := []struct {
+		doc, note string
+	}{
+		{"F does something.\n", ""},
+		{"F does something.\n\nDeprecated: use G\ninstead.\n", "use G instead."},
+		{"Deprecated: use G.\n\nF does something.\n", "use G."},
+		{"F is not Deprecated: really.\n", ""},
+	}
+	for _, c := range cases {
+		if note := DeprecationNote(c.doc); note != c.note {
+			t.Errorf("deprecation note of %q: %q, expected: %q", c.doc, note, c.note)
+		}
+	}
+}
+
+func TestDeprecatedIdentifierUses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := checkTestPackages(t, dir, map[string][][2]string{
+		"p": {{"p.go", `package p
+
+type T struct{}
+
+// Deprecated: use N.
+func (T) M() {}
+func (T) N() {}
+
+type I interface {
+	// Deprecated: use N.
+	M()
+	N()
+}
+`}},
+		"q": {{"q.go", `package q
+
+import "p"
+
+func Q(t p.T, i p.I) {
+	t.M()
+	i.M()
+	t.N()
+}
+`}},
+	}, "p", "q")
+	d.AnalyzePackages(nil)
+
+	for _, tn := range d.packageList[0].AllTypeNames {
+		for _, sel := range tn.Named.AllMethods {
+			note, expected := d.MethodDeprecationNote(sel.Method), ""
+			if sel.Name() == "M" {
+				expected = "use N."
+			}
+			if note != expected {
+				t.Errorf("deprecation note of %s.%s: %q, expected: %q", tn.Name(), sel.Name(), note, expected)
+			}
+		}
+	}
+	if uses := d.DeprecatedIdentifierUses(d.packageList[1:]); len(uses) != 2 {
+		t.Errorf("deprecated identifier uses: %d, expected: 2", len(uses))
+	}
+
+	// The uses of the fields and methods of instantiated types are
+	// checked against the generic declarations. (The whole analysis
+	// doesn't support type parameters yet.)
+	d = checkTestPackages(t, dir, map[string][][2]string{
+		"g": {{"g.go", `package g
+
+type G[T any] struct{ F T }
+
+func (G[T]) M() {}
+`}},
+		"h": {{"h.go", `package h
+
+import "g"
+
+func H() {
+	var x g.G[int]
+	x.M()
+	_ = x.F
+}
+`}},
+	}, "g", "h")
+	for _, pkg := range d.packageList {
+		pkg.PackageAnalyzeResult = NewPackageAnalyzeResult()
+	}
+	named := d.packageList[0].PPkg.Types.Scope().Lookup("G").Type().(*types.Named)
+	d.deprecatedObjects = map[types.Object]string{
+		named.Method(0): "use N.",
+		named.Underlying().(*types.Struct).Field(0): "use E.",
+	}
+	var notes []string
+	for _, use := range d.DeprecatedIdentifierUses(d.packageList[1:]) {
+		notes = append(notes, use.Note)
+	}
+	if expected := []string{"use N.", "use E."}; !reflect.DeepEqual(notes, expected) {
+		t.Errorf("deprecated identifier uses: %v, expected: %v", notes, expected)
+	}
+	for id, obj := range d.packageList[1].PPkg.TypesInfo.Uses {
+		if id.Name == "M" && d.DeprecationNoteOf(obj) != "use N." {
+			t.Errorf("deprecation note of instantiated method M: %q, expected: %q", d.DeprecationNoteOf(obj), "use N.")
+		}
+	}
+}
+
+func TestAnalyzeIllTypedPackage(t *testing.T) {
+	const src = `package p
+
+type T struct {
+	x Undefined
+	y int
+}
+
+func (t T) M() Undefined     { return t.x }
+func (t *T) N() int          { return t.y + undefinedFunc() }
+func (t T) Q(x undefined.X) {}
+
+type I interface {
+	M() Undefined
+	N() int
+	Missing
+	undefined.Y
+}
+
+type J interface {
+	M()
+	M() int
+}
+
+type K interface {
+	I
+	M() string
+}
+
+type S struct {
+	a int
+	a string
+	T
+	*T
+}
+
+func (S) P()    {}
+func (S) P(int) {}
+
+type U Undefined
+type V T
+type X I
+
+var v = undefinedVar
+var w T = 1
+
+const c Undefined = 1
+const d = "a" + 1
+
+func F(x Undefined, y T) (I, int) { return y, 0 }
+
+func G() {
+	var i I = &T{}
+	i.M()
+	_ = i.(interface{ Bad() Undefined })
+}
+`
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "p.go")
+	if err := ioutil.WriteFile(filename, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkg := checkTestPackage(t, filename, src, true)
+	pkg.PPkg.GoFiles = []string{filename}
+	pkg.PPkg.CompiledGoFiles = []string{filename}
+	pkg.PackageAnalyzeResult = nil // will be set in AnalyzePackages
+	analyzer := &CodeAnalyzer{
+		packageList:  []*Package{pkg},
+		packageTable: map[string]*Package{pkg.Path(): pkg},
+	}
+	analyzer.AnalyzePackages(nil)
+
+	if n := len(pkg.AllTypeNames); n != 8 {
+		t.Errorf("expected 8 type names, got %d", n)
+	}
+}
+
 // ToDo: also check method signatures.
 // There is a bug in std types.MethodSet implementation (Go SDK 1.14-)
 // https://github.com/golang/go/issues/37081
 // Luckily, his test is okay to test with the results of standard packages.
 func TestAnalyzeStandardPackage(t *testing.T) {
 	var analyzer CodeAnalyzer
-	analyzer.ParsePackages(nil, "std")
+	analyzer.ParsePackages(nil, ParseOptions{}, "std")
 	analyzer.AnalyzePackages(nil)
 
 	var cache = &typeutil.MethodSetCache{}
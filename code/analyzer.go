@@ -3,17 +3,21 @@ package code
 import (
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/token"
 	"go/types"
 	"log"
 	"reflect"
 	"strings"
+	"sync"
 
 	"golang.org/x/tools/go/types/typeutil"
 )
 
 const (
-	SubTask_PreparationDone = iota
+	SubTask_ListModules = iota
+	SubTask_DownloadModule
+	SubTask_PreparationDone
 	SubTask_NFilesParsed
 	SubTask_ParsePackagesDone
 	SubTask_CollectPackages
@@ -27,12 +31,19 @@ const (
 	SubTask_RegisterInterfaceMethodsForTypes
 	SubTask_MakeStatistics
 	SubTask_CollectSourceFiles
+	SubTask_FindUnreachableFunctions
 )
 
 type CodeAnalyzer struct {
 	allModules []*Module
 	stdModule  *Module
 
+	// Modules specified by "path@version" arguments.
+	requestedModules []*Module
+
+	// Used to find the source files excluded by the build configuration.
+	buildContext *build.Context
+
 	//stdPackages  map[string]struct{}
 	packageTable map[string]*Package
 	packageList  []*Package
@@ -52,6 +63,10 @@ type CodeAnalyzer struct {
 	ttype2TypeInfoTable typeutil.Map
 	allTypeInfos        []*TypeInfo
 
+	// Types might be still registered when building pages concurrently.
+	// Note: typeutil.Map.At also modifies the map (the hasher cache).
+	typesMutex sync.Mutex
+
 	// Package-level declared type names.
 	lastTypeNameIndex uint32
 	allTypeNameTable  map[string]*TypeName
@@ -66,7 +81,14 @@ type CodeAnalyzer struct {
 	// Why not put []RefPos in TypeInfo, Variable, ...?
 	refPositions map[interface{}][]RefPos
 
-	// Not concurrent safe.
+	// Uses of exported identifiers (package-level ones, fields
+	// and methods) in other packages, by user kinds.
+	exportedIdentifierUses map[types.Object]identifierUses
+
+	// Deprecation notes of deprecated identifiers.
+	deprecatedObjects map[types.Object]string
+
+	// Not concurrent safe. Only used in the analyze phase.
 	tempTypeLookup map[uint32]struct{}
 
 	stats Stats
@@ -87,9 +109,10 @@ type Stats struct {
 
 	FilesWithoutGenerateds int32 // without generated ones
 	FilesWithGenerateds    int32 // with generated ones
-	CodeLines              int32 // ToDo: stat code lines. Use the info in AstFile?
+	CodeLines              int32 // not including generated files
 	BlankCodeLines         int32
 	CommentCodeLines       int32
+	GeneratedCodeLines     int32 // all lines in generated files
 
 	// To calculate imports per file.
 	// Deps per packages are available in other ways.
@@ -162,6 +185,25 @@ type Stats struct {
 	ExportedIdentifers          int32
 	ExportedIdentifersSumLength int32
 	ExportedIdentifiersByLength [100]int32
+
+	// Whole code, including unexported and local resources.
+	UnexportedTypeNames       int32 // package-level
+	UnexportedTypeNamesByKind [KindCount]int32
+	LocalTypeNames            int32
+	LocalTypeNamesByKind      [KindCount]int32
+	UnexportedVariables       int32 // package-level
+	UnexportedConstants       int32 // package-level
+	UnexportedFunctions       int32
+	UnexportedMethods         int32 // of exported types
+	MethodsOfUnexportedTypes  int32
+	FunctionLiterals          int32 // closures
+	FunctionTypedVariables    int32 // package-level and local, not including parameters and fields
+
+	AllFunctions                 int32      // including methods and function literals
+	AllFunctionParameters        int32      // including methods and function literals
+	AllFunctionResults           int32      // including methods and function literals
+	AllFunctionsByParameterCount [100]int32 // including methods and function literals
+	AllFunctionsByResultCount    [100]int32 // including methods and function literals
 }
 
 func incSliceStat(stats []int32, index int) {
@@ -199,6 +241,23 @@ func (d *CodeAnalyzer) resetTempTypeLookupTable() {
 	}
 }
 
+// NewCodeAnalyzerOf creates an analyzer with the specified packages
+// registered, as if they were parsed. The packages in the module with
+// a blank root are viewed as standard packages. Mainly for testing.
+func NewCodeAnalyzerOf(pkgs ...*Package) *CodeAnalyzer {
+	d := &CodeAnalyzer{
+		packageList:  pkgs,
+		packageTable: make(map[string]*Package, len(pkgs)),
+	}
+	for _, pkg := range pkgs {
+		d.packageTable[pkg.Path()] = pkg
+		if pkg.Mod != nil && pkg.Mod.Root == "" {
+			d.stdModule = pkg.Mod
+		}
+	}
+	return d
+}
+
 func (d *CodeAnalyzer) NumPackages() int {
 	return len(d.packageList)
 }
@@ -212,6 +271,11 @@ func (d *CodeAnalyzer) PackageByPath(path string) *Package {
 	return d.packageTable[path]
 }
 
+// RequestedModules returns the modules specified by "path@version" arguments.
+func (d *CodeAnalyzer) RequestedModules() []*Module {
+	return d.requestedModules
+}
+
 func (d *CodeAnalyzer) IsStandardPackage(pkg *Package) bool {
 	return pkg.Mod == d.stdModule
 }
@@ -315,7 +379,14 @@ func (d *CodeAnalyzer) RegisterType(t types.Type) *TypeInfo {
 	return d.TryRegisteringType(t, true)
 }
 
+// TryRegisteringType is concurrent safe.
 func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *TypeInfo {
+	d.typesMutex.Lock()
+	defer d.typesMutex.Unlock()
+	return d.tryRegisteringType(t, createOnNonexist)
+}
+
+func (d *CodeAnalyzer) tryRegisteringType(t types.Type, createOnNonexist bool) *TypeInfo {
 	typeInfo, _ := d.ttype2TypeInfoTable.At(t).(*TypeInfo)
 	if typeInfo == nil && createOnNonexist {
 		if d.forbidRegisterTypes {
@@ -339,7 +410,7 @@ func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *
 		case *types.Named:
 			//typeInfo.Name = t.Obj().Name()
 
-			underlying := d.RegisterType(t.Underlying())
+			underlying := d.tryRegisteringType(t.Underlying(), true)
 			typeInfo.Underlying = underlying
 			//underlying.Underlying = underlying // already done
 		default:
@@ -353,7 +424,7 @@ func (d *CodeAnalyzer) TryRegisteringType(t types.Type, createOnNonexist bool) *
 			// Pointers of interfaces are not important.
 		default:
 			// *T might have methods if T is neigher an interface nor pointer type.
-			d.RegisterType(types.NewPointer(t))
+			d.tryRegisteringType(types.NewPointer(t), true)
 		}
 	}
 	return typeInfo
@@ -418,8 +489,9 @@ func (d *CodeAnalyzer) CleanImplements(self *TypeInfo) []Implementation {
 	// * unnameds whose underlied names are also in the list (or are self)
 	// The ones in internal packages are kept.
 
-	typeLookupTable := d.tempTypeLookupTable()
-	defer d.resetTempTypeLookupTable()
+	// This function is called when building pages, so the shared
+	// temp lookup table can't be used here.
+	typeLookupTable := make(map[uint32]struct{}, len(self.Implements)*2+2)
 
 	if itt, ok := self.TT.Underlying().(*types.Interface); ok {
 		typeLookupTable[self.index] = struct{}{}
@@ -812,15 +884,27 @@ func (d *CodeAnalyzer) registerExplicitlySpecifiedMethods(typeInfo *TypeInfo, as
 				panic("not a valid embedding interface type name")
 			case *ast.Ident:
 				ttn := pkg.PPkg.TypesInfo.Uses[expr]
+				if ttn == nil && pkg.PPkg.IllTyped {
+					continue // errors tolerated
+				}
 				id = d.Id2(ttn.Pkg(), ttn.Name())
 			case *ast.SelectorExpr:
 				srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-				srcPkg := srcObj.(*types.PkgName)
+				srcPkg, ok := srcObj.(*types.PkgName)
+				if !ok {
+					if pkg.PPkg.IllTyped {
+						continue // errors tolerated
+					}
+					panic(fmt.Sprintf("not a types.PkgName: %[1]v, %[1]T", srcObj))
+				}
 				id = d.Id2(srcPkg.Imported(), expr.Sel.Name)
 			}
 
 			tn := d.allTypeNameTable[id]
 			if tn == nil {
+				if pkg.PPkg.IllTyped {
+					continue // errors tolerated
+				}
 				panic("TypeName for " + id + " not found")
 			}
 
@@ -930,6 +1014,7 @@ func (d *CodeAnalyzer) registerExplicitlyDeclaredMethod(f *Function) {
 		Type:        d.RegisterType(funcObj.Type()),
 		PointerRecv: ptrRecv,
 		AstFunc:     funcDecl,
+		Function:    f,
 	}
 	if !token.IsExported(funcName) {
 		method.Pkg = pkg
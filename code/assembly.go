@@ -0,0 +1,307 @@
+package code
+
+import (
+	"bytes"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// An AsmFunction is a function implemented in Go assembly,
+// declared by a TEXT directive in a .s file.
+type AsmFunction struct {
+	Name     string // without the package prefix
+	Position token.Position
+}
+
+func (r *PackageAnalyzeResult) AsmFunctionByName(name string) *AsmFunction {
+	if i, ok := r.asmFunctionIndexes[name]; ok {
+		return &r.AsmFunctions[i]
+	}
+	return nil
+}
+
+// AsmImplementation returns the assembly implementation
+// of a package-level function which has no body.
+func (f *Function) AsmImplementation() *AsmFunction {
+	if f.AstDecl == nil || f.AstDecl.Body != nil || f.AstDecl.Recv != nil || f.Pkg == nil {
+		return nil
+	}
+	return f.Pkg.AsmFunctionByName(f.Name())
+}
+
+// collectAsmFunctions finds the functions declared
+// by TEXT directives in the .s files of a package.
+func (d *CodeAnalyzer) collectAsmFunctions(pkg *Package) {
+	for _, path := range pkg.PPkg.OtherFiles {
+		if filepath.Ext(path) != ".s" {
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Println("collectAsmFunctions:", err)
+			continue
+		}
+
+		line := 1
+		lastOffset := 0
+		tokens := ScanAsmTokens(content)
+		for i, t := range tokens {
+			if t.Kind != AsmToken_Instruction || string(content[t.Start:t.End]) != "TEXT" {
+				continue
+			}
+			if i+1 >= len(tokens) || tokens[i+1].Kind != AsmToken_Symbol {
+				continue
+			}
+			pkgPath, name := ParseAsmSymbol(content[tokens[i+1].Start:tokens[i+1].End])
+			if pkgPath != "" && pkgPath != pkg.Path() || name == "" {
+				continue
+			}
+			if _, ok := pkg.asmFunctionIndexes[name]; ok {
+				continue
+			}
+
+			line += bytes.Count(content[lastOffset:t.Start], []byte{'\n'})
+			lastOffset = t.Start
+			if pkg.asmFunctionIndexes == nil {
+				pkg.asmFunctionIndexes = make(map[string]int, 32)
+			}
+			pkg.asmFunctionIndexes[name] = len(pkg.AsmFunctions)
+			pkg.AsmFunctions = append(pkg.AsmFunctions, AsmFunction{
+				Name: name,
+				Position: token.Position{
+					Filename: path,
+					Offset:   t.Start,
+					Line:     line,
+					Column:   t.Start - bytes.LastIndexByte(content[:t.Start], '\n'),
+				},
+			})
+		}
+	}
+}
+
+// ParseAsmSymbol splits an assembly symbol, like "math∕big·addVV",
+// into a package path and a name. The package path is blank for
+// the symbols in the current package, like "·addVV".
+func ParseAsmSymbol(symbol []byte) (pkgPath, name string) {
+	s := string(symbol)
+	if i := strings.IndexByte(s, '<'); i >= 0 {
+		s = s[:i] // ABI selectors, like "<ABIInternal>"
+	}
+	i := strings.LastIndex(s, "·")
+	if i < 0 {
+		return "", ""
+	}
+	return strings.Replace(s[:i], "∕", "/", -1), s[i+len("·"):]
+}
+
+//==================================
+
+type AsmTokenKind uint8
+
+const (
+	AsmToken_Punct AsmTokenKind = iota
+	AsmToken_Ident
+	AsmToken_Number
+	AsmToken_String
+	AsmToken_Comment
+	AsmToken_Directive // like "#include", with the leading "#"
+	AsmToken_Instruction
+	AsmToken_Register
+	AsmToken_PseudoRegister // FP, SB, SP and PC
+	AsmToken_Symbol         // names containing "·"
+	AsmToken_Label
+)
+
+type AsmToken struct {
+	Kind       AsmTokenKind
+	Start, End int // offsets
+}
+
+var asmPseudoRegisters = map[string]bool{
+	"FP": true, "SB": true, "SP": true, "PC": true,
+}
+
+var asmRegisters = map[string]bool{
+	"AX": true, "BX": true, "CX": true, "DX": true, "SI": true, "DI": true, "BP": true,
+	"AL": true, "BL": true, "CL": true, "DL": true, "AH": true, "BH": true, "CH": true, "DH": true,
+	"SIB": true, "DIB": true, "BPB": true, "SPB": true,
+	"LR": true, "ZR": true, "RSP": true, "TLS": true, "CTR": true, "XER": true, "RARG": true,
+	"g": true, "FPCR": true, "FPSR": true, "NZCV": true,
+}
+
+// isAsmRegister checks names like "R12", "X3", "Y15", "R8L" and "AX".
+func isAsmRegister(name string) bool {
+	if asmRegisters[name] {
+		return true
+	}
+	i := 0
+	for i < len(name) && 'A' <= name[i] && name[i] <= 'Z' {
+		i++
+	}
+	if i == 0 || i > 2 || i == len(name) {
+		return false
+	}
+	k := i
+	for k < len(name) && '0' <= name[k] && name[k] <= '9' {
+		k++
+	}
+	if k == i {
+		return false
+	}
+	switch name[k:] {
+	case "", "B", "W", "L":
+		return true
+	}
+	return false
+}
+
+func isAsmIdentRune(r rune, first bool) bool {
+	switch {
+	case r == '_' || r == '·' || r == '∕':
+		return true
+	case 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+		return true
+	case '0' <= r && r <= '9':
+		return !first
+	}
+	return false
+}
+
+// ScanAsmTokens splits Go assembly code into tokens, for highlighting
+// and finding TEXT symbols only. Whitespaces are not included.
+func ScanAsmTokens(src []byte) []AsmToken {
+	var tokens = make([]AsmToken, 0, len(src)/4)
+	var stmtStart = true // no tokens before in the current statement
+	var lineStart = true // only whitespaces before in the current line
+	for i := 0; i < len(src); {
+		b := src[i]
+		switch {
+		case b == '\n':
+			lineStart, stmtStart = true, true
+			i++
+			continue
+		case b == ';':
+			stmtStart = true
+			tokens = append(tokens, AsmToken{Kind: AsmToken_Punct, Start: i, End: i + 1})
+			i++
+			continue
+		case b == ' ' || b == '\t' || b == '\r' || b == '\f' || b == '\v':
+			i++
+			continue
+		case b == '\\' && i+1 < len(src) && (src[i+1] == '\n' || src[i+1] == '\r'):
+			// Line continuation in macros. The next line
+			// is viewed as a new statement.
+			stmtStart = true
+			i += 2
+			continue
+		}
+
+		start := i
+		kind := AsmToken_Punct
+		r, size := utf8.DecodeRune(src[i:])
+		switch {
+		case b == '/' && i+1 < len(src) && src[i+1] == '/':
+			kind = AsmToken_Comment
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i > start && src[i-1] == '\r' {
+				i--
+			}
+		case b == '/' && i+1 < len(src) && src[i+1] == '*':
+			kind = AsmToken_Comment
+			if k := bytes.Index(src[i+2:], []byte("*/")); k >= 0 {
+				i += 2 + k + 2
+			} else {
+				i = len(src)
+			}
+		case b == '#' && lineStart:
+			kind = AsmToken_Directive
+			i++
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+				i++
+			}
+			for i < len(src) && isCIdentByte(src[i], false) {
+				i++
+			}
+			if string(bytes.TrimSpace(src[start+1:i])) == "include" {
+				// Treat the included file as a string.
+				tokens = append(tokens, AsmToken{Kind: kind, Start: start, End: i})
+				for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+					i++
+				}
+				start = i
+				if i < len(src) && (src[i] == '"' || src[i] == '<') {
+					kind = AsmToken_String
+					for i++; i < len(src) && src[i] != '"' && src[i] != '>' && src[i] != '\n'; i++ {
+					}
+					if i < len(src) && src[i] != '\n' {
+						i++
+					}
+				}
+				if start == i {
+					continue
+				}
+			}
+		case b == '"' || b == '\'':
+			kind = AsmToken_String
+			for i++; i < len(src) && src[i] != b && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i < len(src) && src[i] == b {
+				i++
+			}
+		case '0' <= b && b <= '9':
+			kind = AsmToken_Number
+			for i++; i < len(src) && (isCIdentByte(src[i], false) || src[i] == '.'); i++ {
+			}
+		case isAsmIdentRune(r, true):
+			for i += size; i < len(src); i += size {
+				r, size = utf8.DecodeRune(src[i:])
+				if !isAsmIdentRune(r, false) {
+					break
+				}
+			}
+			name := string(src[start:i])
+			switch {
+			case strings.Contains(name, "·"):
+				kind = AsmToken_Symbol
+				// ABI selectors, like "<ABIInternal>".
+				if i+4 < len(src) && bytes.HasPrefix(src[i:], []byte("<ABI")) {
+					if k := bytes.IndexByte(src[i:], '>'); k >= 0 {
+						i += k + 1
+					}
+				}
+			case stmtStart && i < len(src) && src[i] == ':':
+				kind = AsmToken_Label
+				i++
+			case stmtStart:
+				kind = AsmToken_Instruction
+			case asmPseudoRegisters[name]:
+				kind = AsmToken_PseudoRegister
+			case isAsmRegister(name):
+				kind = AsmToken_Register
+			default:
+				kind = AsmToken_Ident
+			}
+		default:
+			i += size
+		}
+		if i > len(src) {
+			i = len(src)
+		}
+
+		lineStart = false
+		if kind != AsmToken_Comment && kind != AsmToken_Label {
+			stmtStart = false
+		}
+		tokens = append(tokens, AsmToken{Kind: kind, Start: start, End: i})
+	}
+	return tokens
+}
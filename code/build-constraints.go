@@ -0,0 +1,218 @@
+package code
+
+import (
+	"bytes"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// An IgnoredFileInfo describes a source file in a package directory
+// which is excluded by the current build configuration.
+type IgnoredFileInfo struct {
+	BareFilename string
+	File         string // full path
+
+	// Why the file is excluded: "filename" (GOOS/GOARCH file name
+	// suffixes), "constraint" (build constraints) or "cgo" (cgo is disabled).
+	Reason string
+
+	BuildConstraint string
+}
+
+// The extensions of the source files which might be excluded by build constraints.
+var sourceFileExts = map[string]bool{
+	".go": true,
+	".s":  true, ".S": true, ".sx": true,
+	".c": true, ".h": true,
+	".cc": true, ".cpp": true, ".cxx": true,
+	".hh": true, ".hpp": true, ".hxx": true,
+	".m": true,
+}
+
+// buildContextFor returns the build context corresponding to the options.
+func buildContextFor(options *ParseOptions) *build.Context {
+	ctxt := build.Default
+	ctxt.GOOS, ctxt.GOARCH = options.Target()
+	ctxt.BuildTags = options.BuildTags
+
+	cgo, ok := options.getenv("CGO_ENABLED")
+	if !ok {
+		cgo = options.CgoEnabled
+	}
+	switch {
+	case cgo != "":
+		ctxt.CgoEnabled = cgo == "1"
+	case ctxt.GOOS != build.Default.GOOS || ctxt.GOARCH != build.Default.GOARCH:
+		// The go command disables cgo by default when cross compiling.
+		ctxt.CgoEnabled = false
+	}
+	return &ctxt
+}
+
+// collectIgnoredFiles finds the source files in the directory of the
+// package which are excluded by the current build configuration.
+// Test files are not considered.
+func (d *CodeAnalyzer) collectIgnoredFiles(pkg *Package) {
+	if d.buildContext == nil || pkg.Path() == "builtin" || pkg.Path() == "unsafe" {
+		return
+	}
+
+	var dir string
+	var includeds = make(map[string]bool, len(pkg.SourceFiles))
+	for _, info := range pkg.SourceFiles {
+		if info.OriginalFile != "" {
+			dir = filepath.Dir(info.OriginalFile)
+			includeds[info.BareFilename] = true
+		}
+	}
+	if dir == "" {
+		return
+	}
+
+	fileInfos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		log.Println("collectIgnoredFiles:", err)
+		return
+	}
+
+	ctxt := d.buildContext
+	for _, fi := range fileInfos {
+		name := fi.Name()
+		if fi.IsDir() || includeds[name] || !sourceFileExts[filepath.Ext(name)] {
+			continue
+		}
+		// Files prefixed with "_" or "." are always ignored.
+		if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		var reason string
+		if ok, err := ctxt.MatchFile(dir, name); err != nil {
+			continue
+		} else if ok {
+			if filepath.Ext(name) != ".go" || ctxt.CgoEnabled || !importsC(path) {
+				continue // not ignored, maybe it belongs to another package.
+			}
+			reason = "cgo"
+		} else if !matchFilename(ctxt, dir, name) {
+			reason = "filename"
+		} else {
+			reason = "constraint"
+		}
+
+		pkg.IgnoredFiles = append(pkg.IgnoredFiles, IgnoredFileInfo{
+			BareFilename:    name,
+			File:            path,
+			Reason:          reason,
+			BuildConstraint: readBuildConstraint(path),
+		})
+	}
+}
+
+// matchFilename checks the GOOS/GOARCH suffixes of the file name only.
+func matchFilename(ctxt *build.Context, dir, name string) bool {
+	nameCtxt := *ctxt
+	nameCtxt.OpenFile = func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(strings.NewReader("package p\n")), nil
+	}
+	ok, _ := nameCtxt.MatchFile(dir, name)
+	return ok
+}
+
+func importsC(filename string) bool {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.ImportsOnly)
+	if err != nil {
+		return false
+	}
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path == "C" {
+			return true
+		}
+	}
+	return false
+}
+
+func readBuildConstraint(filename string) string {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		log.Println("readBuildConstraint:", err)
+		return ""
+	}
+	return buildConstraintOf(content)
+}
+
+// buildConstraintOf returns the build constraint in the leading comments
+// of a source file. A "//go:build" line is preferred to "// +build" lines.
+func buildConstraintOf(content []byte) string {
+	var plusBuilds []string
+	for inBlock := false; len(content) > 0; {
+		var line []byte
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			line, content = content[:i], content[i+1:]
+		} else {
+			line, content = content, nil
+		}
+		line = bytes.TrimSpace(line)
+
+		if inBlock {
+			if i := bytes.Index(line, []byte("*/")); i >= 0 {
+				inBlock = false
+				line = bytes.TrimSpace(line[i+2:])
+			} else {
+				continue
+			}
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("/*")) {
+			if !bytes.Contains(line[2:], []byte("*/")) {
+				inBlock = true
+			}
+			continue
+		}
+		if !bytes.HasPrefix(line, []byte("//")) {
+			break
+		}
+
+		if bytes.HasPrefix(line, []byte("//go:build ")) {
+			return string(line)
+		}
+		if text := bytes.TrimSpace(line[2:]); bytes.HasPrefix(text, []byte("+build ")) {
+			plusBuilds = append(plusBuilds, "// "+string(text))
+		}
+	}
+	return strings.Join(plusBuilds, "; ")
+}
+
+// astFileBuildConstraint is like buildConstraintOf, but it uses
+// the comments (before the package clause) in an ast file.
+func astFileBuildConstraint(file *ast.File) string {
+	var plusBuilds []string
+	for _, cg := range file.Comments {
+		if cg.Pos() >= file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if !strings.HasPrefix(c.Text, "//") {
+				continue
+			}
+			if strings.HasPrefix(c.Text, "//go:build ") {
+				return strings.TrimSpace(c.Text)
+			}
+			if text := strings.TrimSpace(c.Text[2:]); strings.HasPrefix(text, "+build ") {
+				plusBuilds = append(plusBuilds, "// "+text)
+			}
+		}
+	}
+	return strings.Join(plusBuilds, "; ")
+}
@@ -0,0 +1,588 @@
+package code
+
+import (
+	"bytes"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A CgoDeclaration is a C declaration in a cgo preamble or
+// in a local header file, which might be referenced in Go code.
+type CgoDeclaration struct {
+	Name     string // as referenced in Go code, like "foo" and "struct_bar"
+	Kind     string // "func", "var", "type", "struct", "union", "enum", "enumerator" or "macro"
+	Position token.Position
+}
+
+// A CgoSymbol is a C symbol referenced as C.Name in Go code.
+type CgoSymbol struct {
+	Name string
+	Kind string // "func", "type", "var", "const" or "macro", by how it is used.
+	Uses int
+
+	// Nil for the symbols declared in system headers or provided by cgo.
+	Declaration *CgoDeclaration
+}
+
+// The prefixes cmd/cgo uses to rewrite C.xxx references.
+var cgoIdentPrefixes = []struct {
+	prefix, kind string
+}{
+	{"_Cfunc_", "func"},
+	{"_Ctype_", "type"},
+	{"_Cvar_", "var"},
+	{"_Cmacro_", "macro"},
+	{"_Ciconst_", "const"},
+	{"_Cfconst_", "const"},
+	{"_Csconst_", "const"},
+}
+
+// CgoSymbolOfIdent returns the C name and kind of an identifier
+// which is rewritten by cmd/cgo from a C.xxx reference.
+// A blank name is returned if the identifier is not such one.
+func CgoSymbolOfIdent(ident string) (name, kind string) {
+	if !strings.HasPrefix(ident, "_C") {
+		return "", ""
+	}
+	for _, p := range cgoIdentPrefixes {
+		if strings.HasPrefix(ident, p.prefix) {
+			return ident[len(p.prefix):], p.kind
+		}
+	}
+	return "", ""
+}
+
+func (r *PackageAnalyzeResult) CgoDeclarationByName(name string) *CgoDeclaration {
+	if i, ok := r.cgoDeclarationIndexes[name]; ok {
+		return &r.CgoDeclarations[i]
+	}
+	return nil
+}
+
+// collectCgoSymbols finds the C declarations in the cgo preambles and
+// local header files of a package, and the C symbols used in its Go files.
+// It must be called after BuildCgoFileMappings.
+func (d *CodeAnalyzer) collectCgoSymbols(pkg *Package) {
+	var uses = make(map[string]*CgoSymbol)
+	var register = func(decl CgoDeclaration) {
+		// The first declaration wins, so that the ones in preambles
+		// are preferred to the ones in header files.
+		if _, ok := pkg.cgoDeclarationIndexes[decl.Name]; ok {
+			return
+		}
+		if pkg.cgoDeclarationIndexes == nil {
+			pkg.cgoDeclarationIndexes = make(map[string]int, 64)
+		}
+		pkg.cgoDeclarationIndexes[decl.Name] = len(pkg.CgoDeclarations)
+		pkg.CgoDeclarations = append(pkg.CgoDeclarations, decl)
+	}
+
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		if info.AstFile == nil || info.OriginalFile == "" || info.GeneratedFile == info.OriginalFile {
+			continue // not a cgo file
+		}
+
+		info.CgoPreambles = findCgoPreambles(info.AstFile)
+		if len(info.CgoPreambles) > 0 {
+			content, err := ioutil.ReadFile(info.GeneratedFile)
+			if err != nil {
+				log.Println("collectCgoSymbols:", err)
+				continue
+			}
+			file := pkg.PPkg.Fset.File(info.AstFile.Pos())
+			if file.Size() != len(content) {
+				continue
+			}
+			for _, cg := range info.CgoPreambles {
+				start := file.Offset(cg.Pos())
+				preamble, _ := CgoPreambleContent(content, file, cg)
+				for _, decl := range collectCDeclarations(preamble, ScanCTokens(preamble)) {
+					// The //line directives in the generated file
+					// map the positions to the original Go file.
+					register(CgoDeclaration{
+						Name:     decl.name,
+						Kind:     decl.kind,
+						Position: pkg.PPkg.Fset.PositionFor(file.Pos(start+decl.offset), true),
+					})
+				}
+			}
+		}
+
+		ast.Inspect(info.AstFile, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				if name, kind := CgoSymbolOfIdent(ident.Name); name != "" {
+					if sym := uses[name]; sym != nil {
+						sym.Uses++
+					} else {
+						uses[name] = &CgoSymbol{Name: name, Kind: kind, Uses: 1}
+					}
+				}
+			}
+			return true
+		})
+	}
+
+	if len(uses) == 0 {
+		return
+	}
+
+	for _, path := range pkg.PPkg.OtherFiles {
+		if filepath.Ext(path) != ".h" {
+			continue
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			log.Println("collectCgoSymbols:", err)
+			continue
+		}
+		lineStarts := []int{0}
+		for i, b := range content {
+			if b == '\n' {
+				lineStarts = append(lineStarts, i+1)
+			}
+		}
+		for _, decl := range collectCDeclarations(content, ScanCTokens(content)) {
+			line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > decl.offset })
+			register(CgoDeclaration{
+				Name: decl.name,
+				Kind: decl.kind,
+				Position: token.Position{
+					Filename: path,
+					Offset:   decl.offset,
+					Line:     line,
+					Column:   decl.offset - lineStarts[line-1] + 1,
+				},
+			})
+		}
+	}
+
+	pkg.CgoSymbols = make([]CgoSymbol, 0, len(uses))
+	for _, sym := range uses {
+		sym.Declaration = pkg.CgoDeclarationByName(sym.Name)
+		pkg.CgoSymbols = append(pkg.CgoSymbols, *sym)
+	}
+	sort.Slice(pkg.CgoSymbols, func(i, j int) bool {
+		return pkg.CgoSymbols[i].Name < pkg.CgoSymbols[j].Name
+	})
+}
+
+// findCgoPreambles returns the preamble comments of the `import "C"`
+// declarations in a file. In the files generated by cmd/cgo, such
+// declarations have been rewritten as `import _ "unsafe"`.
+func findCgoPreambles(file *ast.File) []*ast.CommentGroup {
+	var preambles []*ast.CommentGroup
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			imp := spec.(*ast.ImportSpec)
+			path, _ := strconv.Unquote(imp.Path.Value)
+			if path != "C" && (path != "unsafe" || imp.Name == nil || imp.Name.Name != "_") {
+				continue
+			}
+			cg := imp.Doc
+			if cg == nil && len(genDecl.Specs) == 1 {
+				cg = genDecl.Doc
+			}
+			if cg != nil {
+				preambles = append(preambles, cg)
+			}
+		}
+	}
+	return preambles
+}
+
+// CgoPreambleContent returns the C code in a preamble comment group.
+// The comment markers are replaced with spaces, so that the offsets
+// in the result are the same as the ones in the comment group.
+// The offsets of the comment markers are also returned, in pairs.
+func CgoPreambleContent(content []byte, file *token.File, cg *ast.CommentGroup) (preamble []byte, markers []int) {
+	start := file.Offset(cg.Pos())
+	preamble = append([]byte(nil), content[start:file.Offset(cg.End())]...)
+	for _, c := range cg.List {
+		offset := file.Offset(c.Pos()) - start
+		markers = append(markers, offset, offset+2)
+		preamble[offset], preamble[offset+1] = ' ', ' '
+		if strings.HasPrefix(c.Text, "/*") {
+			end := file.Offset(c.End()) - start
+			markers = append(markers, end-2, end)
+			preamble[end-2], preamble[end-1] = ' ', ' '
+		}
+	}
+	return preamble, markers
+}
+
+//==================================
+
+type CTokenKind uint8
+
+const (
+	CToken_Punct CTokenKind = iota
+	CToken_Ident
+	CToken_Keyword
+	CToken_Number
+	CToken_String
+	CToken_Comment
+	CToken_Directive // like "#include", with the leading "#"
+)
+
+type CToken struct {
+	Kind       CTokenKind
+	Start, End int // offsets
+}
+
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true,
+	"continue": true, "default": true, "do": true, "double": true, "else": true,
+	"enum": true, "extern": true, "float": true, "for": true, "goto": true,
+	"if": true, "inline": true, "int": true, "long": true, "register": true,
+	"restrict": true, "return": true, "short": true, "signed": true, "sizeof": true,
+	"static": true, "struct": true, "switch": true, "typedef": true, "union": true,
+	"unsigned": true, "void": true, "volatile": true, "while": true,
+	"_Bool": true, "_Complex": true, "_Atomic": true, "_Noreturn": true,
+	"_Alignas": true, "_Alignof": true, "_Static_assert": true, "_Thread_local": true,
+	"__attribute__": true, "__inline__": true, "__restrict": true,
+}
+
+func isCIdentByte(b byte, first bool) bool {
+	return b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || !first && '0' <= b && b <= '9'
+}
+
+// ScanCTokens splits C source code into tokens. It is a lightweight
+// tokenizer for highlighting and finding declarations only, so
+// preprocessor directives are not processed. Whitespaces and line
+// continuations are not included in the result.
+func ScanCTokens(src []byte) []CToken {
+	var tokens = make([]CToken, 0, len(src)/4)
+	var lineStart = true // only whitespaces before in the current line
+	var inInclude = false
+	for i := 0; i < len(src); {
+		b := src[i]
+		switch {
+		case b == '\n':
+			lineStart, inInclude = true, false
+			i++
+			continue
+		case b == ' ' || b == '\t' || b == '\r' || b == '\f' || b == '\v':
+			i++
+			continue
+		case b == '\\' && i+1 < len(src) && (src[i+1] == '\n' || src[i+1] == '\r'):
+			i += 2 // line continuation
+			continue
+		}
+
+		start := i
+		kind := CToken_Punct
+		switch {
+		case b == '/' && i+1 < len(src) && src[i+1] == '/':
+			kind = CToken_Comment
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i > start && src[i-1] == '\r' {
+				i--
+			}
+		case b == '/' && i+1 < len(src) && src[i+1] == '*':
+			kind = CToken_Comment
+			if k := bytes.Index(src[i+2:], []byte("*/")); k >= 0 {
+				i += 2 + k + 2
+			} else {
+				i = len(src)
+			}
+		case b == '#' && lineStart:
+			kind = CToken_Directive
+			i++
+			for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+				i++
+			}
+			nameStart := i
+			for i < len(src) && isCIdentByte(src[i], false) {
+				i++
+			}
+			inInclude = string(src[nameStart:i]) == "include"
+		case b == '"' || b == '\'' || b == '<' && inInclude:
+			kind = CToken_String
+			end := b
+			if b == '<' {
+				end = '>'
+			}
+			for i++; i < len(src) && src[i] != end && src[i] != '\n'; i++ {
+				if src[i] == '\\' {
+					i++
+				}
+			}
+			if i < len(src) && src[i] == end {
+				i++
+			}
+		case '0' <= b && b <= '9' || b == '.' && i+1 < len(src) && '0' <= src[i+1] && src[i+1] <= '9':
+			kind = CToken_Number
+			for i++; i < len(src); i++ {
+				c := src[i]
+				if (c == '+' || c == '-') && strings.IndexByte("eEpP", src[i-1]) >= 0 {
+					continue
+				}
+				if c != '.' && !isCIdentByte(c, false) {
+					break
+				}
+			}
+		case isCIdentByte(b, true):
+			kind = CToken_Ident
+			for i++; i < len(src) && isCIdentByte(src[i], false); i++ {
+			}
+			if cKeywords[string(src[start:i])] {
+				kind = CToken_Keyword
+			}
+		default:
+			i++
+		}
+		if i > len(src) {
+			i = len(src)
+		}
+
+		lineStart = false
+		tokens = append(tokens, CToken{Kind: kind, Start: start, End: i})
+	}
+	return tokens
+}
+
+type cDeclaration struct {
+	name, kind string
+	offset     int
+}
+
+// collectCDeclarations finds the top-level declarations in C source code.
+// It is a heuristic implementation, which works for common code styles.
+func collectCDeclarations(src []byte, tokens []CToken) []cDeclaration {
+	var decls []cDeclaration
+	var text = func(t CToken) string {
+		return string(src[t.Start:t.End])
+	}
+	var isPunct = func(t CToken, p string) bool {
+		return t.Kind == CToken_Punct && text(t) == p
+	}
+
+	// Remove comments and preprocessor directives (except macro names).
+	var toks = make([]CToken, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t.Kind {
+		case CToken_Comment:
+			continue
+		case CToken_Directive:
+			directive := strings.TrimSpace(strings.TrimPrefix(text(t), "#"))
+			lineEnd := cLogicalLineEnd(src, t.Start)
+			if directive == "define" && i+1 < len(tokens) && tokens[i+1].Start < lineEnd && tokens[i+1].Kind == CToken_Ident {
+				decls = append(decls, cDeclaration{text(tokens[i+1]), "macro", tokens[i+1].Start})
+			}
+			for i+1 < len(tokens) && tokens[i+1].Start < lineEnd {
+				i++
+			}
+			continue
+		}
+		toks = append(toks, t)
+	}
+
+	for i := 0; i < len(toks); {
+		// Skip stray tokens, such as the ones of `extern "C" {` blocks.
+		if t := toks[i]; t.Kind != CToken_Ident && t.Kind != CToken_Keyword || text(t) == "extern" && i+1 < len(toks) && toks[i+1].Kind == CToken_String {
+			i++
+			continue
+		}
+
+		// Find the end of the statement.
+		start, braceDepth, isFunc := i, 0, false
+		parenBeforeBrace, assignBeforeBrace := false, false
+		for ; i < len(toks); i++ {
+			t := toks[i]
+			if t.Kind != CToken_Punct {
+				continue
+			}
+			switch text(t) {
+			case "(":
+				if braceDepth == 0 {
+					parenBeforeBrace = true
+				}
+			case "=":
+				if braceDepth == 0 {
+					assignBeforeBrace = true
+				}
+			case "{":
+				braceDepth++
+			case "}":
+				braceDepth--
+			}
+			if braceDepth == 0 && (text(t) == ";" || text(t) == "}" && parenBeforeBrace && !assignBeforeBrace) {
+				isFunc = text(t) == "}"
+				i++
+				break
+			}
+			if braceDepth < 0 {
+				break
+			}
+		}
+		stmt := toks[start:i]
+
+		// Tags of structs, unions and enums, and enumerators.
+		for k := 0; k+2 < len(stmt); k++ {
+			switch text(stmt[k]) {
+			case "struct", "union", "enum":
+			default:
+				continue
+			}
+			if stmt[k+1].Kind == CToken_Ident && isPunct(stmt[k+2], "{") {
+				decls = append(decls, cDeclaration{text(stmt[k]) + "_" + text(stmt[k+1]), text(stmt[k]), stmt[k+1].Start})
+			}
+			if text(stmt[k]) != "enum" {
+				continue
+			}
+			m := k + 1
+			if stmt[m].Kind == CToken_Ident {
+				m++
+			}
+			if !isPunct(stmt[m], "{") {
+				continue
+			}
+			for m++; m < len(stmt) && !isPunct(stmt[m], "}"); m++ {
+				if stmt[m].Kind == CToken_Ident && (isPunct(stmt[m-1], "{") || isPunct(stmt[m-1], ",")) {
+					decls = append(decls, cDeclaration{text(stmt[m]), "enumerator", stmt[m].Start})
+				}
+			}
+		}
+
+		// Declarators at the top level.
+		isTypedef := len(stmt) > 0 && text(stmt[0]) == "typedef"
+		var k, depth = 0, 0
+		var firstDeclarator = true
+		for k < len(stmt) {
+			// Find the end of the current declarator.
+			end := k
+			for ; end < len(stmt); end++ {
+				t := stmt[end]
+				if t.Kind != CToken_Punct {
+					continue
+				}
+				if depth == 0 && (text(t) == "," || text(t) == ";" || text(t) == "=" || text(t) == "{" && isFunc) {
+					break
+				}
+				switch text(t) {
+				case "(", "{", "[":
+					depth++
+				case ")", "}", "]":
+					depth--
+				}
+			}
+			if name, isFuncDecl, ok := cDeclaratorName(stmt[k:end], text, firstDeclarator); ok {
+				kind := "var"
+				switch {
+				case isTypedef:
+					kind = "type"
+				case isFuncDecl:
+					kind = "func"
+				}
+				decls = append(decls, cDeclaration{text(name), kind, name.Start})
+			}
+			firstDeclarator = false
+
+			// Skip initializers and bodies.
+			for depth = 0; end < len(stmt); end++ {
+				t := stmt[end]
+				if t.Kind != CToken_Punct {
+					continue
+				}
+				switch text(t) {
+				case "(", "{", "[":
+					depth++
+				case ")", "}", "]":
+					depth--
+				}
+				if depth == 0 && text(t) == "," {
+					break
+				}
+			}
+			k = end + 1
+			depth = 0
+		}
+	}
+
+	return decls
+}
+
+// cDeclaratorName finds the declared name in a declarator. For the first
+// declarator in a declaration, the tokens also include the specifiers.
+func cDeclaratorName(toks []CToken, text func(CToken) string, withSpecifiers bool) (name CToken, isFunc, ok bool) {
+	depth := 0
+	for i, t := range toks {
+		if t.Kind == CToken_Punct {
+			switch text(t) {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			case "[", ":":
+				return name, false, ok
+			case "(":
+				if depth > 0 {
+					depth++
+					continue
+				}
+				// A pointer to function, like "(*name)(...)".
+				if i+2 < len(toks) && (text(toks[i+1]) == "*" || text(toks[i+1]) == "^") && toks[i+2].Kind == CToken_Ident {
+					return toks[i+2], false, true
+				}
+				// For "__attribute__((...))" etc.
+				if i > 0 && toks[i-1].Kind == CToken_Keyword {
+					depth++
+					continue
+				}
+				return name, ok, ok
+			case ")":
+				if depth > 0 {
+					depth--
+				}
+			}
+			continue
+		}
+		if depth == 0 && t.Kind == CToken_Ident {
+			if i > 0 && toks[i-1].Kind == CToken_Keyword {
+				switch text(toks[i-1]) {
+				case "struct", "union", "enum":
+					continue // a tag
+				}
+			}
+			name, ok = t, true
+			if !withSpecifiers {
+				return name, i+1 < len(toks) && text(toks[i+1]) == "(", true
+			}
+		}
+	}
+	return name, false, ok
+}
+
+// cLogicalLineEnd returns the end offset of the line containing
+// the offset, with line continuations considered.
+func cLogicalLineEnd(src []byte, offset int) int {
+	for {
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		end := offset + i
+		k := end
+		if k > 0 && src[k-1] == '\r' {
+			k--
+		}
+		if k == 0 || src[k-1] != '\\' {
+			return end
+		}
+		offset = end + 1
+	}
+}
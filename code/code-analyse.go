@@ -88,11 +88,18 @@ func (d *CodeAnalyzer) AnalyzePackages(onSubTaskDone func(int, time.Duration, ..
 
 	for _, pkg := range d.packageList {
 		d.analyzePackage_CollectMoreStatistics(pkg)
+		d.analyzePackage_CollectWholeCodeStatistics(pkg)
+		d.analyzePackage_CollectFunctionMetrics(pkg)
+		d.analyzePackage_CountIdentifierUses(pkg)
 	}
 	d.analyzePackage_CollectMoreStatisticsFinal()
 
 	logProgress(SubTask_MakeStatistics)
 
+	d.analyzePackages_FindUnreachableFunctions()
+
+	logProgress(SubTask_FindUnreachableFunctions)
+
 	// ...
 
 	// The following is moved to TestAnalyzer.
@@ -225,6 +232,9 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations() { // (resultMethodC
 			sel := selectors[i]
 			funcSig, ok := sel.Method.Type.TT.(*types.Signature)
 			if !ok {
+				if isIllTypedPackage(sel.Method.Pkg) {
+					continue // errors tolerated
+				}
 				panic(fmt.Sprintf("not a types.Signature: %T", sel.Method.Type.TT))
 			}
 			pkgImportPath := ""
@@ -305,6 +315,9 @@ func (d *CodeAnalyzer) analyzePackages_FindImplementations() { // (resultMethodC
 			sel := selectors[i]
 			funcSig, ok := sel.Method.Type.TT.(*types.Signature)
 			if !ok {
+				if isIllTypedPackage(sel.Method.Pkg) {
+					continue // errors tolerated
+				}
 				panic("not a types.Signature")
 			}
 			pkgImportPath := ""
@@ -826,6 +839,9 @@ func (d *CodeAnalyzer) registerNamedInterfaceMethodsForInvolvedTypeNames(pkg *Pa
 
 			sig, ok := sel.Method.Type.TT.(*types.Signature)
 			if !ok {
+				if pkg.PPkg.IllTyped {
+					continue // errors tolerated
+				}
 				panic("impossible")
 			}
 
@@ -1000,6 +1016,12 @@ type SeleterMapManager struct {
 
 //var debug = false
 
+// isIllTypedPackage reports whether or not a selector
+// is declared in a package containing type errors.
+func isIllTypedPackage(pkg *Package) bool {
+	return pkg != nil && pkg.PPkg.IllTyped
+}
+
 func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int, currentCounter uint32, smm *SeleterMapManager) (r bool) {
 
 	//if !debug {
@@ -1130,6 +1152,9 @@ func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int,
 				if sel.Method != nil {
 					if old, ok := selectors[sel.Id]; ok {
 						if old.Method.Type != sel.Method.Type {
+							if isIllTypedPackage(old.Method.Pkg) || isIllTypedPackage(sel.Method.Pkg) {
+								continue // errors tolerated
+							}
 							panic("direct overlapped interface methods and signatures are different")
 						} else {
 							//log.Println("$$$ overlapping interface method:", sel.Id, ". (allowed since Go 1.14)")
@@ -1177,6 +1202,9 @@ func (d *CodeAnalyzer) collectSelectorsForInterfaceType(t *TypeInfo, depth int,
 						for _, sel := range ut.AllMethods {
 							if old, ok := selectors[sel.Id]; ok {
 								if old.Method.Type != sel.Method.Type {
+									if isIllTypedPackage(old.Method.Pkg) || isIllTypedPackage(sel.Method.Pkg) {
+										continue // errors tolerated
+									}
 									panic("overlapped interface methods but signatures are different")
 								} else {
 									// ToDo: The current implementation does not always find true overlappings.
@@ -1325,6 +1353,9 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 		checkedTypes[structType.index] = 0
 		for _, sel := range structType.DirectSelectors {
 			if _, exist := selectorMap[sel.Id]; exist {
+				if isIllTypedPackage(sel.Field.Pkg) {
+					continue // errors tolerated
+				}
 				panic("should not")
 			} else {
 				selectorMap[sel.Id] = sel
@@ -1517,6 +1548,9 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 	// Direct declared methods.
 	for _, sel := range namedType.DirectSelectors {
 		if _, exist := selectorMap[sel.Id]; exist {
+			if isIllTypedPackage(sel.Method.Pkg) {
+				continue // errors tolerated
+			}
 			panic("should not")
 		} else {
 			selectorMap[sel.Id] = sel
@@ -1545,90 +1579,38 @@ func (d *CodeAnalyzer) collectSelectorsFroNonInterfaceType(t *TypeInfo, smm *Sel
 	}
 }
 
-// ToDo
+// confirmPackageModules attaches the non-std packages to the modules
+// reported by go/packages. The modules listed in the module preparation
+// phase are reused, so that each module is represented only once.
 func (d *CodeAnalyzer) confirmPackageModules() {
-	// Two cases:
-	// 1. check the .../vendor/modules.txt files
-	// 2. check GOPATH/pkg/mod/...
-
-	// # list all module dependency relations
-	// go mod graph
-	//	k8s.io/kubernetes sigs.k8s.io/yaml@v1.1.0
-	//	...
-	//	k8s.io/apiserver@v0.0.0 go.uber.org/zap@v1.10.0
-	//	...
-
-	// # from Michael Matloob
-	// go list -f '{{.Module.Path}} {{.Module.Dir}}' pkg-import-path
-
-	// # list all involved modules
-	// go list -m all
-	//	volcano.sh/volcano
-	//	modernc.org/xc v1.0.0 => modernc.org/xc v1.0.0
-	//	...
-	// go list -f '{{.ImportPath}} {{.Module}}' all
-	//	go101.org/gold/tests/n go101.org/gold
-	//	golang.org/x/mod/internal/lazyregexp golang.org/x/mod v0.1.1-0.20191105210325-c90efee705ee
-	//	unsafe <nil>
-	//	vendor/golang.org/x/crypto/chacha20 <nil>
-	//	...
-	// go list -json all
-	//	.Module
-	//
-	// Maybe, it is still better to analyze it manauuly.
-	// Temp not to show module pages, module info is only used to find asParamsOf/asResultsOf
-
-	// # get the module at CWD
-	// go list -m
-	//	volcano.sh/volcano
-	//   or
-	//	go list -m: not using modules
-
-	// # find GOROOT to find std module info
-	// go env
-
-	//findPkgModule := func(pkg *Package) {
-	//	// d.stdPackages
-	//}
-	//_ = findPkgModule
-
-	//for _, pkg := range d.packageList {
-	//	if len(pkg.PPkg.GoFiles) == 0 {
-	//		continue
-	//	}
-	//	dir := filepath.Dir(pkg.PPkg.GoFiles[0])
-	//	filename := filepath.Join(dir, "go.mod")
-	//	filedata, err := ioutil.ReadFile(filename)
-	//	if err != nil {
-	//		if !errors.Is(err, os.ErrNotExist) {
-	//			log.Printf("ioutil.ReadFile %s error: %s", filename, err)
-	//		}
-	//		continue
-	//	}
-	//
-	//	modFile, err := modfile.ParseLax(filename, filedata, nil)
-	//	if err != nil {
-	//	}
-	//
-	//	mod := Module{
-	//		Dir:     dir,
-	//		Root:    modFile.Module.Mod.Path,
-	//		Version: modFile.Module.Mod.Version,
-	//	}
-	//
-	//	_ = mod
-	//}
-
-	// I decided to delay the impplementation of this funciton now.
-	// One intention to confirm module information is
-	// to support module pages, but this is not very essential.
-	// Another intention to confirm module information
-	// is to calculate the distances of pacakges.
-	// However, it might be not perfect to determine
-	// the distance of two packages by checking if
-	// they are in the same module.
-	//
-	// The module info confirmed in this funciton will be only for showing.
+	type moduleKey struct{ path, version string }
+	var modules = make(map[moduleKey]*Module, len(d.allModules))
+	for _, m := range d.allModules {
+		if m != d.stdModule {
+			modules[moduleKey{m.Root, m.Version}] = m
+		}
+	}
+	for _, pkg := range d.packageList {
+		pm := pkg.PPkg.Module
+		if pkg.Mod != nil || pm == nil {
+			continue
+		}
+		key := moduleKey{pm.Path, pm.Version}
+		mod := modules[key]
+		if mod == nil {
+			mod = &Module{
+				Dir:     pm.Dir,
+				Root:    pm.Path,
+				Version: pm.Version,
+			}
+			if mod.Dir == "" && pm.Replace != nil {
+				mod.Dir = pm.Replace.Dir
+			}
+			modules[key] = mod
+			d.allModules = append(d.allModules, mod)
+		}
+		pkg.Mod = mod
+	}
 }
 
 // Important for registerFunctionForInvolvedTypeNames and registerValueForItsTypeName.
@@ -1709,9 +1691,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 	// ToDo: use info.TypeOf, info.ObjectOf
 
 	for _, file := range pkg.PPkg.Syntax {
-		d.stats.AstFiles++
-		d.stats.Imports += int32(len(file.Imports))
-		incSliceStat(d.stats.FilesByImportCount[:], len(file.Imports))
+		pkg.stats.AstFiles++
+		pkg.stats.Imports += int32(len(file.Imports))
+		incSliceStat(pkg.stats.FilesByImportCount[:], len(file.Imports))
 
 		//if len(file.Imports) == 0 {
 		//	log.Println("----", pkg.PPkg.Fset.PositionFor(file.Pos(), false))
@@ -1739,6 +1721,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 				obj := pkg.PPkg.TypesInfo.Defs[fd.Name]
 				switch funcObj := obj.(type) {
 				default:
+					if pkg.PPkg.IllTyped {
+						continue // errors tolerated
+					}
 					panic("not a types.Func")
 				case *types.Func:
 					f = &Function{
@@ -1773,6 +1758,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 						obj := pkg.PPkg.TypesInfo.Defs[typeSpec.Name]
 						typeObj, ok := obj.(*types.TypeName)
 						if !ok {
+							if pkg.PPkg.IllTyped {
+								continue // errors tolerated
+							}
 							//log.Println(pkg.PPkg.Fset.PositionFor(typeSpec.Pos(), false))
 							//log.Println(pkg.PPkg.TypesInfo.Defs)
 							panic(fmt.Sprintf("not a types.TypeName: %[1]v, %[1]T. Spec: %v", obj, typeSpec.Name.Name))
@@ -1781,6 +1769,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 						tv := pkg.PPkg.TypesInfo.Types[typeSpec.Type]
 						if !tv.IsType() {
 							if pkg.Path() != "unsafe" {
+								if pkg.PPkg.IllTyped {
+									continue // errors tolerated
+								}
 								panic(typeSpec.Name.Name + ": not type")
 							}
 
@@ -1899,6 +1890,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 							obj := pkg.PPkg.TypesInfo.Defs[name]
 							varObj, ok := obj.(*types.Var)
 							if !ok {
+								if pkg.PPkg.IllTyped {
+									continue // errors tolerated
+								}
 								panic("not a types.Var")
 							}
 
@@ -1926,6 +1920,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 							obj := pkg.PPkg.TypesInfo.Defs[name]
 							constObj, ok := obj.(*types.Const)
 							if !ok {
+								if pkg.PPkg.IllTyped {
+									continue // errors tolerated
+								}
 								panic("not a types.Const")
 							}
 
@@ -1956,6 +1953,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 
 						pkgObj, ok := obj.(*types.PkgName)
 						if !ok {
+							if pkg.PPkg.IllTyped {
+								continue // errors tolerated
+							}
 							//log.Println(pkg.PPkg.Fset.PositionFor(importSpec.Pos(), false))
 							//log.Println(pkg.PPkg.TypesInfo.Implicits)
 							panic(fmt.Sprintf("not a types.PkgName: %[1]v, %[1]T. Spec: %v, %v", obj, importSpec.Name, importSpec.Path.Value))
@@ -2026,29 +2026,30 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 		// ToDo: sometimes unexported ones are also needed to read code.
 		if f.Exported() {
 			if f.IsMethod() {
-				d.stats.ExportedMethods++
-				//incSliceStat(d.stats.MethodsByParameterCount[:], numParams)
-				//incSliceStat(d.stats.FunctionsByResultCount[:], numResults)
+				pkg.stats.ExportedMethods++
+				//incSliceStat(pkg.stats.MethodsByParameterCount[:], numParams)
+				//incSliceStat(pkg.stats.FunctionsByResultCount[:], numResults)
 			} else {
-				d.stats.ExportedFunctions++
-				//incSliceStat(d.stats.FunctionsByParameterCount[:], numParams)
-				//incSliceStat(d.stats.MethodsByResultCount[:], numResults)
+				pkg.stats.ExportedFunctions++
+				//incSliceStat(pkg.stats.FunctionsByParameterCount[:], numParams)
+				//incSliceStat(pkg.stats.MethodsByResultCount[:], numResults)
 			}
 			if lastResultIsError {
-				d.stats.ExportedFunctionWithLastErrorResult++
-			}
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(f.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(f.Name()))
-			d.stats.ExportedIdentifers++
-			d.stats.ExportedFunctionParameters += int32(numParams)
-			d.stats.ExportedFunctionResults += int32(numResults)
-			incSliceStat(d.stats.ExportedFunctionsByParameterCount[:], numParams)
-			incSliceStat(d.stats.ExportedFunctionsByResultCount[:], numResults)
+				pkg.stats.ExportedFunctionWithLastErrorResult++
+			}
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(f.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(f.Name()))
+			pkg.stats.ExportedIdentifers++
+			pkg.stats.ExportedFunctionParameters += int32(numParams)
+			pkg.stats.ExportedFunctionResults += int32(numResults)
+			incSliceStat(pkg.stats.ExportedFunctionsByParameterCount[:], numParams)
+			incSliceStat(pkg.stats.ExportedFunctionsByResultCount[:], numResults)
 		}
 	}
 	//for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
 	//	// moved to analyzePackage_CollectMoreStatistics
 	//}
+	d.collectDeprecatedDeclarations(pkg)
 	if isBuiltinPkg {
 		//var tnRune, tnInt32, tnByte, tnUint8 *TypeName
 		//for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
@@ -2076,13 +2077,13 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
 		if v.Exported() {
 			d.registerValueForItsTypeName(v)
-			d.stats.ExportedVariables++
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(v.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(v.Name()))
-			d.stats.ExportedIdentifers++
+			pkg.stats.ExportedVariables++
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(v.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(v.Name()))
+			pkg.stats.ExportedIdentifers++
 
 			kind := Kind(v.TType())
-			d.stats.ExportedVariablesByTypeKind[kind]++
+			pkg.stats.ExportedVariablesByTypeKind[kind]++
 		}
 		if v.AstSpec.Type != nil {
 			d.lookForAndRegisterUnnamedInterfaceAndStructTypes(v.AstSpec.Type, v.Pkg)
@@ -2091,13 +2092,13 @@ func (d *CodeAnalyzer) analyzePackage_CollectDeclarations(pkg *Package) {
 	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
 		if c.Exported() {
 			d.registerValueForItsTypeName(c)
-			d.stats.ExportedConstants++
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(c.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(c.Name()))
-			d.stats.ExportedIdentifers++
+			pkg.stats.ExportedConstants++
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(c.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(c.Name()))
+			pkg.stats.ExportedIdentifers++
 
 			kind := Kind(c.TType())
-			d.stats.ExportedConstantsByTypeKind[kind]++
+			pkg.stats.ExportedConstantsByTypeKind[kind]++
 		}
 	}
 }
@@ -2109,19 +2110,19 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 	var isBuiltinPkg = pkg.Path() == "builtin"
 
 	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
-		d.stats.roughTypeNameCount++
+		pkg.stats.roughTypeNameCount++
 
 		if isBuiltinPkg != token.IsExported(tn.Name()) {
-			incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(tn.Name()))
-			d.stats.ExportedIdentifersSumLength += int32(len(tn.Name()))
-			d.stats.ExportedIdentifers++
+			incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(tn.Name()))
+			pkg.stats.ExportedIdentifersSumLength += int32(len(tn.Name()))
+			pkg.stats.ExportedIdentifers++
 
 			denoting := tn.Denoting()
 			kind := denoting.Kind()
-			d.stats.ExportedTypeNamesByKind[kind]++
+			pkg.stats.ExportedTypeNamesByKind[kind]++
 
 			if tn.Alias != nil {
-				d.stats.ExportedTypeAliases++
+				pkg.stats.ExportedTypeAliases++
 				if t := tn.Alias.Denoting; t.TypeName != nil && t.TypeName.Exported() {
 					continue // to avoid duplicated statistics
 				}
@@ -2134,24 +2135,24 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 				}
 			}
 			if kind == reflect.Interface {
-				incSliceStat(d.stats.ExportedNamedInterfacesByMethodCount[:], len(denoting.AllMethods))
-				incSliceStat(d.stats.ExportedNamedInterfacesByExportedMethodCount[:], numExportedMethods)
-				d.stats.ExportedNamedInterfacesExportedMethods += int32(numExportedMethods)
-				d.stats.roughExportedIdentifierCount += int32(numExportedMethods)
+				incSliceStat(pkg.stats.ExportedNamedInterfacesByMethodCount[:], len(denoting.AllMethods))
+				incSliceStat(pkg.stats.ExportedNamedInterfacesByExportedMethodCount[:], numExportedMethods)
+				pkg.stats.ExportedNamedInterfacesExportedMethods += int32(numExportedMethods)
+				pkg.stats.roughExportedIdentifierCount += int32(numExportedMethods)
 				continue
 			}
-			incSliceStat(d.stats.ExportedNamedNonInterfaceTypesByMethodCount[:], len(denoting.AllMethods))
-			incSliceStat(d.stats.ExportedNamedNonInterfaceTypesByExportedMethodCount[:], numExportedMethods)
+			incSliceStat(pkg.stats.ExportedNamedNonInterfaceTypesByMethodCount[:], len(denoting.AllMethods))
+			incSliceStat(pkg.stats.ExportedNamedNonInterfaceTypesByExportedMethodCount[:], numExportedMethods)
 
 			if numExportedMethods > 0 {
-				d.stats.ExportedNamedNonInterfacesExportedMethods += int32(numExportedMethods)
-				d.stats.roughExportedIdentifierCount += int32(numExportedMethods)
-				d.stats.ExportedNamedNonInterfacesWithExportedMethods++
+				pkg.stats.ExportedNamedNonInterfacesExportedMethods += int32(numExportedMethods)
+				pkg.stats.roughExportedIdentifierCount += int32(numExportedMethods)
+				pkg.stats.ExportedNamedNonInterfacesWithExportedMethods++
 			}
 
 			if kind == reflect.Struct {
-				incSliceStat(d.stats.ExportedNamedStructsByFieldCount[:], len(denoting.AllFields))
-				d.stats.ExportedNamedStructTypeFields += int32(len(denoting.AllFields))
+				incSliceStat(pkg.stats.ExportedNamedStructsByFieldCount[:], len(denoting.AllFields))
+				pkg.stats.ExportedNamedStructTypeFields += int32(len(denoting.AllFields))
 
 				hasEmbeddeds, numExportedPromoteds := false, 0
 				numExporteds, numExpliciteds, numExportedExpliciteds := 0, 0, 0
@@ -2165,9 +2166,9 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 						}
 					}
 					if sel.Depth == 0 {
-						incSliceStat(d.stats.ExportedIdentifiersByLength[:], len(sel.Name()))
-						d.stats.ExportedIdentifersSumLength += int32(len(sel.Name()))
-						d.stats.ExportedIdentifers++
+						incSliceStat(pkg.stats.ExportedIdentifiersByLength[:], len(sel.Name()))
+						pkg.stats.ExportedIdentifersSumLength += int32(len(sel.Name()))
+						pkg.stats.ExportedIdentifers++
 
 						numExpliciteds++
 					} else {
@@ -2175,23 +2176,23 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 					}
 				}
 				if hasEmbeddeds {
-					d.stats.ExportedNamedStructTypesWithPromotedFields++
+					pkg.stats.ExportedNamedStructTypesWithPromotedFields++
 				}
 				ut := d.RegisterType(denoting.TT.Underlying())
 				if ut.EmbeddingFields > 0 {
-					d.stats.ExportedNamedStructTypesWithEmbeddingFields++
+					pkg.stats.ExportedNamedStructTypesWithEmbeddingFields++
 				}
-				incSliceStat(d.stats.ExportedNamedStructsByEmbeddingFieldCount[:], int(ut.EmbeddingFields))
+				incSliceStat(pkg.stats.ExportedNamedStructsByEmbeddingFieldCount[:], int(ut.EmbeddingFields))
 
-				incSliceStat(d.stats.ExportedNamedStructsByExplicitFieldCount[:], numExpliciteds)
-				d.stats.ExportedNamedStructTypeExplicitFields += int32(numExpliciteds)
-				incSliceStat(d.stats.ExportedNamedStructsByExportedFieldCount[:], numExporteds)
-				d.stats.ExportedNamedStructTypeExportedFields += int32(numExporteds)
-				incSliceStat(d.stats.ExportedNamedStructsByExportedExplicitFieldCount[:], numExportedExpliciteds)
-				d.stats.ExportedNamedStructTypeExportedExplicitFields += int32(numExportedExpliciteds)
-				d.stats.roughExportedIdentifierCount += int32(numExportedExpliciteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExplicitFieldCount[:], numExpliciteds)
+				pkg.stats.ExportedNamedStructTypeExplicitFields += int32(numExpliciteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExportedFieldCount[:], numExporteds)
+				pkg.stats.ExportedNamedStructTypeExportedFields += int32(numExporteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExportedExplicitFieldCount[:], numExportedExpliciteds)
+				pkg.stats.ExportedNamedStructTypeExportedExplicitFields += int32(numExportedExpliciteds)
+				pkg.stats.roughExportedIdentifierCount += int32(numExportedExpliciteds)
 
-				incSliceStat(d.stats.ExportedNamedStructsByExportedPromotedFieldCount[:], numExportedPromoteds)
+				incSliceStat(pkg.stats.ExportedNamedStructsByExportedPromotedFieldCount[:], numExportedPromoteds)
 				//if numExportedPromoteds >= 5 {
 				//	log.Println(numExportedPromoteds, tn.Package().Path(), tn.Name())
 				//}
@@ -2201,53 +2202,71 @@ func (d *CodeAnalyzer) analyzePackage_CollectMoreStatistics(pkg *Package) {
 }
 
 func (d *CodeAnalyzer) analyzePackage_CollectMoreStatisticsFinal() {
-	var sum = func(kinds ...reflect.Kind) (r int32) {
-		for _, k := range kinds {
-			r += d.stats.ExportedTypeNamesByKind[k]
-		}
-		return
-	}
-	d.stats.ExportedUnsignedTypeNames = sum(reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr)
-	d.stats.ExportedIntergerTypeNames = d.stats.ExportedUnsignedTypeNames + sum(reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64)
-	d.stats.ExportedNumericTypeNames = d.stats.ExportedIntergerTypeNames + sum(reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128)
-	d.stats.ExportedBasicTypeNames = d.stats.ExportedNumericTypeNames + sum(reflect.Bool, reflect.String)
-	d.stats.ExportedCompositeTypeNames = sum(reflect.Array, reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct, reflect.UnsafePointer)
-	d.stats.ExportedTypeNames = d.stats.ExportedCompositeTypeNames + d.stats.ExportedBasicTypeNames
-
-	d.stats.Packages = int32(len(d.packageList))
 	for _, pkg := range d.packageList {
+		pkg.stats.Packages = 1
 		if d.IsStandardPackage(pkg) {
-			d.stats.StdPackages++
+			pkg.stats.StdPackages = 1
 		}
-		d.stats.FilesWithGenerateds += int32(len(pkg.SourceFiles))
-		d.stats.AllPackageDeps += int32(len(pkg.Deps))
-		incSliceStat(d.stats.PackagesByDeps[:], len(pkg.Deps))
+		pkg.stats.FilesWithGenerateds = int32(len(pkg.SourceFiles))
+		pkg.stats.AllPackageDeps = int32(len(pkg.Deps))
+		incSliceStat(pkg.stats.PackagesByDeps[:], len(pkg.Deps))
+		pkg.stats.roughExportedIdentifierCount += pkg.stats.ExportedIdentifers
+		pkg.stats.sumKinds()
 	}
 
-	d.stats.roughExportedIdentifierCount += d.stats.ExportedIdentifers
+	d.stats = StatisticsOf(d.packageList)
+}
+
+// The runtime functions which implement some built-in operations.
+// Some of them only exist in some Go versions.
+var runtimeFunctionsForOperations = []string{
+	"selectgo",      // for select blocks (except one-case-plus-default ones)
+	"selectnbsend",  // one-case-plus-default select blocks
+	"selectnbrecv",  // select {case v = <-c:; default:}
+	"selectnbrecv2", // select {case v, ok = <-c:; default:}
+	"chansend",      // c <- v
+	"chanrecv1",     // v = <- c
+	"chanrecv2",     // v, ok = <-c
+	"gopanic",
+	"gorecover",
+
+	// make, new, append, copy, close
+	"makemap_small", "makemap", "makechan", "makeslice",
+	"newobject", "growslice", "slicecopy", "typedslicecopy", "slicestringcopy",
+	"closechan",
+
+	// map element accesses, modifications and deletions
+	"mapaccess1", "mapaccess1_fast32", "mapaccess1_fast64", "mapaccess1_faststr",
+	"mapaccess2", "mapaccess2_fast32", "mapaccess2_fast64", "mapaccess2_faststr",
+	"mapassign", "mapassign_fast32", "mapassign_fast64", "mapassign_faststr",
+	"mapassign_fast32ptr", "mapassign_fast64ptr",
+	"mapdelete", "mapdelete_fast32", "mapdelete_fast64", "mapdelete_faststr",
+	"mapclear",
+
+	// clear(slice)
+	"memclrNoHeapPointers", "memclrHasPointers",
+
+	// string concatenations
+	"concatstring2", "concatstring3", "concatstring4", "concatstring5", "concatstrings",
+
+	// interface conversions and type assertions
+	"convT", "convTnoptr", "convT16", "convT32", "convT64", "convTstring", "convTslice",
+	"convT2E", "convT2I", "convT2Enoptr", "convT2Inoptr",
+	"assertE2I", "assertE2I2", "assertI2I", "assertI2I2",
+
+	// go and defer statements
+	"newproc", "deferprocStack", "deferproc",
 }
 
 func (d *CodeAnalyzer) analyzePackage_CollectSomeRuntimeFunctionPositions() {
 	// ...
 	if runtimePkg := d.packageTable["runtime"]; runtimePkg != nil {
-		fnames := []string{
-			"selectgo",      // for select blocks (except one-case-plus-default ones)
-			"selectnbsend",  // one-case-plus-default select blocks
-			"selectnbrecv",  // select {case v = <-c:; default:}
-			"selectnbrecv2", // select {case v, ok = <-c:; default:}
-			"chansend",      // c <- v
-			"chanrecv1",     // v = <- c
-			"chanrecv2",     // v, ok = <-c
-			"gopanic",
-			"gorecover",
-			// gave up other built-in functions
-		}
-		d.runtimeFuncPositions = make(map[string]token.Position, 32)
+		d.runtimeFuncPositions = make(map[string]token.Position, len(runtimeFunctionsForOperations))
 
-		for _, f := range fnames {
+		for _, f := range runtimeFunctionsForOperations {
 			obj := runtimePkg.PPkg.Types.Scope().Lookup(f)
 			if obj == nil {
-				log.Printf("!!! runtime.%s is not found", f)
+				continue // not implemented in the current Go version
 			}
 			d.runtimeFuncPositions[f] = runtimePkg.PPkg.Fset.PositionFor(obj.Pos(), false)
 		}
@@ -2271,7 +2290,13 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 					typeSpec := spec.(*ast.TypeSpec)
 
 					obj := pkg.PPkg.TypesInfo.Defs[typeSpec.Name]
-					typeObj := obj.(*types.TypeName)
+					typeObj, ok := obj.(*types.TypeName)
+					if !ok {
+						if pkg.PPkg.IllTyped {
+							continue // errors tolerated
+						}
+						panic(fmt.Sprintf("not a types.TypeName: %[1]v, %[1]T. Spec: %v", obj, typeSpec.Name.Name))
+					}
 					if typeObj.Name() == "_" {
 						continue
 					}
@@ -2282,6 +2307,9 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 
 					newTypeName := d.allTypeNameTable[d.Id2(typeObj.Pkg(), typeObj.Name())]
 					if newTypeName == nil {
+						if pkg.PPkg.IllTyped {
+							continue // errors tolerated
+						}
 						panic("type name " + typeSpec.Name.Name + " not found: " + d.Id1(typeObj.Pkg(), typeObj.Name()))
 					}
 
@@ -2309,18 +2337,27 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 
 							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr)
 							if srcObj == nil {
-								if pkg.Path() != "unsafe" {
+								if pkg.Path() != "unsafe" && !pkg.PPkg.IllTyped {
 									panic("srcObj is nil but package is not unsafe")
 								}
 								return
 							}
-							srcTypeObj := srcObj.(*types.TypeName)
+							srcTypeObj, ok := srcObj.(*types.TypeName)
+							if !ok {
+								if pkg.PPkg.IllTyped {
+									return // errors tolerated
+								}
+								panic(fmt.Sprintf("not a types.TypeName: %[1]v, %[1]T", srcObj))
+							}
 
 							//log.Println("   srcTypeObj.Pkg() =", srcTypeObj.Pkg())
 							// if srcType is a built type, srcTypeObj.Pkg() == nil
 
 							tn := d.allTypeNameTable[d.Id2(srcTypeObj.Pkg(), expr.Name)]
 							if tn == nil {
+								if pkg.PPkg.IllTyped {
+									return // errors tolerated
+								}
 								panic("type name " + expr.Name + " not found")
 							}
 							source.TypeName = tn
@@ -2331,10 +2368,19 @@ func (d *CodeAnalyzer) analyzePackage_FindTypeSources(pkg *Package) {
 						case *ast.SelectorExpr:
 							//log.Println("selector,", pkg.Path()+"."+typeSpec.Name.Name, "source is:")
 							srcObj := pkg.PPkg.TypesInfo.ObjectOf(expr.X.(*ast.Ident))
-							srcPkg := srcObj.(*types.PkgName)
+							srcPkg, ok := srcObj.(*types.PkgName)
+							if !ok {
+								if pkg.PPkg.IllTyped {
+									return // errors tolerated
+								}
+								panic(fmt.Sprintf("not a types.PkgName: %[1]v, %[1]T", srcObj))
+							}
 
 							tn := d.allTypeNameTable[d.Id2(srcPkg.Imported(), expr.Sel.Name)]
 							if tn == nil {
+								if pkg.PPkg.IllTyped {
+									return // errors tolerated
+								}
 								panic("type name " + expr.Sel.Name + " not found")
 							}
 							source.TypeName = tn
@@ -9,6 +9,7 @@ import (
 	"go/types"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -35,25 +36,81 @@ import (
 
 func collectPPackages(ppkgs []*packages.Package) map[string]*packages.Package {
 	var allPPkgs = make(map[string]*packages.Package, 1000)
-	var regPkgs func(ppkg *packages.Package)
-	regPkgs = func(ppkg *packages.Package) {
-		if _, present := allPPkgs[ppkg.PkgPath]; present {
-			return
+	// Returns false if the package (or one of its dependencies)
+	// is another variant of a registered package.
+	var regPkgs func(ppkg *packages.Package) bool
+	regPkgs = func(ppkg *packages.Package) bool {
+		if p, present := allPPkgs[ppkg.PkgPath]; present {
+			return p == ppkg
 		}
 
 		allPPkgs[ppkg.PkgPath] = ppkg
 		for _, p := range ppkg.Imports {
-			regPkgs(p)
+			if !regPkgs(p) {
+				delete(allPPkgs, ppkg.PkgPath)
+				return false
+			}
+		}
+		return true
+	}
+
+	// When tests are loaded, a package "p" with test files has another
+	// variant "p [p.test]" which also contains the test files.
+	// Only one variant of a package can be analyzed. The test variant is
+	// preferred, unless the normal variant is imported by other packages.
+	// Packages depending on the unselected variants are ignored.
+	var importeds = make(map[*packages.Package]bool, len(ppkgs))
+	var markImporteds func(ppkg *packages.Package)
+	markImporteds = func(ppkg *packages.Package) {
+		for _, p := range ppkg.Imports {
+			if !importeds[p] {
+				importeds[p] = true
+				markImporteds(p)
+			}
+		}
+	}
+	var testVariants []*packages.Package
+	for _, ppkg := range ppkgs {
+		if isTestVariant(ppkg) {
+			testVariants = append(testVariants, ppkg)
+		} else {
+			markImporteds(ppkg)
+		}
+	}
+	if len(testVariants) > 0 {
+		// The test variants are registered firstly.
+		var normalVariants = make(map[string]*packages.Package, len(ppkgs))
+		for _, ppkg := range ppkgs {
+			if ppkg.ID == ppkg.PkgPath {
+				normalVariants[ppkg.PkgPath] = ppkg
+			}
+		}
+		for _, ppkg := range testVariants {
+			if ppkg.ID == ppkg.PkgPath+" ["+ppkg.PkgPath+".test]" && !importeds[normalVariants[ppkg.PkgPath]] {
+				regPkgs(ppkg)
+			}
 		}
 	}
 
 	for _, ppkg := range ppkgs {
-		regPkgs(ppkg)
+		if !isTestVariant(ppkg) {
+			regPkgs(ppkg)
+		}
+	}
+	for _, ppkg := range testVariants {
+		// Skip the generated "p.test" main packages.
+		if ppkg.Name != "main" || !strings.HasSuffix(ppkg.ID, ".test") {
+			regPkgs(ppkg)
+		}
 	}
 
 	return allPPkgs
 }
 
+func isTestVariant(ppkg *packages.Package) bool {
+	return strings.HasSuffix(ppkg.ID, ".test]") || strings.HasSuffix(ppkg.ID, ".test")
+}
+
 func collectStdPackages() ([]string, error) {
 	//log.Println("[collect std packages ...]")
 	//defer log.Println("[collect std packages done]")
@@ -76,7 +133,109 @@ func collectStdPackages() ([]string, error) {
 	return pkgs, nil
 }
 
-func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...int32), args ...string) bool {
+// ParseOptions controls how packages are loaded and parsed.
+type ParseOptions struct {
+	// Continue analyzing when some packages have errors.
+	// Ill-typed packages are analyzed as far as go/types allows.
+	TolerateErrors bool
+
+	// The directory to load packages in. Blank means the current directory.
+	Dir string
+
+	// Build tags, such as "integration".
+	BuildTags []string
+
+	// The target OS and architecture.
+	// Blank means the ones used by the go command.
+	GOOS, GOARCH string
+
+	// Load the test files of the specified packages.
+	Tests bool
+
+	// Whether or not cgo is enabled, "1" or "0".
+	// Blank means the default of the go command.
+	CgoEnabled string
+
+	// Extra environment variables for the go command, each is
+	// in the "KEY=VALUE" form. They override the above options.
+	Env []string
+}
+
+// buildFlags returns the build flags passed to the go command.
+func (options *ParseOptions) buildFlags() []string {
+	if len(options.BuildTags) == 0 {
+		return nil
+	}
+	return []string{"-tags=" + strings.Join(options.BuildTags, ",")}
+}
+
+// environ appends the environment variables specified in the options
+// to env. A nil env means the environment of the current process.
+func (options *ParseOptions) environ(env []string) []string {
+	if options.GOOS == "" && options.GOARCH == "" && options.CgoEnabled == "" && len(options.Env) == 0 {
+		return env
+	}
+	if env == nil {
+		env = os.Environ()
+	}
+	env = env[:len(env):len(env)]
+	if options.CgoEnabled != "" {
+		env = append(env, "CGO_ENABLED="+options.CgoEnabled)
+	}
+	if options.GOOS != "" {
+		env = append(env, "GOOS="+options.GOOS)
+	}
+	if options.GOARCH != "" {
+		env = append(env, "GOARCH="+options.GOARCH)
+	}
+	return append(env, options.Env...)
+}
+
+// getenv returns the value of the last key=value item in Env.
+func (options *ParseOptions) getenv(key string) (string, bool) {
+	for i := len(options.Env) - 1; i >= 0; i-- {
+		if kv := options.Env[i]; strings.HasPrefix(kv, key) && len(kv) > len(key) && kv[len(key)] == '=' {
+			return kv[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// Target returns the target OS and architecture of analyzing.
+func (options *ParseOptions) Target() (goos, goarch string) {
+	goos, goarch = build.Default.GOOS, build.Default.GOARCH
+	if v, ok := options.getenv("GOOS"); ok {
+		goos = v
+	} else if options.GOOS != "" {
+		goos = options.GOOS
+	}
+	if v, ok := options.getenv("GOARCH"); ok {
+		goarch = v
+	} else if options.GOARCH != "" {
+		goarch = options.GOARCH
+	}
+	return
+}
+
+// BuildConfiguration describes the build configuration used in
+// analyzing, like "GOOS=linux GOARCH=amd64 -tags=foo CGO_ENABLED=0".
+// It is shown in pages, so the other environment variables specified
+// in Env are not included, for their values might be secrets.
+func (options *ParseOptions) BuildConfiguration() string {
+	goos, goarch := options.Target()
+	var items = []string{"GOOS=" + goos, "GOARCH=" + goarch}
+	if len(options.BuildTags) > 0 {
+		items = append(items, "-tags="+strings.Join(options.BuildTags, ","))
+	}
+	if v, ok := options.getenv("CGO_ENABLED"); ok {
+		items = append(items, "CGO_ENABLED="+v)
+	} else if options.CgoEnabled != "" {
+		items = append(items, "CGO_ENABLED="+options.CgoEnabled)
+	}
+	return strings.Join(items, " ")
+}
+
+func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...int32), options ParseOptions, args ...string) bool {
 
 	var stopWatch = util.NewStopWatch()
 	if onSubTaskDone == nil {
@@ -86,7 +245,26 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 		onSubTaskDone(task, stopWatch.Duration(resetWatch), args...)
 	}
 
+	// "path@version" arguments are resolved against the local module cache.
+	moduleVersions, err := parseModuleVersionArgs(args)
+	if err != nil {
+		log.Println(err)
+		return false
+	}
+	var workDir string
+	if len(moduleVersions) > 0 {
+		var patterns []string
+		workDir, patterns, err = prepareModuleVersionsWorkspace(moduleVersions)
+		if err != nil {
+			log.Println(err)
+			return false
+		}
+		defer os.RemoveAll(workDir)
+		args = patterns
+	}
+
 	// ...
+	builtinAppended := false
 	for _, arg := range args {
 		if arg == "builtin" {
 			goto Start
@@ -96,6 +274,7 @@ func (d *CodeAnalyzer) ParsePackages(onSubTaskDone func(int, time.Duration, ...i
 	// "builtin" package is always needed.
 	// ToDo: remove this line, use a custom builtin page.
 	args = append(args, "builtin")
+	builtinAppended = true
 
 Start:
 	//log.Println("[parse packages ...], args:", args)
@@ -108,8 +287,9 @@ Start:
 		Mode: packages.NeedName | packages.NeedImports | packages.NeedDeps |
 			packages.NeedTypes | packages.NeedExportsFile | packages.NeedFiles |
 			packages.NeedCompiledGoFiles | packages.NeedTypesSizes |
-			packages.NeedSyntax | packages.NeedTypesInfo,
-		Tests: false, // ToDo: parse tests
+			packages.NeedSyntax | packages.NeedTypesInfo | packages.NeedModule,
+		Tests:      options.Tests,
+		BuildFlags: options.buildFlags(),
 		// It looks, if Tests is set to true, then run "GOOS=windows gold std" will fail with
 		//		panic: TypeName for runtime.LFNode not found
 
@@ -143,6 +323,28 @@ Start:
 		//       And, go/types can be used to verify the correctness of the custom implementaion.
 	}
 
+	if workDir != "" {
+		configForParsing.Dir = workDir
+		configForParsing.Env = options.environ(moduleVersionsEnv())
+	} else {
+		configForParsing.Dir = options.Dir
+		configForParsing.Env = options.environ(nil)
+	}
+
+	// go/packages reports nothing when downloading modules,
+	// so download them in a separated phase to show progress.
+	// Standard packages don't need any modules.
+	for _, arg := range args {
+		if arg != "std" && arg != "builtin" {
+			flags := configForParsing.BuildFlags
+			if options.Tests {
+				flags = append(flags[:len(flags):len(flags)], "-test")
+			}
+			d.prepareModules(configForParsing.Dir, configForParsing.Env, flags, args, logProgress)
+			break
+		}
+	}
+
 	ppkgs, err := packages.Load(configForParsing, args...)
 	if err != nil {
 		log.Println("packages.Load (parse packages):", err)
@@ -179,7 +381,10 @@ Start:
 		}
 	}
 	if hasErrors {
-		log.Fatal("exit for above errors")
+		if !options.TolerateErrors {
+			log.Fatal("exit for above errors")
+		}
+		log.Println("continue analyzing with above errors tolerated")
 	}
 
 	var allPPkgs = collectPPackages(ppkgs)
@@ -207,7 +412,7 @@ Start:
 		if pkg == nil {
 			//packageListChanged = true
 
-			pkg := &Package{PPkg: ppkg}
+			pkg := &Package{PPkg: ppkg, Errors: collectPackageErrors(ppkg)}
 			d.packageTable[path] = pkg
 			d.packageList = append(d.packageList, pkg)
 
@@ -225,6 +430,15 @@ Start:
 	}
 	d.builtinPkg = d.packageTable["builtin"]
 
+	for _, ppkg := range ppkgs {
+		if pkg := d.packageTable[ppkg.PkgPath]; pkg != nil {
+			pkg.Requested = true
+		}
+	}
+	if builtinAppended && d.builtinPkg != nil {
+		d.builtinPkg.Requested = false
+	}
+
 	var pkgNumDepedBys = make(map[*Package]uint32, len(allPPkgs))
 	for _, pkg := range d.packageList {
 		pkg.Deps = make([]*Package, 0, len(pkg.PPkg.Imports))
@@ -256,8 +470,10 @@ Start:
 		Root:    "",
 		Version: "", // ToDo
 	}
-	estimatedNumMods := 1 + len(d.packageList)/3
-	d.allModules = make([]*Module, estimatedNumMods)
+	if d.allModules == nil {
+		estimatedNumMods := 1 + len(d.packageList)/3
+		d.allModules = make([]*Module, 0, estimatedNumMods)
+	}
 	d.allModules = append(d.allModules, d.stdModule)
 
 	for _, path := range stdPkgs {
@@ -267,11 +483,61 @@ Start:
 		}
 	}
 
-	// ...
+	d.confirmModuleVersions(moduleVersions)
+
+	d.buildContext = buildContextFor(&options)
 
 	return true
 }
 
+func collectPackageErrors(ppkg *packages.Package) []PackageError {
+	if ppkg.PkgPath == "builtin" || len(ppkg.Errors) == 0 {
+		return nil
+	}
+
+	errs := make([]PackageError, 0, len(ppkg.Errors))
+	for _, e := range ppkg.Errors {
+		errs = append(errs, PackageError{
+			Position: parseErrorPosition(e.Pos),
+			Message:  e.Msg,
+		})
+	}
+	return errs
+}
+
+// parseErrorPosition parses positions in the "file:line:column",
+// "file:line" or "file" forms. "-" and blank mean unknown.
+func parseErrorPosition(pos string) (p token.Position) {
+	if pos == "" || pos == "-" {
+		return
+	}
+
+	var nums [2]int
+	var n int
+	for n < len(nums) {
+		i := strings.LastIndexByte(pos, ':')
+		if i < 0 {
+			break
+		}
+		v, err := strconv.Atoi(pos[i+1:])
+		if err != nil {
+			break
+		}
+		nums[n] = v
+		n++
+		pos = pos[:i]
+	}
+
+	p.Filename = pos
+	switch n {
+	case 1:
+		p.Line = nums[0]
+	case 2:
+		p.Line, p.Column = nums[1], nums[0]
+	}
+	return
+}
+
 func fillUnsafePackage(unsafePPkg *packages.Package, builtinPPkg *packages.Package) {
 	intType := builtinPPkg.Types.Scope().Lookup("int").Type()
 
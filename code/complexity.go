@@ -0,0 +1,107 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// FunctionMetrics are some size and complexity metrics of a function.
+type FunctionMetrics struct {
+	Complexity int // cyclomatic complexity
+	Statements int // not including blocks, case clauses and labels
+	Nesting    int // the max nesting depth of control flow statements
+	Parameters int
+}
+
+// analyzePackage_CollectFunctionMetrics calculates the metrics of the
+// functions and methods (with bodies) declared in a package.
+func (d *CodeAnalyzer) analyzePackage_CollectFunctionMetrics(pkg *Package) {
+	for _, f := range pkg.AllFunctions {
+		if f.AstDecl != nil && f.AstDecl.Body != nil {
+			f.Metrics = CalculateFunctionMetrics(f.AstDecl)
+		}
+	}
+}
+
+// CalculateFunctionMetrics calculates the metrics of a function declaration.
+// Function literals in the body are viewed as parts of the function.
+// An "else if" is viewed at the same nesting depth as its leading "if".
+func CalculateFunctionMetrics(fd *ast.FuncDecl) (m FunctionMetrics) {
+	if params := fd.Type.Params; params != nil {
+		for _, field := range params.List {
+			if n := len(field.Names); n > 0 {
+				m.Parameters += n
+			} else {
+				m.Parameters++
+			}
+		}
+	}
+	if fd.Body == nil {
+		return
+	}
+
+	m.Complexity = 1
+	var stack []ast.Node
+	var depth = 0
+	var isNesting = func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt:
+			// else if
+			if parent, ok := stack[len(stack)-1].(*ast.IfStmt); ok && parent.Else == n {
+				return false
+			}
+			return true
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt, *ast.FuncLit:
+			return true
+		}
+		return false
+	}
+	var nestings []bool
+	ast.Inspect(fd.Body, func(n ast.Node) bool {
+		if n == nil {
+			if nestings[len(nestings)-1] {
+				depth--
+			}
+			stack = stack[:len(stack)-1]
+			nestings = nestings[:len(nestings)-1]
+			return false
+		}
+
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			m.Complexity++
+		case *ast.CaseClause:
+			if n.List != nil { // not default
+				m.Complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil { // not default
+				m.Complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				m.Complexity++
+			}
+		}
+		switch n.(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+		case *ast.LabeledStmt: // only the labeled statement is counted
+		case ast.Stmt:
+			if n != fd.Body {
+				m.Statements++
+			}
+		}
+
+		nesting := len(stack) > 0 && isNesting(n)
+		if nesting {
+			depth++
+			if depth > m.Nesting {
+				m.Nesting = depth
+			}
+		}
+		stack = append(stack, n)
+		nestings = append(nestings, nesting)
+		return true
+	})
+	return
+}
@@ -0,0 +1,176 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// DeprecationNote returns the content of the "Deprecated: " paragraph
+// in a doc comment, or a blank string if there is no such paragraph.
+// The lines of the paragraph are joined into one.
+func DeprecationNote(doc string) string {
+	for _, para := range strings.Split(doc, "\n\n") {
+		para = strings.TrimSpace(para)
+		if strings.HasPrefix(para, "Deprecated:") {
+			return strings.Join(strings.Fields(para[len("Deprecated:"):]), " ")
+		}
+	}
+	return ""
+}
+
+// collectDeprecatedDeclarations records the deprecated package-level
+// declarations, methods, struct fields and interface methods in a package.
+func (d *CodeAnalyzer) collectDeprecatedDeclarations(pkg *Package) {
+	var register = func(obj types.Object, doc string) {
+		if obj == nil {
+			return
+		}
+		if note := DeprecationNote(doc); note != "" {
+			if d.deprecatedObjects == nil {
+				d.deprecatedObjects = make(map[types.Object]string, 1024)
+			}
+			d.deprecatedObjects[obj] = note
+		}
+	}
+	var registerFields = func(fields *ast.FieldList) {
+		for _, field := range fields.List {
+			for _, name := range field.Names {
+				register(pkg.PPkg.TypesInfo.Defs[name], field.Doc.Text())
+			}
+		}
+	}
+
+	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
+		register(tn.TypeName, tn.Documentation())
+		switch t := tn.AstSpec.Type.(type) {
+		case *ast.StructType:
+			registerFields(t.Fields)
+		case *ast.InterfaceType:
+			registerFields(t.Methods)
+		}
+	}
+	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
+		if f.Func != nil {
+			register(f.Func, f.Documentation())
+		}
+	}
+	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
+		register(v.Var, v.Documentation())
+	}
+	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
+		register(c.Const, c.Documentation())
+	}
+}
+
+// DeprecationNoteOf returns the deprecation note of an object,
+// or a blank string if the object is not deprecated.
+func (d *CodeAnalyzer) DeprecationNoteOf(obj types.Object) string {
+	return d.deprecatedObjects[originObject(obj)]
+}
+
+// ResourceDeprecationNote returns the deprecation note of a resource,
+// or a blank string if the resource is not deprecated.
+func (d *CodeAnalyzer) ResourceDeprecationNote(res Resource) string {
+	if len(d.deprecatedObjects) == 0 {
+		return ""
+	}
+	switch res := res.(type) {
+	case *TypeName:
+		return d.deprecatedObjects[res.TypeName]
+	case *Constant:
+		return d.deprecatedObjects[res.Const]
+	case *Variable:
+		return d.deprecatedObjects[res.Var]
+	case *Function:
+		if res.Func != nil {
+			return d.deprecatedObjects[res.Func]
+		}
+	case *InterfaceMethod:
+		if m := res.Method; m.Pkg != nil && m.AstField != nil && len(m.AstField.Names) > 0 {
+			return d.deprecatedObjects[m.Pkg.PPkg.TypesInfo.Defs[m.AstField.Names[0]]]
+		}
+	}
+	return ""
+}
+
+// MethodDeprecationNote returns the deprecation note of a concrete
+// or interface method, or a blank string if it is not deprecated.
+func (d *CodeAnalyzer) MethodDeprecationNote(m *Method) string {
+	if len(d.deprecatedObjects) == 0 || m.Pkg == nil {
+		return ""
+	}
+	var name *ast.Ident
+	if m.AstFunc != nil {
+		name = m.AstFunc.Name
+	} else if m.AstField != nil && len(m.AstField.Names) > 0 {
+		name = m.AstField.Names[0]
+	}
+	if name == nil {
+		return ""
+	}
+	return d.deprecatedObjects[m.Pkg.PPkg.TypesInfo.Defs[name]]
+}
+
+// A DeprecatedIdentifierUse is a use of a deprecated
+// identifier declared in another package.
+type DeprecatedIdentifierUse struct {
+	Pkg      *Package // the package containing the use
+	Position token.Position
+	Object   types.Object
+	Note     string
+}
+
+// DeprecatedIdentifierUses returns the uses of the deprecated identifiers
+// declared in other packages, in the specified packages. The results are
+// sorted by package paths and positions.
+func (d *CodeAnalyzer) DeprecatedIdentifierUses(pkgs []*Package) []DeprecatedIdentifierUse {
+	var uses []DeprecatedIdentifierUse
+	if len(d.deprecatedObjects) == 0 {
+		return uses
+	}
+	for _, pkg := range pkgs {
+		if pkg.PackageAnalyzeResult == nil {
+			continue
+		}
+		info := pkg.PPkg.TypesInfo
+		for _, file := range pkg.PPkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				id, ok := n.(*ast.Ident)
+				if !ok {
+					return true
+				}
+				obj := info.Uses[id]
+				if obj == nil || obj.Pkg() == pkg.PPkg.Types {
+					return true
+				}
+				// Fields and methods of instantiated types are
+				// deprecated if their generic declarations are.
+				obj = originObject(obj)
+				if note, ok := d.deprecatedObjects[obj]; ok {
+					uses = append(uses, DeprecatedIdentifierUse{
+						Pkg:      pkg,
+						Position: pkg.PPkg.Fset.PositionFor(id.Pos(), false),
+						Object:   obj,
+						Note:     note,
+					})
+				}
+				return true
+			})
+		}
+	}
+
+	sort.SliceStable(uses, func(i, j int) bool {
+		a, b := &uses[i], &uses[j]
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		if a.Position.Filename != b.Position.Filename {
+			return a.Position.Filename < b.Position.Filename
+		}
+		return a.Position.Offset < b.Position.Offset
+	})
+	return uses
+}
@@ -0,0 +1,145 @@
+package code
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"regexp"
+)
+
+// LineStats records the numbers of code, comment and blank lines.
+// A line containing both code and comments is counted as a code line.
+type LineStats struct {
+	Code    int32
+	Comment int32
+	Blank   int32
+}
+
+func (ls *LineStats) Add(other LineStats) {
+	ls.Code += other.Code
+	ls.Comment += other.Comment
+	ls.Blank += other.Blank
+}
+
+func (ls LineStats) Total() int32 {
+	return ls.Code + ls.Comment + ls.Blank
+}
+
+// See https://golang.org/s/generatedcode
+var generatedCodeComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// collectLineStats counts the lines of the Go source files of a package.
+// The lines in generated files are counted separately.
+// For cgo files, the original files are counted.
+func (d *CodeAnalyzer) collectLineStats(pkg *Package) {
+	for i := range pkg.SourceFiles {
+		info := &pkg.SourceFiles[i]
+		if info.AstFile == nil {
+			continue
+		}
+		if info.OriginalFile == "" || filepath.Ext(info.OriginalFile) != ".go" {
+			info.Generated = true // cgo generated files without original files
+			continue
+		}
+
+		content, err := ioutil.ReadFile(info.OriginalFile)
+		if err != nil {
+			log.Println("collectLineStats:", err)
+			continue
+		}
+		info.Lines, info.Generated = CountGoSourceLines(content)
+		if info.Generated {
+			pkg.GeneratedLines.Add(info.Lines)
+		} else {
+			pkg.Lines.Add(info.Lines)
+		}
+	}
+
+	if pkg.Mod != nil {
+		pkg.Mod.Lines.Add(pkg.Lines)
+		pkg.Mod.GeneratedLines.Add(pkg.GeneratedLines)
+	}
+	pkg.stats.CodeLines += pkg.Lines.Code
+	pkg.stats.CommentCodeLines += pkg.Lines.Comment
+	pkg.stats.BlankCodeLines += pkg.Lines.Blank
+	pkg.stats.GeneratedCodeLines += pkg.GeneratedLines.Total()
+}
+
+// CountGoSourceLines counts the code, comment and blank lines of Go
+// source code, and reports whether or not the code is generated.
+func CountGoSourceLines(content []byte) (stats LineStats, generated bool) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(content))
+	var s scanner.Scanner
+	s.Init(file, content, nil, scanner.ScanComments)
+
+	const (
+		blank   = 0
+		comment = 1
+		code    = 2
+	)
+	lineKinds := make([]byte, countLines(content)+1) // line numbers start from 1
+	var mark = func(from, to int, kind byte) {
+		for line := from; line <= to && line < len(lineKinds); line++ {
+			if lineKinds[line] < kind {
+				lineKinds[line] = kind
+			}
+		}
+	}
+
+	packageClauseFound := false
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		start := file.Line(pos)
+		switch tok {
+		case token.COMMENT:
+			end := file.Line(pos + token.Pos(len(lit)) - 1)
+			mark(start, end, comment)
+			if !packageClauseFound && !generated && generatedCodeComment.MatchString(lit) {
+				generated = true
+			}
+		case token.SEMICOLON:
+			if lit == "\n" {
+				continue // auto-inserted
+			}
+			mark(start, start, code)
+		default:
+			if tok == token.PACKAGE {
+				packageClauseFound = true
+			}
+			end := start
+			if lit != "" {
+				end = file.Line(pos + token.Pos(len(lit)) - 1)
+			}
+			mark(start, end, code)
+		}
+	}
+
+	for line := 1; line < len(lineKinds); line++ {
+		switch lineKinds[line] {
+		case code:
+			stats.Code++
+		case comment:
+			stats.Comment++
+		default:
+			stats.Blank++
+		}
+	}
+	return
+}
+
+// countLines returns the number of lines in content.
+// The blank line after the last line break is not counted.
+func countLines(content []byte) int {
+	n := bytes.Count(content, []byte{'\n'})
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		n++
+	}
+	return n
+}
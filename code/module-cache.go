@@ -0,0 +1,162 @@
+package code
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go101.org/gold/internal/util"
+)
+
+// A ModuleVersion is specified by a "path@version" argument.
+// Such modules are analyzed straight from the local module cache.
+type ModuleVersion struct {
+	Path    string
+	Version string
+}
+
+// parseModuleVersionArgs returns nil if none of args are in the
+// "path@version" form. Mixing such arguments with others is not allowed.
+func parseModuleVersionArgs(args []string) ([]ModuleVersion, error) {
+	var mvs []ModuleVersion
+	for _, arg := range args {
+		i := strings.LastIndex(arg, "@")
+		if i < 0 {
+			continue
+		}
+		path, version := arg[:i], arg[i+1:]
+		if path == "" || strings.HasPrefix(path, ".") || filepath.IsAbs(path) {
+			return nil, fmt.Errorf("invalid module path in %s", arg)
+		}
+		// Queries, such as "latest" and "master", need network.
+		if !strings.HasPrefix(version, "v") {
+			return nil, fmt.Errorf("%s: only exact module versions (vX.Y.Z...) are supported", arg)
+		}
+		mvs = append(mvs, ModuleVersion{Path: path, Version: version})
+	}
+	if len(mvs) > 0 && len(mvs) != len(args) {
+		return nil, errors.New("path@version arguments can't be mixed with other arguments")
+	}
+	return mvs, nil
+}
+
+func moduleCacheDir() string {
+	output, err := util.RunShellCommand(time.Second*5, "", nil, "go", "env", "GOMODCACHE")
+	if err == nil {
+		// GOMODCACHE is supported since Go 1.15.
+		if dir := strings.TrimSpace(string(output)); dir != "" {
+			return dir
+		}
+	}
+
+	gopath := filepath.SplitList(build.Default.GOPATH)
+	if len(gopath) == 0 {
+		return ""
+	}
+	return filepath.Join(gopath[0], "pkg", "mod")
+}
+
+// escapeModulePath escapes a module path or version the same way
+// the go command does for the module cache: each upper-case letter
+// is replaced with an exclamation mark followed by its lower-case form.
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if 'A' <= r && r <= 'Z' {
+			b.WriteByte('!')
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cachedModuleDir returns the directory of the extracted module
+// in the local module cache. An error is returned if the module
+// version has not been downloaded yet.
+func cachedModuleDir(modCache string, mv ModuleVersion) (string, error) {
+	escapedPath, escapedVersion := escapeModulePath(mv.Path), escapeModulePath(mv.Version)
+	zipFile := filepath.Join(modCache, "cache", "download", filepath.FromSlash(escapedPath), "@v", escapedVersion+".zip")
+	if _, err := os.Stat(zipFile); err != nil {
+		return "", fmt.Errorf(`module %[1]s@%[2]s is not found in the local module cache (%[3]s).
+Please run "go mod download %[1]s@%[2]s" in a module directory to download it first`,
+			mv.Path, mv.Version, modCache)
+	}
+	return filepath.Join(modCache, filepath.FromSlash(escapedPath)+"@"+escapedVersion), nil
+}
+
+// prepareModuleVersionsWorkspace synthesizes a temporary module which
+// requires the specified module versions. The caller should remove the
+// returned directory after use.
+func prepareModuleVersionsWorkspace(mvs []ModuleVersion) (dir string, patterns []string, err error) {
+	modCache := moduleCacheDir()
+	if modCache == "" {
+		return "", nil, errors.New("local module cache directory is unknown")
+	}
+
+	var goMod strings.Builder
+	goMod.WriteString("module gold.local/modulecache\n\ngo 1.13\n\nrequire (\n")
+	for _, mv := range mvs {
+		if _, err := cachedModuleDir(modCache, mv); err != nil {
+			return "", nil, err
+		}
+		fmt.Fprintf(&goMod, "\t%s %s\n", mv.Path, mv.Version)
+		patterns = append(patterns, mv.Path+"/...")
+	}
+	goMod.WriteString(")\n")
+
+	dir, err = ioutil.TempDir("", "gold-modulecache-")
+	if err != nil {
+		return "", nil, err
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod.String()), 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, err
+	}
+	return dir, patterns, nil
+}
+
+// moduleVersionsEnv makes sure the go command only uses the local module cache.
+// The checksums of the cached modules have been verified when they were downloaded.
+func moduleVersionsEnv() []string {
+	return append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOSUMDB=off", "GOWORK=off")
+}
+
+// confirmModuleVersions attaches the packages in the specified modules to their modules.
+func (d *CodeAnalyzer) confirmModuleVersions(mvs []ModuleVersion) {
+	modCache := moduleCacheDir()
+	for _, mv := range mvs {
+		var mod *Module
+		for _, m := range d.allModules {
+			if m.Root == mv.Path && m.Version == mv.Version {
+				mod = m // listed in the module preparation phase
+				break
+			}
+		}
+		if mod == nil {
+			dir, _ := cachedModuleDir(modCache, mv)
+			mod = &Module{
+				Dir:     dir,
+				Root:    mv.Path,
+				Version: mv.Version,
+			}
+			d.allModules = append(d.allModules, mod)
+		}
+		d.requestedModules = append(d.requestedModules, mod)
+
+		for _, pkg := range d.packageList {
+			if pkg.Mod != nil && len(pkg.Mod.Root) > len(mv.Path) {
+				continue // in a nested module
+			}
+			if path := pkg.Path(); path == mv.Path || strings.HasPrefix(path, mv.Path+"/") {
+				pkg.Mod = mod
+			}
+		}
+	}
+}
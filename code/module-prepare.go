@@ -0,0 +1,265 @@
+package code
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The json output format of "go list -m -json" and "go mod download -json".
+type goListModule struct {
+	Path    string
+	Version string
+	Main    bool
+	Dir     string
+	Replace *goListModule
+	Error   interface{} // a string for "go mod download", a struct for "go list -m"
+}
+
+func runGoCommand(timeout time.Duration, dir string, env []string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	command := exec.CommandContext(ctx, "go", args...)
+	command.Dir = dir
+	command.Env = env
+	var stderr bytes.Buffer
+	command.Stderr = &stderr
+	output, err := command.Output()
+	if err != nil && stderr.Len() > 0 {
+		log.Print(stderr.String())
+	}
+	return output, err
+}
+
+// A goListPackage is a package in the json output of "go list -deps -json".
+type goListPackage struct {
+	ImportPath string
+	Standard   bool
+	Module     *goListModule
+}
+
+// prepareModules downloads the modules which contain the packages needed
+// by the specified patterns but are not in the local module cache yet,
+// so that the progress of the slow preparation work can be reported
+// module by module. Nothing is done when not in module mode.
+//
+// The needed packages are listed with module downloading disabled.
+// The modules of the packages which fail to load are looked up in
+// the build list and downloaded, then the packages are listed again
+// to find the ones needed by the downloaded modules, until no more
+// modules need to be downloaded. The listed modules are recorded in
+// d.allModules and attached to their packages after loading.
+//
+// Failures are not fatal here. The later packages.Load call will report
+// the real problems, if there are any.
+func (d *CodeAnalyzer) prepareModules(dir string, env, flags, patterns []string, logProgress func(resetWatch bool, task int, args ...int32)) {
+	if env == nil {
+		env = os.Environ()
+	}
+	goMod, goProxy := goModuleEnv(dir, env)
+	if goMod == "" || goMod == os.DevNull {
+		return // not in module mode
+	}
+	// The last one wins if an environment variable is set multiple times.
+	listEnv := append(env[:len(env):len(env)], "GOPROXY=off")
+	listArgs := append(append([]string{"list", "-e", "-deps", "-json"}, flags...), "--")
+	listArgs = append(listArgs, patterns...)
+
+	var listedModules = make(map[string]*Module) // keyed by "path@version"
+	var buildList []goListModule                 // listed when needed
+	for {
+		output, err := runGoCommand(time.Minute*10, dir, listEnv, listArgs...)
+		if err != nil {
+			logProgress(true, SubTask_ListModules, 0, 0)
+			return
+		}
+
+		var missings []string
+		for decoder := json.NewDecoder(bytes.NewReader(output)); ; {
+			var p goListPackage
+			if err := decoder.Decode(&p); err != nil {
+				if err != io.EOF {
+					log.Println("decode package list:", err)
+				}
+				break
+			}
+			switch {
+			case p.Standard:
+			case p.Module == nil:
+				missings = append(missings, p.ImportPath)
+			case !p.Module.Main:
+				d.listModule(listedModules, p.Module)
+			}
+		}
+
+		var toDownloads []int
+		// The "path@version" arguments of "go mod download",
+		// keyed by the indexes of the modules in d.allModules.
+		var downloadArgs = make(map[int]string)
+		if len(missings) > 0 && buildList == nil {
+			buildList = listBuildList(dir, env)
+		}
+		for _, path := range missings {
+			m := moduleProviding(buildList, path)
+			if m == nil || listedModules[m.Path+"@"+m.Version] != nil {
+				continue // not provided by a dependency module, or tried before
+			}
+			mod := d.listModule(listedModules, m)
+			if arg := moduleDownloadArg(m); arg != "" && mod.Dir == "" {
+				toDownloads = append(toDownloads, len(d.allModules)-1)
+				downloadArgs[len(d.allModules)-1] = arg
+			}
+		}
+
+		// With GOPROXY=off, "go mod download" fails for every module not cached.
+		if strings.HasPrefix(goProxy, "off") {
+			toDownloads = nil
+		}
+
+		logProgress(true, SubTask_ListModules, int32(len(listedModules)), int32(len(toDownloads)))
+		if len(toDownloads) == 0 {
+			return
+		}
+		d.downloadModules(dir, env, toDownloads, downloadArgs, logProgress)
+	}
+}
+
+// listModule records a module listed by the go command, if it has not
+// been recorded yet, and returns the recorded one.
+func (d *CodeAnalyzer) listModule(listedModules map[string]*Module, m *goListModule) *Module {
+	key := m.Path + "@" + m.Version
+	if mod := listedModules[key]; mod != nil {
+		return mod
+	}
+	mod := &Module{
+		Dir:     m.Dir,
+		Root:    m.Path,
+		Version: m.Version,
+	}
+	listedModules[key] = mod
+	d.allModules = append(d.allModules, mod)
+	return mod
+}
+
+// downloadModules downloads the modules at the specified indexes
+// in d.allModules and reports the progress module by module.
+func (d *CodeAnalyzer) downloadModules(dir string, env []string, toDownloads []int, downloadArgs map[int]string, logProgress func(resetWatch bool, task int, args ...int32)) {
+	// The go command downloads each module once, so several
+	// go processes are run concurrently to speed up.
+	const NumWorkers = 4
+	var modIndexes = make(chan int, len(toDownloads))
+	for _, i := range toDownloads {
+		modIndexes <- i
+	}
+	close(modIndexes)
+
+	var progressMutex sync.Mutex
+	var numDones int32
+	var wg sync.WaitGroup
+	wg.Add(NumWorkers)
+	for range [NumWorkers]struct{}{} {
+		go func() {
+			defer wg.Done()
+			for i := range modIndexes {
+				mod := d.allModules[i]
+				output, err := runGoCommand(time.Minute*10, dir, env, "mod", "download", "-json", downloadArgs[i])
+				var failed int32
+				var m goListModule
+				if err == nil {
+					err = json.Unmarshal(output, &m)
+				}
+				if err != nil || m.Error != nil {
+					failed = 1
+				} else {
+					mod.Dir = m.Dir
+				}
+
+				progressMutex.Lock()
+				numDones++
+				logProgress(false, SubTask_DownloadModule, int32(i), numDones, int32(len(toDownloads)), failed)
+				progressMutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// listBuildList returns the modules in the build list, except the main modules.
+func listBuildList(dir string, env []string) []goListModule {
+	output, err := runGoCommand(time.Minute*10, dir, env, "list", "-m", "-json", "all")
+	if err != nil {
+		return nil
+	}
+	var mods []goListModule
+	for decoder := json.NewDecoder(bytes.NewReader(output)); ; {
+		var m goListModule
+		if err := decoder.Decode(&m); err != nil {
+			if err != io.EOF {
+				log.Println("decode module list:", err)
+			}
+			break
+		}
+		if !m.Main {
+			mods = append(mods, m)
+		}
+	}
+	return mods
+}
+
+// moduleProviding returns the module in a build list which provides
+// the package with the specified import path, or nil if none does.
+// The module with the longest matching path wins, as the go command does.
+func moduleProviding(buildList []goListModule, pkgPath string) *goListModule {
+	var found *goListModule
+	for i := range buildList {
+		m := &buildList[i]
+		if pkgPath != m.Path && !strings.HasPrefix(pkgPath, m.Path+"/") {
+			continue
+		}
+		if found == nil || len(m.Path) > len(found.Path) {
+			found = m
+		}
+	}
+	return found
+}
+
+// moduleDownloadArg returns the "path@version" argument of "go mod download"
+// for a module. Replaced modules are downloaded as their replacements.
+// Blank is returned for modules replaced by local directories.
+func moduleDownloadArg(m *goListModule) string {
+	if m.Replace != nil {
+		m = m.Replace
+	}
+	if m.Version == "" {
+		return ""
+	}
+	return m.Path + "@" + m.Version
+}
+
+// goModuleEnv returns the GOMOD and GOPROXY values used by the go command.
+// GOMOD is blank when not in module mode, or os.DevNull when there is no
+// main module. GOPROXY might be set in the environment or the go env file.
+func goModuleEnv(dir string, env []string) (goMod, goProxy string) {
+	output, err := runGoCommand(time.Minute, dir, env, "env", "GOMOD", "GOPROXY")
+	if err != nil {
+		return "", ""
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) != 2 {
+		return "", ""
+	}
+	return strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1])
+}
+
+// ModuleAt returns the module at the specified index.
+// It is used to report module preparation progress.
+func (d *CodeAnalyzer) ModuleAt(i int) *Module {
+	return d.allModules[i]
+}
@@ -15,6 +15,10 @@ type Module struct {
 	Dir     string
 	Root    string // root import path
 	Version string
+
+	// Line statistics of the analyzed packages in the module.
+	Lines          LineStats // not including generated files
+	GeneratedLines LineStats
 }
 
 type Package struct {
@@ -26,6 +30,14 @@ type Package struct {
 	DepLevel int // 0 means the level is not determined yet
 	DepedBys []*Package
 
+	// Errors found when loading and type checking the package.
+	// They are only possible when errors are tolerated.
+	Errors []PackageError
+
+	// Whether or not the package is matched by the arguments.
+	// It is false for dependency packages.
+	Requested bool
+
 	// This field might be shared with PackageForDisplay
 	// for concurrenct reads.
 	*PackageAnalyzeResult
@@ -35,6 +47,12 @@ func (p *Package) Path() string {
 	return p.PPkg.PkgPath // might be prefixed with "vendor/", which is different from import path.
 }
 
+// A PackageError is a loading, parsing or type checking error in a package.
+type PackageError struct {
+	Position token.Position // might be invalid
+	Message  string
+}
+
 type PackageAnalyzeResult struct {
 	AllTypeNames []*TypeName
 	AllFunctions []*Function
@@ -42,6 +60,26 @@ type PackageAnalyzeResult struct {
 	AllConstants []*Constant
 	AllImports   []*Import
 	SourceFiles  []SourceFileInfo
+
+	// Source files excluded by the build configuration.
+	IgnoredFiles []IgnoredFileInfo
+
+	// C declarations in cgo preambles and local header files,
+	// and the C symbols used in Go code (sorted by name).
+	CgoDeclarations       []CgoDeclaration
+	CgoSymbols            []CgoSymbol
+	cgoDeclarationIndexes map[string]int
+
+	// Functions implemented in assembly files.
+	AsmFunctions       []AsmFunction
+	asmFunctionIndexes map[string]int
+
+	// Line statistics of Go source files.
+	Lines          LineStats // not including generated files
+	GeneratedLines LineStats
+
+	// The statistics of this package only.
+	stats Stats
 }
 
 func NewPackageAnalyzeResult() *PackageAnalyzeResult {
@@ -74,6 +112,15 @@ func (r *PackageAnalyzeResult) SourceFileInfoByBareFilename(bareFilename string)
 	return nil
 }
 
+func (r *PackageAnalyzeResult) IgnoredFileInfoByBareFilename(bareFilename string) *IgnoredFileInfo {
+	for i := range r.IgnoredFiles {
+		if r.IgnoredFiles[i].BareFilename == bareFilename {
+			return &r.IgnoredFiles[i]
+		}
+	}
+	return nil
+}
+
 // ToDo: better to maintain a global sourceFilePath => SourceFileInfo table?
 //func (r *PackageAnalyzeResult) SourceFileInfo(srcPath string) *SourceFileInfo {
 func (r *PackageAnalyzeResult) SourceFileInfoByFilePath(srcPath string) *SourceFileInfo {
@@ -630,6 +677,11 @@ type Function struct {
 	Type    *TypeInfo
 	Pkg     *Package // some duplicated with types.Func.Pkg(), except builtin functions
 	AstDecl *ast.FuncDecl
+
+	Metrics FunctionMetrics // zero for functions without bodies
+
+	// Only calculated for functions declared in main packages.
+	Unreachable bool
 }
 
 func (f *Function) Name() string {
@@ -845,6 +897,7 @@ func (fld *Field) Position() token.Position {
 
 type Method struct {
 	AstFunc      *ast.FuncDecl      // for concrete methods
+	Function     *Function          // for concrete methods
 	AstInterface *ast.InterfaceType // for interface methods
 	AstField     *ast.Field         // for interface methods
 
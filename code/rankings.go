@@ -0,0 +1,229 @@
+package code
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// A RankedItem is an item in a top-N ranking list.
+type RankedItem struct {
+	Pkg      *Package
+	Name     string         // blank for packages, "T.M" for methods
+	Position token.Position // invalid for packages
+	Count    int
+}
+
+// Rankings are top-N ranking lists of some resources
+// in the analyzed packages.
+type Rankings struct {
+	MostImplementedInterfaces   []RankedItem
+	TypesWithMostMethods        []RankedItem
+	LargestPackagesByDecls      []RankedItem
+	LargestPackagesByLines      []RankedItem
+	MostImportedPackages        []RankedItem
+	FunctionsWithMostParameters []RankedItem
+	MostReferencedIdentifiers   []RankedItem
+}
+
+// The kinds of the packages using identifiers.
+const (
+	userKind_Normal = iota
+	userKind_Test   // _test.go files
+	userKind_Main
+	numUserKinds
+)
+
+type identifierUses [numUserKinds]int32
+
+func (uses *identifierUses) total() int32 {
+	return uses[userKind_Normal] + uses[userKind_Test] + uses[userKind_Main]
+}
+
+// analyzePackage_CountIdentifierUses counts the uses of the exported
+// identifiers (package-level ones, fields and methods) declared in
+// other packages.
+func (d *CodeAnalyzer) analyzePackage_CountIdentifierUses(pkg *Package) {
+	if pkg.PPkg.TypesInfo == nil {
+		return
+	}
+	if d.exportedIdentifierUses == nil {
+		d.exportedIdentifierUses = make(map[types.Object]identifierUses, 4096)
+	}
+
+	packageUserKind := userKind_Normal
+	if pkg.PPkg.Name == "main" {
+		packageUserKind = userKind_Main
+	}
+	for id, obj := range pkg.PPkg.TypesInfo.Uses {
+		objPkg := obj.Pkg()
+		if objPkg == nil || objPkg == pkg.PPkg.Types || !obj.Exported() {
+			continue
+		}
+		if p := obj.Parent(); p != nil && p != objPkg.Scope() {
+			continue // locals (fields and methods have no parents)
+		}
+		// Both the internal and external test files are viewed as tests.
+		userKind := packageUserKind
+		if strings.HasSuffix(pkg.PPkg.Fset.PositionFor(id.Pos(), false).Filename, "_test.go") {
+			userKind = userKind_Test
+		}
+		obj = originObject(obj)
+		uses := d.exportedIdentifierUses[obj]
+		uses[userKind]++
+		d.exportedIdentifierUses[obj] = uses
+	}
+}
+
+// originObject returns the generic declaration of a field or method
+// of an instantiated type, or the object itself for other objects.
+func originObject(obj types.Object) types.Object {
+	switch obj := obj.(type) {
+	case *types.Func:
+		return obj.Origin()
+	case *types.Var:
+		return obj.Origin()
+	}
+	return obj
+}
+
+// ExportedIdentifierUses returns the number of uses of an exported
+// identifier in the packages other than its own.
+func (d *CodeAnalyzer) ExportedIdentifierUses(obj types.Object) int {
+	uses := d.exportedIdentifierUses[originObject(obj)]
+	return int(uses.total())
+}
+
+// countImplementations counts the named types (and pointers of named
+// types) implementing an interface type, as the type listings in pages do.
+// The interface itself and unnamed types are not counted.
+func (d *CodeAnalyzer) countImplementations(itn *TypeName) int {
+	n := 0
+	for _, impledBy := range itn.Named.ImplementedBys {
+		if tn, _ := d.RetrieveTypeName(impledBy); tn != nil && tn != itn {
+			n++
+		}
+	}
+	return n
+}
+
+// Rankings builds the top-n ranking lists. Items with zero
+// counts are excluded. The lists are not cached.
+func (d *CodeAnalyzer) Rankings(n int) *Rankings {
+	return d.RankingsOf(d.packageList, n)
+}
+
+// RankingsOf builds the top-n ranking lists of the items declared
+// in the specified packages. The package rankings are only built
+// when there are multiple packages.
+func (d *CodeAnalyzer) RankingsOf(pkgs []*Package, n int) *Rankings {
+	var r Rankings
+	var keepTopN = func(items []RankedItem) []RankedItem {
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Count > items[j].Count
+		})
+		if len(items) > n {
+			items = items[:n]
+		}
+		for i := range items {
+			if items[i].Count == 0 {
+				return items[:i]
+			}
+		}
+		return items
+	}
+
+	var inScope = make(map[*Package]bool, len(pkgs))
+	for _, pkg := range pkgs {
+		inScope[pkg] = true
+
+		numDecls := len(pkg.AllTypeNames) + len(pkg.AllFunctions) + len(pkg.AllVariables) + len(pkg.AllConstants)
+		r.LargestPackagesByDecls = append(r.LargestPackagesByDecls, RankedItem{Pkg: pkg, Count: numDecls})
+
+		numLines := 0
+		for i := range pkg.SourceFiles {
+			if astFile := pkg.SourceFiles[i].AstFile; astFile != nil {
+				if f := pkg.PPkg.Fset.File(astFile.Pos()); f != nil {
+					numLines += f.LineCount()
+				}
+			}
+		}
+		r.LargestPackagesByLines = append(r.LargestPackagesByLines, RankedItem{Pkg: pkg, Count: numLines})
+
+		r.MostImportedPackages = append(r.MostImportedPackages, RankedItem{Pkg: pkg, Count: len(pkg.DepedBys)})
+
+		for _, tn := range pkg.AllTypeNames {
+			if tn.Named == nil || !tn.Exported() {
+				continue
+			}
+			item := RankedItem{Pkg: pkg, Name: tn.Name(), Position: tn.Position()}
+			if types.IsInterface(tn.Named.TT) {
+				item.Count = d.countImplementations(tn)
+				r.MostImplementedInterfaces = append(r.MostImplementedInterfaces, item)
+			} else {
+				item.Count = len(tn.Named.AllMethods)
+				r.TypesWithMostMethods = append(r.TypesWithMostMethods, item)
+			}
+		}
+
+		for _, f := range pkg.AllFunctions {
+			if f.Func == nil || !f.Exported() || f.AstDecl == nil {
+				continue
+			}
+			sig := f.Func.Type().(*types.Signature)
+			name := f.Name()
+			if recv := sig.Recv(); recv != nil {
+				rt := recv.Type()
+				if ptr, ok := rt.(*types.Pointer); ok {
+					rt = ptr.Elem()
+				}
+				if named, ok := rt.(*types.Named); ok {
+					name = named.Obj().Name() + "." + name
+				}
+			}
+			r.FunctionsWithMostParameters = append(r.FunctionsWithMostParameters, RankedItem{
+				Pkg: pkg, Name: name, Position: f.Position(), Count: sig.Params().Len(),
+			})
+		}
+	}
+
+	for obj, uses := range d.exportedIdentifierUses {
+		if obj.Parent() != obj.Pkg().Scope() {
+			continue // fields and methods
+		}
+		pkg := d.PackageByPath(obj.Pkg().Path())
+		if pkg == nil || !inScope[pkg] {
+			continue
+		}
+		r.MostReferencedIdentifiers = append(r.MostReferencedIdentifiers, RankedItem{
+			Pkg:      pkg,
+			Name:     obj.Name(),
+			Position: pkg.PPkg.Fset.PositionFor(obj.Pos(), false),
+			Count:    int(uses.total()),
+		})
+	}
+	// Map iteration order is random, so sort them by names firstly.
+	sort.Slice(r.MostReferencedIdentifiers, func(i, j int) bool {
+		a, b := &r.MostReferencedIdentifiers[i], &r.MostReferencedIdentifiers[j]
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		return a.Name < b.Name
+	})
+
+	if len(pkgs) < 2 {
+		r.LargestPackagesByDecls = nil
+		r.LargestPackagesByLines = nil
+		r.MostImportedPackages = nil
+	}
+
+	r.MostImplementedInterfaces = keepTopN(r.MostImplementedInterfaces)
+	r.TypesWithMostMethods = keepTopN(r.TypesWithMostMethods)
+	r.LargestPackagesByDecls = keepTopN(r.LargestPackagesByDecls)
+	r.LargestPackagesByLines = keepTopN(r.LargestPackagesByLines)
+	r.MostImportedPackages = keepTopN(r.MostImportedPackages)
+	r.FunctionsWithMostParameters = keepTopN(r.FunctionsWithMostParameters)
+	r.MostReferencedIdentifiers = keepTopN(r.MostReferencedIdentifiers)
+	return &r
+}
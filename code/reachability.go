@@ -0,0 +1,224 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+// analyzePackages_FindUnreachableFunctions marks the functions and methods
+// declared in the main packages which are not reachable from the entry
+// points of the analyzed code. The entry points include:
+// * the main and init functions, and the initializers of package-level variables;
+// * the test, benchmark, example and fuzz functions in _test.go files;
+// * the exported functions and methods of the non-main ones of the main packages;
+// * functions with //export or //go:linkname directives.
+//
+// The analysis is conservative. All the methods which might be called
+// through a reachable interface method are viewed as reachable.
+// Function literals are viewed as parts of their enclosing functions.
+// Functions only called through reflection are reported as unreachable.
+func (d *CodeAnalyzer) analyzePackages_FindUnreachableFunctions() {
+	// Instantiated generic functions share
+	// the positions of their origin functions.
+	var funcTable = make(map[token.Pos]*Function, 8192)
+	var methodsByName = make(map[string][]*Function, 1024)
+	for _, pkg := range d.packageList {
+		if pkg.PackageAnalyzeResult == nil {
+			continue
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Func == nil || f.AstDecl == nil {
+				continue
+			}
+			funcTable[f.Func.Pos()] = f
+			if f.IsMethod() {
+				methodsByName[f.Name()] = append(methodsByName[f.Name()], f)
+			}
+		}
+	}
+
+	var reached = make(map[*Function]bool, len(funcTable))
+	var reachedInterfaceMethods = make(map[*types.Func]bool, 1024)
+	var queue = make([]*Function, 0, 1024)
+
+	var reach = func(f *Function) {
+		if !reached[f] {
+			reached[f] = true
+			queue = append(queue, f)
+		}
+	}
+
+	var reachObject func(obj types.Object)
+	reachObject = func(obj types.Object) {
+		fn, ok := obj.(*types.Func)
+		if !ok {
+			return
+		}
+		if f := funcTable[fn.Pos()]; f != nil {
+			reach(f)
+			return
+		}
+		sig := fn.Type().(*types.Signature)
+		if sig.Recv() == nil || !types.IsInterface(sig.Recv().Type()) {
+			return // functions without bodies (in Go), or of packages not analyzed.
+		}
+		if reachedInterfaceMethods[fn] {
+			return
+		}
+		reachedInterfaceMethods[fn] = true
+
+		itt := d.TryRegisteringType(sig.Recv().Type().Underlying(), false)
+		if itt == nil {
+			// For example, the methods of type parameter constraints.
+			// Resolve the call by method name.
+			for _, m := range methodsByName[fn.Name()] {
+				if fn.Exported() || m.Pkg.PPkg.Types == fn.Pkg() {
+					reach(m)
+				}
+			}
+			return
+		}
+		for _, impl := range itt.ImplementedBys {
+			if types.IsInterface(impl.TT) {
+				continue
+			}
+			if m, _, _ := types.LookupFieldOrMethod(impl.TT, true, fn.Pkg(), fn.Name()); m != nil && m != obj {
+				reachObject(m)
+			}
+		}
+	}
+
+	var reachUsedFunctions = func(pkg *Package, node ast.Node) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok {
+				if obj := pkg.PPkg.TypesInfo.Uses[id]; obj != nil {
+					reachObject(obj)
+				}
+			}
+			return true
+		})
+	}
+
+	// Roots.
+	for _, pkg := range d.packageList {
+		if pkg.PackageAnalyzeResult == nil {
+			continue
+		}
+
+		for _, file := range pkg.PPkg.Syntax {
+			for _, decl := range file.Decls {
+				if gd, ok := decl.(*ast.GenDecl); ok && gd.Tok == token.VAR {
+					reachUsedFunctions(pkg, gd)
+				}
+			}
+		}
+
+		isMain := pkg.PPkg.Name == "main"
+		for _, f := range pkg.AllFunctions {
+			if f.Func == nil || f.AstDecl == nil {
+				continue
+			}
+			if f.Name() == "init" && !f.IsMethod() {
+				reach(f)
+				continue
+			}
+			if !pkg.Requested {
+				continue
+			}
+			if isEntryFunction(f, isMain) {
+				reach(f)
+			}
+		}
+	}
+
+	for len(queue) > 0 {
+		f := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		if f.AstDecl.Body != nil {
+			reachUsedFunctions(f.Pkg, f.AstDecl.Body)
+		}
+	}
+
+	for _, pkg := range d.packageList {
+		if pkg.PackageAnalyzeResult == nil || !pkg.Requested {
+			continue
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Func != nil && f.AstDecl != nil && f.Name() != "_" {
+				f.Unreachable = !reached[f]
+			}
+		}
+	}
+}
+
+// isEntryFunction reports whether or not a function
+// declared in a main package is an entry point.
+func isEntryFunction(f *Function, inMainPackage bool) bool {
+	if inMainPackage {
+		if f.Name() == "main" && !f.IsMethod() {
+			return true
+		}
+	} else if f.Exported() {
+		return true
+	}
+
+	if doc := f.AstDecl.Doc; doc != nil {
+		for _, c := range doc.List {
+			if strings.HasPrefix(c.Text, "//export ") || strings.HasPrefix(c.Text, "//go:linkname ") {
+				return true
+			}
+		}
+	}
+
+	return isTestingFunction(f)
+}
+
+// isTestingFunction reports whether or not a function is a test,
+// benchmark, example or fuzz function declared in a _test.go file.
+func isTestingFunction(f *Function) bool {
+	if f.IsMethod() || f.AstDecl == nil {
+		return false
+	}
+	filename := f.Pkg.PPkg.Fset.PositionFor(f.AstDecl.Pos(), false).Filename
+	if !strings.HasSuffix(filename, "_test.go") {
+		return false
+	}
+	for _, prefix := range []string{"Test", "Benchmark", "Example", "Fuzz"} {
+		if strings.HasPrefix(f.Name(), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnreachableFunctions returns the unreachable functions and methods
+// declared in the specified packages, sorted by package paths and positions.
+func (d *CodeAnalyzer) UnreachableFunctions(pkgs []*Package) []*Function {
+	var funcs []*Function
+	for _, pkg := range pkgs {
+		if pkg.PackageAnalyzeResult == nil {
+			continue
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Unreachable {
+				funcs = append(funcs, f)
+			}
+		}
+	}
+
+	sort.Slice(funcs, func(i, j int) bool {
+		a, b := funcs[i], funcs[j]
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		pa, pb := a.Position(), b.Position()
+		if pa.Filename != pb.Filename {
+			return pa.Filename < pb.Filename
+		}
+		return pa.Offset < pb.Offset
+	})
+	return funcs
+}
@@ -43,7 +43,7 @@ func (d *CodeAnalyzer) CollectSourceFiles() {
 
 		for _, path := range pkg.PPkg.OtherFiles {
 			d.sourceFile2PackageTable[path] = pkg
-			d.stats.FilesWithoutGenerateds++
+			pkg.stats.FilesWithoutGenerateds++
 		}
 
 		for _, path := range pkg.PPkg.CompiledGoFiles {
@@ -55,10 +55,14 @@ func (d *CodeAnalyzer) CollectSourceFiles() {
 				//log.Println("! in GoFiles but not CompiledGoFiles:", path)
 				d.sourceFile2PackageTable[path] = pkg
 			}
-			d.stats.FilesWithoutGenerateds++
+			pkg.stats.FilesWithoutGenerateds++
 		}
 
 		d.BuildCgoFileMappings(pkg)
+		d.collectIgnoredFiles(pkg)
+		d.collectCgoSymbols(pkg)
+		d.collectAsmFunctions(pkg)
+		d.collectLineStats(pkg)
 
 		//d.stats.Files += int32(len(pkg.SourceFiles))
 	}
@@ -88,6 +92,16 @@ type SourceFileInfo struct {
 	// If an original Go file has a corresponding generated file,
 	// then the ast file is for that generated file.
 	AstFile *ast.File
+
+	// The "//go:build" or "// +build" lines, if any.
+	BuildConstraint string
+
+	// The preambles of the `import "C"` declarations in a cgo file.
+	CgoPreambles []*ast.CommentGroup
+
+	// Line statistics, for Go files only.
+	Lines     LineStats
+	Generated bool
 }
 
 var cgoGenIdent = []byte(`// Code generated by cmd/cgo; DO NOT EDIT.`)
@@ -175,10 +189,11 @@ func (d *CodeAnalyzer) BuildCgoFileMappings(pkg *Package) {
 			// ToDo: verify compiledFile must be also in  pkg.PPkg.GoFiles
 			pkg.SourceFiles = append(pkg.SourceFiles,
 				SourceFileInfo{
-					BareFilename:  filepath.Base(compiledFile),
-					OriginalFile:  compiledFile,
-					GeneratedFile: compiledFile,
-					AstFile:       pkg.PPkg.Syntax[i],
+					BareFilename:    filepath.Base(compiledFile),
+					OriginalFile:    compiledFile,
+					GeneratedFile:   compiledFile,
+					AstFile:         pkg.PPkg.Syntax[i],
+					BuildConstraint: astFileBuildConstraint(pkg.PPkg.Syntax[i]),
 				},
 			)
 			continue
@@ -191,6 +206,7 @@ func (d *CodeAnalyzer) BuildCgoFileMappings(pkg *Package) {
 
 		if info.OriginalFile != "" && info.GeneratedFile != info.OriginalFile {
 			d.generatedFile2OriginalFileTable[info.GeneratedFile] = info.OriginalFile
+			info.BuildConstraint = readBuildConstraint(info.OriginalFile)
 		}
 
 		//info.AstFile = pkg.PPkg.Syntax[i]
@@ -210,8 +226,9 @@ func (d *CodeAnalyzer) BuildCgoFileMappings(pkg *Package) {
 	for _, path := range pkg.PPkg.OtherFiles {
 		pkg.SourceFiles = append(pkg.SourceFiles,
 			SourceFileInfo{
-				BareFilename: filepath.Base(path),
-				OriginalFile: path,
+				BareFilename:    filepath.Base(path),
+				OriginalFile:    path,
+				BuildConstraint: readBuildConstraint(path),
 			},
 		)
 	}
@@ -0,0 +1,265 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+)
+
+// sumKinds calculates the type name counts of some kind categories.
+func (s *Stats) sumKinds() {
+	var sum = func(kinds ...reflect.Kind) (r int32) {
+		for _, k := range kinds {
+			r += s.ExportedTypeNamesByKind[k]
+		}
+		return
+	}
+	s.ExportedUnsignedTypeNames = sum(reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr)
+	s.ExportedIntergerTypeNames = s.ExportedUnsignedTypeNames + sum(reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64)
+	s.ExportedNumericTypeNames = s.ExportedIntergerTypeNames + sum(reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128)
+	s.ExportedBasicTypeNames = s.ExportedNumericTypeNames + sum(reflect.Bool, reflect.String)
+	s.ExportedCompositeTypeNames = sum(reflect.Array, reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.Struct, reflect.UnsafePointer)
+	s.ExportedTypeNames = s.ExportedCompositeTypeNames + s.ExportedBasicTypeNames
+}
+
+// add adds the counters in another Stats to s.
+// All the exported fields are either int32 or [N]int32 values.
+func (s *Stats) add(other *Stats) {
+	v, o := reflect.ValueOf(s).Elem(), reflect.ValueOf(other).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Int32:
+			f.SetInt(f.Int() + o.Field(i).Int())
+		case reflect.Array:
+			for k, of := 0, o.Field(i); k < f.Len(); k++ {
+				f.Index(k).SetInt(f.Index(k).Int() + of.Index(k).Int())
+			}
+		default:
+			panic("unexpected Stats field kind: " + f.Kind().String())
+		}
+	}
+	s.roughTypeNameCount += other.roughTypeNameCount
+	s.roughExportedIdentifierCount += other.roughExportedIdentifierCount
+}
+
+// analyzePackage_CollectWholeCodeStatistics collects the statistics
+// of unexported package-level resources and local resources.
+// The statistics of exported resources are collected in
+// analyzePackage_CollectDeclarations and analyzePackage_CollectMoreStatistics.
+func (d *CodeAnalyzer) analyzePackage_CollectWholeCodeStatistics(pkg *Package) {
+	if pkg.Path() == "builtin" || pkg.PPkg.TypesInfo == nil {
+		return
+	}
+
+	var countSignature = func(sig *types.Signature) {
+		pkg.stats.AllFunctions++
+		pkg.stats.AllFunctionParameters += int32(sig.Params().Len())
+		pkg.stats.AllFunctionResults += int32(sig.Results().Len())
+		incSliceStat(pkg.stats.AllFunctionsByParameterCount[:], sig.Params().Len())
+		incSliceStat(pkg.stats.AllFunctionsByResultCount[:], sig.Results().Len())
+	}
+
+	for _, tn := range pkg.AllTypeNames {
+		if !tn.Exported() {
+			pkg.stats.UnexportedTypeNames++
+			pkg.stats.UnexportedTypeNamesByKind[tn.Denoting().Kind()]++
+		}
+	}
+	for _, v := range pkg.AllVariables {
+		if !v.Exported() {
+			pkg.stats.UnexportedVariables++
+		}
+	}
+	for _, c := range pkg.AllConstants {
+		if !c.Exported() {
+			pkg.stats.UnexportedConstants++
+		}
+	}
+	for _, f := range pkg.AllFunctions {
+		if f.Func == nil {
+			continue
+		}
+		sig := f.Func.Type().(*types.Signature)
+		countSignature(sig)
+		if recv := sig.Recv(); recv != nil {
+			rt := recv.Type()
+			if ptr, ok := rt.(*types.Pointer); ok {
+				rt = ptr.Elem()
+			}
+			if named, ok := rt.(*types.Named); ok && !named.Obj().Exported() {
+				pkg.stats.MethodsOfUnexportedTypes++
+			} else if !f.Exported() {
+				pkg.stats.UnexportedMethods++
+			}
+		} else if !f.Exported() {
+			pkg.stats.UnexportedFunctions++
+		}
+	}
+
+	var info = pkg.PPkg.TypesInfo
+	var pkgScope = pkg.PPkg.Types.Scope()
+	var checkFunctionTypedVariable = func(id *ast.Ident) {
+		if v, ok := info.Defs[id].(*types.Var); ok {
+			if _, ok := v.Type().Underlying().(*types.Signature); ok {
+				pkg.stats.FunctionTypedVariables++
+			}
+		}
+	}
+	for _, file := range pkg.PPkg.Syntax {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				if sig, ok := info.TypeOf(n).(*types.Signature); ok {
+					pkg.stats.FunctionLiterals++
+					countSignature(sig)
+				}
+			case *ast.TypeSpec:
+				if obj := info.Defs[n.Name]; obj != nil && obj.Parent() != pkgScope {
+					pkg.stats.LocalTypeNames++
+					pkg.stats.LocalTypeNamesByKind[Kind(obj.Type())]++
+				}
+			case *ast.ValueSpec:
+				for _, id := range n.Names {
+					checkFunctionTypedVariable(id)
+				}
+			case *ast.AssignStmt:
+				if n.Tok == token.DEFINE {
+					for _, lhs := range n.Lhs {
+						if id, ok := lhs.(*ast.Ident); ok {
+							checkFunctionTypedVariable(id)
+						}
+					}
+				}
+			}
+			return true
+		})
+	}
+}
+
+// Statistics returns the statistics of the package only.
+func (p *Package) Statistics() Stats {
+	return p.stats
+}
+
+// StatisticsOf combines the statistics of the specified packages.
+func StatisticsOf(pkgs []*Package) Stats {
+	var stats Stats
+	for _, pkg := range pkgs {
+		if pkg.PackageAnalyzeResult != nil {
+			stats.add(&pkg.stats)
+		}
+	}
+	stats.sumKinds()
+	return stats
+}
+
+// ModulePackages returns the analyzed packages in a module.
+func (d *CodeAnalyzer) ModulePackages(mod *Module) []*Package {
+	var pkgs []*Package
+	for _, pkg := range d.packageList {
+		if pkg.Mod == mod {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+// ModuleByRoot returns the module containing analyzed packages
+// with the specified root import path. The standard module is
+// looked up by "std".
+func (d *CodeAnalyzer) ModuleByRoot(root string) *Module {
+	if root == "std" {
+		return d.stdModule
+	}
+	for _, pkg := range d.packageList {
+		if pkg.Mod != nil && pkg.Mod != d.stdModule && pkg.Mod.Root == root {
+			return pkg.Mod
+		}
+	}
+	return nil
+}
+
+// MainPackages returns the packages matched by the arguments,
+// not including the dependency packages.
+func (d *CodeAnalyzer) MainPackages() []*Package {
+	var pkgs []*Package
+	for _, pkg := range d.packageList {
+		if pkg.Requested {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	return pkgs
+}
+
+// PackageMetrics are some size and dependency metrics of a package.
+type PackageMetrics struct {
+	Path     string
+	Standard bool
+	Main     bool // matched by the arguments
+
+	// Package-level declarations. Functions include methods.
+	TypeNames int
+	Functions int
+	Variables int
+	Constants int
+
+	ExportedTypeNames int32
+	ExportedFunctions int32
+	ExportedMethods   int32
+	ExportedVariables int32
+	ExportedConstants int32
+
+	Deps     int
+	DepedBys int
+	DepLevel int
+
+	Files          int // including generated and non-Go ones
+	GoFiles        int32
+	CodeLines      int32 // not including generated files
+	CommentLines   int32
+	BlankLines     int32
+	GeneratedLines int32
+}
+
+// PackageMetricsList returns the metrics of the analyzed packages.
+func (d *CodeAnalyzer) PackageMetricsList() []PackageMetrics {
+	list := make([]PackageMetrics, 0, len(d.packageList))
+	for _, pkg := range d.packageList {
+		if pkg.PackageAnalyzeResult == nil {
+			continue
+		}
+		list = append(list, PackageMetrics{
+			Path:     pkg.Path(),
+			Standard: d.IsStandardPackage(pkg),
+			Main:     pkg.Requested,
+
+			TypeNames: len(pkg.AllTypeNames),
+			Functions: len(pkg.AllFunctions),
+			Variables: len(pkg.AllVariables),
+			Constants: len(pkg.AllConstants),
+
+			ExportedTypeNames: pkg.stats.ExportedTypeNames,
+			ExportedFunctions: pkg.stats.ExportedFunctions,
+			ExportedMethods:   pkg.stats.ExportedMethods,
+			ExportedVariables: pkg.stats.ExportedVariables,
+			ExportedConstants: pkg.stats.ExportedConstants,
+
+			Deps:     len(pkg.Deps),
+			DepedBys: len(pkg.DepedBys),
+			DepLevel: pkg.DepLevel,
+
+			Files:          len(pkg.SourceFiles),
+			GoFiles:        pkg.stats.AstFiles,
+			CodeLines:      pkg.Lines.Code,
+			CommentLines:   pkg.Lines.Comment,
+			BlankLines:     pkg.Lines.Blank,
+			GeneratedLines: pkg.GeneratedLines.Total(),
+		})
+	}
+	return list
+}
@@ -0,0 +1,118 @@
+package code
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// An UnusedIdentifier is an exported identifier which is
+// not used by any other analyzed packages.
+type UnusedIdentifier struct {
+	Pkg      *Package
+	Kind     string // "type", "func", "var", "const", "method", "field"
+	Name     string // "T.M" for methods and fields
+	Position token.Position
+}
+
+// UnusedIdentifierOptions specifies which kinds of
+// packages are viewed as users of identifiers.
+// Other packages are always viewed as users.
+type UnusedIdentifierOptions struct {
+	TestPackagesAsUsers bool // the _test.go files of all packages
+	MainPackagesAsUsers bool
+}
+
+func (uses *identifierUses) countFor(options UnusedIdentifierOptions) int32 {
+	n := uses[userKind_Normal]
+	if options.TestPackagesAsUsers {
+		n += uses[userKind_Test]
+	}
+	if options.MainPackagesAsUsers {
+		n += uses[userKind_Main]
+	}
+	return n
+}
+
+// UnusedExportedIdentifiers returns the exported identifiers declared
+// in the specified packages but not used by other analyzed packages.
+//
+// Only the explicit fields and methods of exported named types are
+// checked. Methods which might be used through interfaces implemented
+// by their receiver types are viewed as used. The test, benchmark,
+// example and fuzz functions in _test.go files are not reported.
+func (d *CodeAnalyzer) UnusedExportedIdentifiers(pkgs []*Package, options UnusedIdentifierOptions) []UnusedIdentifier {
+	var unuseds []UnusedIdentifier
+	for _, pkg := range pkgs {
+		if pkg.PackageAnalyzeResult == nil || pkg.PPkg.Name == "main" || pkg.Path() == "builtin" || pkg.Path() == "unsafe" {
+			continue
+		}
+		var check = func(obj types.Object, kind, name string) {
+			if !obj.Exported() {
+				return
+			}
+			uses := d.exportedIdentifierUses[obj]
+			if uses.countFor(options) > 0 {
+				return
+			}
+			unuseds = append(unuseds, UnusedIdentifier{
+				Pkg:      pkg,
+				Kind:     kind,
+				Name:     name,
+				Position: pkg.PPkg.Fset.PositionFor(obj.Pos(), false),
+			})
+		}
+
+		for _, tn := range pkg.AllTypeNames {
+			check(tn.TypeName, "type", tn.Name())
+			if tn.Alias != nil || !tn.Exported() {
+				continue
+			}
+			named, ok := tn.Named.TT.(*types.Named)
+			if !ok {
+				continue
+			}
+			if st, ok := named.Underlying().(*types.Struct); ok {
+				for i := 0; i < st.NumFields(); i++ {
+					if f := st.Field(i); !f.Embedded() {
+						check(f, "field", tn.Name()+"."+f.Name())
+					}
+				}
+			}
+			if types.IsInterface(named) {
+				continue
+			}
+			var interfaceMethods = make(map[string]bool)
+			for _, impl := range tn.Named.Implements {
+				for _, sel := range impl.Interface.AllMethods {
+					interfaceMethods[sel.Name()] = true
+				}
+			}
+			for i := 0; i < named.NumMethods(); i++ {
+				if m := named.Method(i); !interfaceMethods[m.Name()] {
+					check(m, "method", tn.Name()+"."+m.Name())
+				}
+			}
+		}
+		for _, f := range pkg.AllFunctions {
+			if f.Func != nil && !f.IsMethod() && !isTestingFunction(f) {
+				check(f.Func, "func", f.Name())
+			}
+		}
+		for _, v := range pkg.AllVariables {
+			check(v.Var, "var", v.Name())
+		}
+		for _, c := range pkg.AllConstants {
+			check(c.Const, "const", c.Name())
+		}
+	}
+
+	sort.Slice(unuseds, func(i, j int) bool {
+		a, b := &unuseds[i], &unuseds[j]
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		return a.Name < b.Name
+	})
+	return unuseds
+}
@@ -1,8 +1,13 @@
 module go101.org/gold
 
-go 1.13
+go 1.19
 
 require (
 	golang.org/x/text v0.3.3
 	golang.org/x/tools v0.0.0-20200804011535-6c149bb5ef0d
 )
+
+require (
+	golang.org/x/mod v0.3.0 // indirect
+	golang.org/x/xerrors v0.0.0-20191204190536-9bdfabe68543 // indirect
+)
@@ -8,9 +8,11 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
+	"go101.org/gold/code"
 	"go101.org/gold/internal/server"
 	"go101.org/gold/internal/util"
 )
@@ -80,12 +82,62 @@ func Run() {
 	}
 
 	silentMode := *silentFlag || *sFlag
+	access := util.ServerAccess{
+		ListenHost: *listenFlag,
+		Token:      *tokenFlag,
+	}
+	parseOptions := code.ParseOptions{
+		TolerateErrors: *tolerateErrorsFlag,
+		Env:            envFlags,
+	}
+	if *tagsFlag != "" {
+		parseOptions.BuildTags = strings.Split(*tagsFlag, ",")
+	}
+	if *cgoFlag != "" {
+		cgo, err := strconv.ParseBool(*cgoFlag)
+		if err != nil {
+			log.Fatalf("invalid -cgo flag value: %s", *cgoFlag)
+		}
+		parseOptions.CgoEnabled = "0"
+		if cgo {
+			parseOptions.CgoEnabled = "1"
+		}
+	}
+
+	if format := *statsFlag; format != "" {
+		log.SetFlags(0)
+		if err := server.ExportStatistics(format, os.Stdout, flag.Args(), parseOptions, Version); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
 	if gen := *genFlag; gen {
 		var viewDocsCommand = func(docsDir string) string {
 			return os.Args[0] + " -dir=" + docsDir
 		}
-		server.Gen(*genIntentFlag, validateDiir(*dirFlag), *langFlag, flag.Args(), silentMode, Version, printUsage, viewDocsCommand)
+		server.Gen(*genIntentFlag, validateDiir(*dirFlag), *langFlag, flag.Args(), parseOptions, silentMode, Version, printUsage, viewDocsCommand)
+		return
+	}
+
+	cacheMemory, err := util.ParseByteSize(*cacheMemFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cacheOptions := server.CacheOptions{
+		MemoryLimit: cacheMemory,
+		Compressed:  *cacheGzipFlag,
+	}
+
+	if *workspacesFlag != "" {
+		if flag.NArg() > 0 {
+			log.Fatal("no arguments are allowed in the multi-workspace mode")
+		}
+		configs, err := server.LoadWorkspaceConfigs(*workspacesFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.RunWorkspaces(*portFlag, *langFlag, configs, parseOptions, cacheOptions, access, *allowMutationsFlag, silentMode, Version, getRoughBuildTime)
 		return
 	}
 
@@ -104,11 +156,11 @@ If docs serving mode is expected, please run "%s ." instead.
 			*portFlag = "9999" // to be consistent with the one used in the old golf program.
 		}
 
-		util.ServeFiles(validateDiir(*dirFlag), *portFlag, silentMode, Version)
+		util.ServeFiles(validateDiir(*dirFlag), *portFlag, access, silentMode, Version)
 		return
 	}
 
-	server.Run(*portFlag, *langFlag, flag.Args(), silentMode, Version, printUsage, getRoughBuildTime)
+	server.Run(*portFlag, *langFlag, flag.Args(), parseOptions, cacheOptions, access, *allowMutationsFlag, silentMode, Version, printUsage, getRoughBuildTime)
 }
 
 var hFlag = flag.Bool("h", false, "show help")
@@ -116,11 +168,41 @@ var helpFlag = flag.Bool("help", false, "show help")
 var versionFlag = flag.Bool("version", false, "show version info")
 var genFlag = flag.Bool("gen", false, "HTML generation mode")
 var genIntentFlag = flag.String("gen-intent", "docs", "docs | testdata")
+var statsFlag = flag.String("stats", "", "print statistics in the specified format (json | csv) instead of serving docs")
 var langFlag = flag.String("lang", "", "docs generation language tag")
 var dirFlag = flag.String("dir", "", "directory for file serving or HTML generation")
 var portFlag = flag.String("port", "", "preferred server port [1024, 65536]. Default: 56789")
 var sFlag = flag.Bool("s", false, "not open a browser automatically")
 var silentFlag = flag.Bool("silent", false, "not open a browser automatically")
+var tolerateErrorsFlag = flag.Bool("tolerate-errors", false, "continue analyzing when some packages have errors")
+var cacheMemFlag = flag.String("cache-mem", "512MB", "memory budget of cached pages")
+var cacheGzipFlag = flag.Bool("cache-gzip", false, "store cached pages compressed")
+var listenFlag = flag.String("listen", "localhost", "the host or IP to listen on")
+var tokenFlag = flag.String("token", "", "the access token required by every request")
+var allowMutationsFlag = flag.Bool("allow-mutations", false, "enable mutating requests, such as updating Gold")
+var workspacesFlag = flag.String("workspaces", "", "the config file of the multi-workspace mode")
+var tagsFlag = flag.String("tags", "", "comma-separated build tags used in analyzing")
+var cgoFlag = flag.String("cgo", "", "enable cgo in analyzing or not (true | false)")
+var envFlags envFlag
+
+func init() {
+	flag.Var(&envFlags, "env", "an extra KEY=VALUE environment variable used in analyzing (repeatable)")
+}
+
+// envFlag collects the values of the repeatable -env flag.
+type envFlag []string
+
+func (f *envFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *envFlag) Set(kv string) error {
+	if strings.Index(kv, "=") <= 0 {
+		return fmt.Errorf("%q is not in the KEY=VALUE form", kv)
+	}
+	*f = append(*f, kv)
+	return nil
+}
 
 func printVersion(out io.Writer) {
 	fmt.Fprintf(out, "Gold %s\n", Version)
@@ -138,6 +220,12 @@ Options:
 		present, others will be ignored.
 	-gen
 		Static HTML docs generation mode.
+	-stats=json|csv
+		Statistics exporting mode. Analyze
+		the packages, print the statistics
+		and per-package metrics to stdout,
+		then exit, without starting a server.
+		Exit with a non-zero code on failures.
 	-dir=DocsDirectory
 		Specifiy the docs generation or file
 		serving diretory. Current directory
@@ -147,10 +235,55 @@ Options:
 		Service port, default to 56789. If
 		the specified or default port is not
 		availabe, a random port will be used.
+	-listen=Host
+		The host name or IP to listen on,
+		default to localhost. Use 0.0.0.0 to
+		listen on all network interfaces.
+	-token=AccessToken
+		If it is specified, every request must
+		provide it, as the "token" query param
+		(only needed for the first visit), or
+		the password of a basic authentication.
+	-allow-mutations
+		Enable mutating requests, such as the
+		one to update Gold. They are refused
+		by default.
+	-workspaces=ConfigFile
+		Multi-workspace mode. The config file
+		is a JSON array of workspaces, each has
+		a name, a dir and args, such as
+		[{"name":"app","dir":"~/app","args":["./..."]}]
+		Relative dirs are relative to the config
+		file. Each workspace is analyzed on its
+		first visit and served under /w/name/.
 	-s/-silent
 		Don't open a browser automatically
 		or don't show HTML file generation
 		logs in docs generation mode.
+	-tolerate-errors
+		Continue analyzing when some packages
+		have errors. Ill-typed packages are
+		analyzed as far as possible and their
+		errors are listed in their docs pages.
+	-tags=Tag1,Tag2,...
+		The build tags used in analyzing.
+	-cgo=true|false
+		Enable cgo in analyzing or not.
+		Default to the go command default,
+		except cgo is disabled for "std".
+	-env KEY=VALUE
+		An extra environment variable used
+		in analyzing, such as GOOS=windows.
+		The flag can be specified repeatedly.
+	-cache-mem=MemoryBudget
+		The memory budget of cached pages,
+		default to 512MB. Least recently used
+		pages are evicted when it is exceeded.
+		Cache statistics (hits, misses, ...)
+		are available at the /api:cache url.
+	-cache-gzip
+		Store cached pages compressed, so
+		that more pages can be cached.
 
 Examples:
 	%[1]v std
@@ -166,11 +299,19 @@ Examples:
 	%[1]v ./...
 		Show docs of all the packages
 		within the current directory.
+	%[1]v golang.org/x/tools@v0.1.0
+		Show docs of all the packages in the
+		specified module version. The version
+		must be in the local module cache.
 	%[1]v -gen -dir=./generated ./...
 		Generate HTML docs pages into the path
 		specified by the -dir flag for the
 		packages under the current directory
 		and their dependency packages.
+	%[1]v -stats=json ./...
+		Print the statistics of the packages
+		under the current directory and their
+		dependency packages in JSON format.
 	%[1]v -dir=. -s
 		Serving the files in working directory
 		without opening a browser window.
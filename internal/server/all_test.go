@@ -1,13 +1,29 @@
 package server
 
 import (
+	"container/list"
+	"context"
 	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
 	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"go101.org/gold/code"
 	"go101.org/gold/internal/util"
+	"golang.org/x/tools/go/packages"
 )
 
 func init() {
@@ -123,6 +139,554 @@ func TestAssureSubsetStringSlice(t *testing.T) {
 	}
 }
 
+func TestMapRuntimeFunctions(t *testing.T) {
+	type testCase struct {
+		op        string
+		key       types.Type
+		forAssign bool
+		goarch    string
+		expected  string
+	}
+	var testCases = []testCase{
+		{"mapaccess1", types.Typ[types.String], false, "amd64", "mapaccess1_faststr mapaccess1"},
+		{"mapaccess2", types.Typ[types.Int], false, "amd64", "mapaccess2_fast64 mapaccess2"},
+		{"mapaccess2", types.Typ[types.Int], false, "386", "mapaccess2_fast32 mapaccess2"},
+		{"mapdelete", types.Typ[types.Uint32], false, "amd64", "mapdelete_fast32 mapdelete"},
+		{"mapdelete", types.Typ[types.Int64], false, "arm", "mapdelete_fast64 mapdelete"},
+		{"mapdelete", types.Typ[types.Int16], false, "amd64", "mapdelete"},
+		{"mapassign", types.NewPointer(types.Typ[types.Int]), true, "amd64", "mapassign_fast64ptr mapassign"},
+		{"mapassign", types.NewPointer(types.Typ[types.Int]), true, "mips", "mapassign_fast32ptr mapassign"},
+		{"mapassign", types.Typ[types.Uintptr], true, "386", "mapassign_fast32 mapassign"},
+		{"mapaccess1", types.NewPointer(types.Typ[types.Int]), false, "amd64", "mapaccess1_fast64 mapaccess1"},
+		{"mapassign", types.Typ[types.Float64], true, "amd64", "mapassign"},
+	}
+	for _, tc := range testCases {
+		funcs := strings.Join(mapRuntimeFunctions(tc.op, tc.key, tc.forAssign, types.SizesFor("gc", tc.goarch)), " ")
+		if funcs != tc.expected {
+			t.Errorf("map runtime functions not match (%s, %s, %s): %s vs. %s", tc.op, tc.key, tc.goarch, funcs, tc.expected)
+		}
+	}
+}
+
+func TestConvTFunctions(t *testing.T) {
+	type testCase struct {
+		from     types.Type
+		toEmpty  bool
+		expected string
+	}
+	var byteArray3 = types.NewArray(types.Typ[types.Byte], 3)
+	var testCases = []testCase{
+		{types.Typ[types.Int], true, "convT64 convTnoptr convT2Enoptr"},
+		{types.Typ[types.Uint], false, "convT64 convTnoptr convT2Inoptr"},
+		{types.Typ[types.Uintptr], true, "convT64 convTnoptr convT2Enoptr"},
+		{types.Typ[types.Float64], true, "convT64 convTnoptr convT2Enoptr"},
+		{types.Typ[types.Rune], true, "convT32 convTnoptr convT2Enoptr"},
+		{types.Typ[types.Int16], true, "convT16 convTnoptr convT2Enoptr"},
+		{types.Typ[types.String], true, "convTstring convT convT2E"},
+		{types.NewSlice(types.Typ[types.Int]), true, "convTslice convT convT2E"},
+		{types.NewStruct([]*types.Var{types.NewField(0, nil, "s", types.Typ[types.String], false)}, nil), true, "convTstring convT convT2E"},
+		{byteArray3, true, "convTnoptr convT2Enoptr"},
+		{types.Typ[types.Complex128], false, "convTnoptr convT2Inoptr"},
+		{types.Typ[types.Bool], true, ""},
+		{types.Typ[types.Uint8], true, ""},
+		{types.NewStruct(nil, nil), true, ""},
+		{types.NewPointer(types.Typ[types.Int]), true, ""},
+		{types.NewArray(types.NewPointer(types.Typ[types.Int]), 1), true, ""},
+	}
+	sizes := types.SizesFor("gc", "amd64")
+	for _, tc := range testCases {
+		funcs := strings.Join(convTFunctions(tc.from, tc.toEmpty, sizes), " ")
+		if funcs != tc.expected {
+			t.Errorf("convT functions not match (%s): %s vs. %s", tc.from, funcs, tc.expected)
+		}
+	}
+}
+
+// newTestAstVisitor parses and type checks a single-file package,
+// and returns a visitor for the file without building any pages.
+func newTestAstVisitor(t *testing.T, src string) (*astVisitor, *ast.File) {
+	t.Helper()
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &types.Info{
+		Types: make(map[ast.Expr]types.TypeAndValue),
+		Defs:  make(map[*ast.Ident]types.Object),
+		Uses:  make(map[*ast.Ident]types.Object),
+	}
+	if _, err := (&types.Config{}).Check("p", fset, []*ast.File{file}, info); err != nil {
+		t.Fatal(err)
+	}
+	return &astVisitor{
+		pkg:             &code.Package{PPkg: &packages.Package{}},
+		fset:            fset,
+		file:            fset.File(file.Pos()),
+		info:            info,
+		sizes:           types.SizesFor("gc", "amd64"),
+		content:         []byte(src),
+		specialAstNodes: list.New(),
+	}, file
+}
+
+func TestBuiltinCallFuncs(t *testing.T) {
+	const src = `package p
+
+func f(m map[string]int, s []int, ps []*int) {
+	clear(m)
+	clear(s)
+	clear(ps)
+	delete(m, "Go")
+}
+`
+	v, file := newTestAstVisitor(t, src)
+	var calls []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			v.handleCallExpr(call)
+			ident := call.Fun.(*ast.Ident)
+			calls = append(calls, ident.Name+":"+strings.Join(v.builtinCallFuncs[ident], " "))
+		}
+		return true
+	})
+	expected := []string{"clear:mapclear", "clear:memclrNoHeapPointers", "clear:memclrHasPointers", "delete:mapdelete_faststr mapdelete"}
+	if strings.Join(calls, ", ") != strings.Join(expected, ", ") {
+		t.Errorf("built-in call functions not match:\n%v\nvs.\n%v", calls, expected)
+	}
+}
+
+func TestImplicitInterfaceConversions(t *testing.T) {
+	const src = `package p
+
+type S struct{ a, b int64 }
+
+func f(x any, ys ...any) {}
+
+func g(n int, s string) (any, error) {
+	var x any = n
+	x = s
+	f(S{}, 1)
+	f(nil, n)
+	f(x, 1, 2)
+	_ = func() any { return 1 }
+	return []int{}, nil
+}
+`
+	v, file := newTestAstVisitor(t, src)
+	fset, info := v.fset, v.info
+
+	var returns []string
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			v.recordReturnResults(n.Body, info.Defs[n.Name].Type().(*types.Signature))
+		case *ast.FuncLit:
+			v.recordReturnResults(n.Body, info.TypeOf(n).(*types.Signature))
+		case *ast.CallExpr:
+			v.handleCallExpr(n)
+		case *ast.AssignStmt:
+			v.handleAssignStmt(n)
+		case *ast.ValueSpec:
+			v.handleValueSpec(n)
+		case *ast.ReturnStmt:
+			returns = append(returns, fmt.Sprintf("%d:%s", fset.Position(n.Return).Line, strings.Join(v.returnConversionFuncs(n), " ")))
+		}
+		return true
+	})
+
+	var ops []string
+	for e := v.specialAstNodes.Front(); e != nil; e = e.Next() {
+		op := e.Value.(*RuntimeOperation)
+		ops = append(ops, fmt.Sprintf("%d:%s:%s", fset.Position(op.pos).Line, op.token, op.funcs[0]))
+	}
+	expected := []string{"8:=:convT64", "9:=:convTstring", "10:(:convTnoptr", "11:(:convT64"}
+	if strings.Join(ops, " ") != strings.Join(expected, " ") {
+		t.Errorf("runtime operations not match:\n%v\nvs.\n%v", ops, expected)
+	}
+	expected = []string{"13:", "14:convTslice convT convT2E"}
+	if strings.Join(returns, " ") != strings.Join(expected, " ") {
+		t.Errorf("return conversions not match:\n%v\nvs.\n%v", returns, expected)
+	}
+}
+
+func TestWriteStatisticsCSV(t *testing.T) {
+	var report StatisticsReport
+	report.Stats.Packages = 2
+	report.Stats.PackagesByDeps[1] = 2
+	report.Packages = []code.PackageMetrics{{Path: "x.y/z", Main: true, Deps: 1}}
+
+	var buf strings.Builder
+	if err := writeStatisticsCSV(&buf, &report); err != nil {
+		t.Fatal(err)
+	}
+	tables := strings.Split(buf.String(), "\n\n")
+	if len(tables) != 2 {
+		t.Fatalf("%d tables are written, expected 2", len(tables))
+	}
+	for _, line := range []string{"Statistic,Value\n", "\nPackages,2\n", "\nPackagesByDeps[1],2\n"} {
+		if !strings.Contains(tables[0], line) {
+			t.Errorf("line %q is not found in the statistics table", line)
+		}
+	}
+	if !strings.HasPrefix(tables[1], "Path,Standard,Main,") || !strings.Contains(tables[1], "\nx.y/z,false,true,") {
+		t.Errorf("unexpected package metrics table:\n%s", tables[1])
+	}
+}
+
+func TestGroupPackagesByModules(t *testing.T) {
+	modA := &code.Module{Root: "example.com/a"}
+	modB := &code.Module{Root: "example.com/b", Version: "v1.2.3"}
+	newPackage := func(path string, mod *code.Module) *code.Package {
+		return &code.Package{PPkg: &packages.Package{PkgPath: path}, Mod: mod}
+	}
+	pkgs := []*code.Package{
+		newPackage("example.com/b", modB),
+		newPackage("example.com/a", modA),
+		newPackage("example.com/b/y", modB),
+		newPackage("example.com/a/x", modA),
+	}
+
+	modules, modulePackages := groupPackagesByModules(pkgs)
+	if len(modules) != 2 || modules[0] != modB || modules[1] != modA {
+		t.Fatalf("unexpected modules: %v", modules)
+	}
+	for mod, expected := range map[*code.Module][]*code.Package{
+		modA: {pkgs[1], pkgs[3]},
+		modB: {pkgs[0], pkgs[2]},
+	} {
+		if got := modulePackages[mod]; len(got) != len(expected) || got[0] != expected[0] || got[1] != expected[1] {
+			t.Errorf("packages of module %s: %v, expected: %v", mod.Root, got, expected)
+		}
+	}
+
+	ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+	for _, c := range []struct {
+		mod   *code.Module
+		title string
+	}{
+		{nil, ds.currentTranslation.Text_MainModule()},
+		{&code.Module{}, "std"},
+		{modA, "example.com/a"},
+		{modB, `example.com/b <span class="module-version">@v1.2.3</span>`},
+	} {
+		if title := ds.moduleTitle(c.mod); title != c.title {
+			t.Errorf("module title: %q, expected: %q", title, c.title)
+		}
+	}
+}
+
+func TestStatisticsOfScope(t *testing.T) {
+	std := &code.Module{}
+	modA := &code.Module{Root: "example.com/a"}
+	modB := &code.Module{Root: "example.com/b", Version: "v1.2.3"}
+	newPackage := func(path string, mod *code.Module, requested bool) *code.Package {
+		return &code.Package{PPkg: &packages.Package{PkgPath: path}, Mod: mod, Requested: requested}
+	}
+	ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+	ds.analyzer = code.NewCodeAnalyzerOf(
+		newPackage("fmt", std, false),
+		newPackage("example.com/a", modA, true),
+		newPackage("example.com/a/x", modA, true),
+		newPackage("example.com/b", modB, false),
+	)
+
+	var testCases = []struct {
+		scope string
+		pkgs  string
+		ok    bool
+	}{
+		{"", "fmt example.com/a example.com/a/x example.com/b", true},
+		{"main", "example.com/a example.com/a/x", true},
+		{"pkg/fmt", "fmt", true},
+		{"pkg/example.com/a/x", "example.com/a/x", true},
+		{"pkg/example.com/c", "", false},
+		{"pkg/", "", false},
+		{"mod/std", "fmt", true},
+		{"mod/example.com/a", "example.com/a example.com/a/x", true},
+		{"mod/example.com/b", "example.com/b", true},
+		{"mod/example.com/a/x", "", false},
+		{"mod/", "", false},
+		{"std", "", false},
+		{"foo/bar", "", false},
+	}
+	for _, tc := range testCases {
+		_, pkgs, ok := ds.statisticsOfScope(tc.scope)
+		var paths []string
+		for _, pkg := range pkgs {
+			paths = append(paths, pkg.Path())
+		}
+		if ok != tc.ok || strings.Join(paths, " ") != tc.pkgs {
+			t.Errorf("statistics of scope %q: %v, %v, expected: %q, %v", tc.scope, paths, ok, tc.pkgs, tc.ok)
+		}
+	}
+}
+
+func TestLoadWorkspaceConfigs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		config string
+		dirs   []string // nil means an error is expected
+	}{
+		{`[{"name": "app", "dir": "app"}, {"name": "std"}]`, []string{filepath.Join(dir, "app"), dir}},
+		{`[{"name": "abs", "dir": "/x/y"}, {"name": "home", "dir": "~/app"}]`, []string{filepath.FromSlash("/x/y"), filepath.Join(home, "app")}},
+		{`[{"name": "app"}, {"name": "app"}]`, nil},
+		{`[{"name": ""}]`, nil},
+		{`[{"name": "a/b"}]`, nil},
+		{`[{"name": "a?b"}]`, nil},
+		{`[]`, nil},
+		{`{`, nil},
+	}
+	configFile := filepath.Join(dir, "workspaces.json")
+	for _, c := range cases {
+		if err := ioutil.WriteFile(configFile, []byte(c.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+		configs, err := LoadWorkspaceConfigs(configFile)
+		if c.dirs == nil {
+			if err == nil {
+				t.Errorf("%s: no errors", c.config)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %s", c.config, err)
+			continue
+		}
+		var dirs []string
+		for _, config := range configs {
+			dirs = append(dirs, config.Dir)
+		}
+		if strings.Join(dirs, "\n") != strings.Join(c.dirs, "\n") {
+			t.Errorf("%s: dirs are %v, expected: %v", c.config, dirs, c.dirs)
+		}
+	}
+}
+
+func TestWorkspacesRouting(t *testing.T) {
+	wss := &workspacesServer{
+		root:   newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil),
+		byName: make(map[string]*workspace),
+	}
+	ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+	ds.workspaceName = "app"
+	ds.urlPrefix = "/w/app"
+	ws := &workspace{WorkspaceConfig: WorkspaceConfig{Name: "app"}, ds: ds}
+	ws.analyze.Do(func() {}) // not to analyze in the test
+	wss.workspaces = append(wss.workspaces, ws)
+	wss.byName["app"] = ws
+
+	cases := []struct {
+		path     string
+		code     int
+		location string
+	}{
+		{"/w/app", http.StatusMovedPermanently, "/w/app/"},
+		{"/w/none/", http.StatusNotFound, ""},
+		{"/w/app/", http.StatusTooEarly, ""}, // the loading page
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		wss.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+		if w.Code != c.code {
+			t.Errorf("%s: status code %d, expected: %d", c.path, w.Code, c.code)
+		}
+		if location := w.Header().Get("Location"); location != c.location {
+			t.Errorf("%s: redirected to %q, expected: %q", c.path, location, c.location)
+		}
+	}
+}
+
+func TestRegisterAnalyzingLogMessage(t *testing.T) {
+	ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+	ds.analyzingLogger = nil
+
+	// Analyzing log messages can be registered and read
+	// when pages are being built with the read lock held.
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	done := make(chan struct{})
+	go func() {
+		ds.registerAnalyzingLogMessage(func() string { return "foo" })
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("registering an analyzing log message is blocked")
+	}
+
+	w := httptest.NewRecorder()
+	ds.loadAPI(w, httptest.NewRequest("GET", "/__load?from=0", nil))
+	var logs []LoadingLogMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &logs); err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || logs[0].Message != "foo" {
+		t.Errorf("unexpected analyzing logs: %v", logs)
+	}
+}
+
+func TestStreamLoadAPI(t *testing.T) {
+	// parseEvents returns the ids of the events in a stream,
+	// the analyzed event is suffixed with "!".
+	var parseEvents = func(stream string) string {
+		var ids []string
+		for _, event := range strings.Split(stream, "\n\n") {
+			var id string
+			for _, line := range strings.Split(event, "\n") {
+				switch {
+				case strings.HasPrefix(line, "id: "):
+					id = line[len("id: "):]
+				case line == "event: analyzed":
+					id += "!"
+				}
+			}
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return strings.Join(ids, " ")
+	}
+
+	var testCases = []struct {
+		messages    []string
+		from        string
+		lastEventID string
+		expected    string
+	}{
+		{[]string{"a", "b", "c"}, "0", "", "0 1 2"},
+		{[]string{"a", "b", "c"}, "1", "", "1 2"},
+		{[]string{"a", "b", "c"}, "9", "", ""},
+		{[]string{"a", "b", "c"}, "0", "0", "1 2"},
+		{[]string{"a", "b", "c"}, "0", "2", ""},
+		{[]string{"a", "b", "c"}, "0", "bad", "0 1 2"},
+		{[]string{"a", "b", ""}, "0", "", "0 1 2!"},
+		{[]string{"a", "b", ""}, "0", "1", "2!"},
+		{[]string{"a", "", "c"}, "0", "", "0 1!"},
+	}
+	for i, tc := range testCases {
+		ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+		ds.analyzingLogger = nil
+		for _, msg := range tc.messages {
+			msg := msg
+			ds.registerAnalyzingLogMessage(func() string { return msg })
+		}
+
+		// Streams without the analyzed event are not finished
+		// until the client has gone.
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+		r := httptest.NewRequest("GET", "/api:load?stream=on&from="+tc.from, nil).WithContext(ctx)
+		if tc.lastEventID != "" {
+			r.Header.Set("Last-Event-ID", tc.lastEventID)
+		}
+		w := httptest.NewRecorder()
+		ds.loadAPI(w, r)
+		finishedByItself := ctx.Err() == nil
+		cancel()
+
+		if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+			t.Errorf("case %d: content type: %s", i, ct)
+		}
+		if events := parseEvents(w.Body.String()); events != tc.expected {
+			t.Errorf("case %d: stream events: %q, expected: %q", i, events, tc.expected)
+		}
+		if analyzed := strings.HasSuffix(tc.expected, "!"); finishedByItself != analyzed {
+			t.Errorf("case %d: stream is finished by itself: %v, expected: %v", i, finishedByItself, analyzed)
+		}
+	}
+
+	// Messages registered later are streamed, until analyzing is done.
+	ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+	ds.analyzingLogger = nil
+	ds.registerAnalyzingLogMessage(func() string { return "a" })
+	w := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		ds.loadAPI(w, httptest.NewRequest("GET", "/api:load?stream=on&from=0", nil))
+		close(done)
+	}()
+	ds.registerAnalyzingLogMessage(func() string { return "b" })
+	ds.registerAnalyzingLogMessage(func() string { return "" })
+	select {
+	case <-done:
+	case <-time.After(loadStreamDuration / 2):
+		t.Fatal("the stream is not finished after analyzing is done")
+	}
+	if events := parseEvents(w.Body.String()); events != "0 1 2!" {
+		t.Errorf("stream events: %q, expected: %q", events, "0 1 2!")
+	}
+}
+
+// panicOnceWriter panics at the first write.
+type panicOnceWriter struct {
+	panicked bool
+}
+
+func (w *panicOnceWriter) Write(p []byte) (int, error) {
+	if !w.panicked {
+		w.panicked = true
+		panic("panicOnceWriter")
+	}
+	return len(p), nil
+}
+
+func TestFailedReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gold-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, panicking := range []bool{false, true} {
+		ds := newDocServer("", code.ParseOptions{}, CacheOptions{}, false, "0.0.0", nil)
+		ds.analyzingLogger = nil
+		if panicking {
+			ds.analyzingLogger = log.New(&panicOnceWriter{}, "", 0)
+		}
+		analyzer := &code.CodeAnalyzer{}
+		ds.analyzer = analyzer
+		ds.phase = Phase_Analyzed
+
+		// Loading fails in a nonexistent directory.
+		if !ds.reload([]string{"."}, code.ParseOptions{Dir: filepath.Join(dir, "nonexistent")}) {
+			t.Fatal("reloading is not started")
+		}
+
+		for start := time.Now(); ; time.Sleep(time.Millisecond * 10) {
+			ds.mutex.RLock()
+			reloading, status := ds.reloading, ds.reloadStatus()
+			ds.mutex.RUnlock()
+			if !reloading {
+				if status != "failed" {
+					t.Errorf("panicking=%v: reload status is %q, expected \"failed\"", panicking, status)
+				}
+				break
+			}
+			if time.Since(start) > time.Minute {
+				t.Fatalf("panicking=%v: reloading is not finished in time", panicking)
+			}
+		}
+		if ds.analyzer != analyzer {
+			t.Errorf("panicking=%v: the analyzer is replaced by a failed reload", panicking)
+		}
+	}
+}
+
 func TestPreviousVersion(t *testing.T) {
 	type testCase struct {
 		version, previous string
@@ -147,6 +711,251 @@ func TestPreviousVersion(t *testing.T) {
 	}
 }
 
+func TestPageCache(t *testing.T) {
+	type step struct {
+		key   string
+		size  int
+		built bool // whether the page is built in the step
+	}
+	type testCase struct {
+		limit      int64
+		compressed bool
+		steps      []step
+		kept       string // the kept keys, most recently used first
+		evictions  int64
+	}
+	var testCases = []testCase{
+		{10, false, []step{{"a", 4, true}, {"b", 4, true}, {"a", 4, false}, {"c", 4, true}, {"b", 4, true}}, "b c", 2},
+		// The sizes of compressed pages are counted.
+		{1000, true, []step{{"a", 1000, true}, {"b", 1000, true}, {"a", 1000, false}, {"c", 1000, true}, {"a", 1000, false}}, "a c b", 0},
+		{10, false, []step{{"a", 11, true}, {"a", 11, true}, {"b", 10, true}, {"b", 10, false}}, "b", 2},
+		{0, false, []step{{"a", 1, true}, {"a", 1, true}}, "", 2},
+	}
+	for i, tc := range testCases {
+		c := newPageCache(0, CacheOptions{MemoryLimit: tc.limit, Compressed: tc.compressed})
+		for j, s := range tc.steps {
+			content := []byte(strings.Repeat(s.key, s.size))
+			built := false
+			page, err := c.getPage(s.key, func() ([]byte, error) {
+				built = true
+				return content, nil
+			})
+			if err != nil {
+				t.Fatalf("case %d, step %d: getPage error: %s", i, j, err)
+			}
+			if string(page) != string(content) {
+				t.Errorf("case %d, step %d: page content: %q, expected: %q", i, j, page, content)
+			}
+			if built != s.built {
+				t.Errorf("case %d, step %d: page built: %v, expected: %v", i, j, built, s.built)
+			}
+		}
+		var kept []string
+		for e := c.lru.Front(); e != nil; e = e.Next() {
+			kept = append(kept, e.Value.(*cachedPage).key.(string))
+		}
+		if r := strings.Join(kept, " "); r != tc.kept {
+			t.Errorf("case %d: kept pages: %q, expected: %q", i, r, tc.kept)
+		}
+		if stats := c.statistics(); stats.Evictions != tc.evictions || stats.Pages != len(kept) || stats.Size > tc.limit && len(kept) > 0 {
+			t.Errorf("case %d: unexpected statistics: %+v", i, stats)
+		}
+	}
+
+	// Failed buildings are not cached.
+	c := newPageCache(0, CacheOptions{MemoryLimit: 100})
+	buildErr := fmt.Errorf("build error")
+	if _, err := c.getPage("x", func() ([]byte, error) { return nil, buildErr }); err != buildErr {
+		t.Errorf("getPage error: %v, expected: %v", err, buildErr)
+	}
+	func() {
+		defer func() { recover() }()
+		c.getPage("x", func() ([]byte, error) { panic("build panic") })
+	}()
+	if page, err := c.getPage("x", func() ([]byte, error) { return []byte("x"), nil }); err != nil || string(page) != "x" {
+		t.Errorf("getPage after failed buildings: %q, %v", page, err)
+	}
+
+	// Concurrent requests for a page being built share the building.
+	c = newPageCache(0, CacheOptions{MemoryLimit: 100})
+	var release = make(chan struct{})
+	var builds int32
+	var build = func() ([]byte, error) {
+		atomic.AddInt32(&builds, 1)
+		<-release
+		return []byte("page"), nil
+	}
+	const n = 8
+	var wg sync.WaitGroup
+	var results = make([]string, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			page, _ := c.getPage("p", build)
+			results[i] = string(page)
+		}(i)
+	}
+	for {
+		c.mutex.Lock()
+		stats := c.stats
+		c.mutex.Unlock()
+		if stats.Hits+stats.Misses == n {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if builds != 1 {
+		t.Errorf("page is built %d times, expected once", builds)
+	}
+	for i, r := range results {
+		if r != "page" {
+			t.Errorf("result %d: %q, expected: %q", i, r, "page")
+		}
+	}
+}
+
+func TestNegotiateContentEncoding(t *testing.T) {
+	var testCases = [][2]string{
+		{"", ""},
+		{"identity", ""},
+		{"gzip", "gzip"},
+		{"x-gzip", "gzip"},
+		{"deflate", "deflate"},
+		{"gzip, deflate, br", "gzip"},
+		{"deflate, gzip", "gzip"},
+		{"gzip;q=0.5, deflate", "deflate"},
+		{"gzip; q=0.8, deflate;q=0.8", "gzip"},
+		{"gzip;q=0, deflate;q=0.1", "deflate"},
+		{"gzip;q=0, deflate;q=0", ""},
+		{"gzip;q=bad", ""},
+		{"*", "gzip"},
+		{"*;q=0", ""},
+		{"gzip;q=0, *", "deflate"},
+		{"*;q=0.5, deflate", "deflate"},
+		{"deflate;q=0.2, *;q=0.5", "gzip"},
+		{"GZIP;Q=1", "gzip"},
+	}
+	for _, tc := range testCases {
+		if encoding := negotiateContentEncoding(tc[0]); encoding != tc[1] {
+			t.Errorf("negotiateContentEncoding(%q): %q, expected: %q", tc[0], encoding, tc[1])
+		}
+	}
+}
+
+func TestCheckNotModified(t *testing.T) {
+	defer func(old bool) { genDocsMode = old }(genDocsMode)
+	genDocsMode = false
+
+	const etag = `"123-abc"`
+	var testCases = []struct {
+		ifNoneMatch string
+		notModified bool
+	}{
+		{"", false},
+		{`"123-abc"`, true},
+		{`"123-abd"`, false},
+		{`W/"123-abc"`, true},
+		{`"000-abc", "123-abc"`, true},
+		{`"000-abc",W/"123-abc"`, true},
+		{`"000-abc", "111-abc"`, false},
+		{`*`, true},
+		{`123-abc`, false},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tc.ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", tc.ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		if notModified := checkNotModified(w, r, etag); notModified != tc.notModified {
+			t.Errorf("checkNotModified(%q): %v, expected: %v", tc.ifNoneMatch, notModified, tc.notModified)
+		} else if notModified {
+			if w.Code != http.StatusNotModified || w.Header().Get("ETag") != etag {
+				t.Errorf("checkNotModified(%q): response %d with ETag %s", tc.ifNoneMatch, w.Code, w.Header().Get("ETag"))
+			}
+		} else if w.Code != http.StatusOK || w.Body.Len() > 0 {
+			t.Errorf("checkNotModified(%q): response is written", tc.ifNoneMatch)
+		}
+	}
+}
+
+func TestServerAccessHandler(t *testing.T) {
+	var handler = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("wrapped"))
+	}
+	var testCases = []struct {
+		token    string
+		target   string
+		setup    func(r *http.Request)
+		code     int
+		location string // for redirects
+	}{
+		{"", "/pkg:fmt", nil, http.StatusOK, ""},
+		{"secret", "/pkg:fmt", nil, http.StatusUnauthorized, ""},
+		{"secret", "/pkg:fmt?token=secret", nil, http.StatusSeeOther, "/pkg:fmt"},
+		{"secret", "/pkg:fmt?x=1&token=secret", nil, http.StatusSeeOther, "/pkg:fmt?x=1"},
+		{"secret", "/pkg:fmt?token=wrong", nil, http.StatusUnauthorized, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "gold-token", Value: "secret"})
+		}, http.StatusOK, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "gold-token", Value: "wrong"})
+		}, http.StatusUnauthorized, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.SetBasicAuth("anyone", "secret")
+		}, http.StatusOK, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.SetBasicAuth("secret", "wrong")
+		}, http.StatusUnauthorized, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer secret")
+		}, http.StatusOK, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.Header.Set("Authorization", "bearer  secret ")
+		}, http.StatusOK, ""},
+		{"secret", "/pkg:fmt", func(r *http.Request) {
+			r.Header.Set("Authorization", "Bearer secre")
+		}, http.StatusUnauthorized, ""},
+	}
+	for i, tc := range testCases {
+		h := util.ServerAccess{Token: tc.token}.Handler(http.HandlerFunc(handler))
+		r := httptest.NewRequest("GET", tc.target, nil)
+		if tc.setup != nil {
+			tc.setup(r)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tc.code {
+			t.Errorf("case %d (%s): response code %d, expected: %d", i, tc.target, w.Code, tc.code)
+			continue
+		}
+		switch w.Code {
+		case http.StatusSeeOther:
+			if location := w.Header().Get("Location"); location != tc.location {
+				t.Errorf("case %d (%s): redirected to %q, expected: %q", i, tc.target, location, tc.location)
+			}
+			cookies := w.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != "gold-token" || cookies[0].Value != tc.token || !cookies[0].HttpOnly {
+				t.Errorf("case %d (%s): unexpected cookies: %v", i, tc.target, cookies)
+			}
+		case http.StatusUnauthorized:
+			if w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("case %d (%s): no WWW-Authenticate header", i, tc.target)
+			}
+			if strings.Contains(w.Body.String(), "wrapped") {
+				t.Errorf("case %d (%s): the wrapped handler is called", i, tc.target)
+			}
+		case http.StatusOK:
+			if w.Body.String() != "wrapped" {
+				t.Errorf("case %d (%s): the wrapped handler is not called", i, tc.target)
+			}
+		}
+	}
+}
+
 func TestDocsForStandardPackages(t *testing.T) {
 	// ...
 	data, err := ioutil.ReadFile(filepath.Join("..", "testing", "data", "testdata.json.tar.gz"))
@@ -221,5 +1030,5 @@ func TestDocsForStandardPackages(t *testing.T) {
 }
 
 func TestGenerateDocsOfStandardPackages(t *testing.T) {
-	GenDocs("", []string{"std"}, "en-US", true, "v0.0.0", nil, nil)
+	GenDocs("", []string{"std"}, code.ParseOptions{}, "en-US", true, "v0.0.0", nil, nil)
 }
@@ -0,0 +1,31 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// api:cache
+// - GET: get the page cache statistics. They tell whether
+// or not the cache memory budget is appropriate.
+func (ds *docServer) cacheAPI(w http.ResponseWriter, r *http.Request) {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if ds.pages == nil {
+		w.WriteHeader(http.StatusTooEarly)
+		fmt.Fprint(w, `{"error": "not analyzed yet"}`)
+		return
+	}
+
+	data, err := json.Marshal(ds.pages.statistics())
+	if err != nil {
+		fmt.Fprintf(w, `{"error": "%s"}`, err.Error())
+		return
+	}
+
+	w.Write(data)
+}
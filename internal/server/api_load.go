@@ -13,7 +13,8 @@ import (
 
 // loading page
 func (ds *docServer) loadingPage(w http.ResponseWriter, r *http.Request) {
-	var pageUrl = r.URL.String()
+	// r.URL has the workspace url prefix stripped.
+	var pageUrl = ds.urlPrefix + r.URL.String()
 
 	fmt.Fprintf(w, `<!DOCTYPE html>
 <html>
@@ -66,7 +67,7 @@ func (ds *docServer) loadingPage(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 
-		var url = window.location.protocol+'//'+window.location.host+'/api:load';
+		var url = window.location.protocol+'//'+window.location.host+'%[1]s/api:load';
 		var page = 'from=';
 		var from = 0;
 		var code = '';
@@ -84,11 +85,31 @@ func (ds *docServer) loadingPage(w http.ResponseWriter, r *http.Request) {
 		}
 
 		fromi()
+
+		if (window.EventSource) {
+			var source = new EventSource(url + '?stream=on&' + page + from);
+			source.onmessage = function (e) {
+				var msg = JSON.parse(e.data);
+				if (document.getElementById('loading-message-' + msg.ID)) {
+					return
+				}
+				code=document.createElement('code')
+				code.setAttribute('id','loading-message-'+msg.ID)
+				code.innerHTML=msg.Message+ "<br/>"
+				pre.appendChild(code)
+			};
+			source.addEventListener('analyzed', function () {
+				source.close()
+				window.location.href = window.location.pathname
+			});
+			return
+		}
+
 		timer = setInterval(function () {
 			if (needjump) {
 				console.log("jump: ")
 				clearInterval(timer)
-				window.location.href = window.location.protocol+'//'+window.location.host
+				window.location.href = window.location.protocol+'//'+window.location.host+'%[1]s/'
 				return
 			}
 			createXMLHttpRequest(url, 'get', page+from, function (data) {
@@ -112,6 +133,7 @@ func (ds *docServer) loadingPage(w http.ResponseWriter, r *http.Request) {
 	}
 </script>
 `,
+			ds.urlPrefix,
 		)
 	}
 
@@ -124,7 +146,11 @@ func (ds *docServer) loadingPage(w http.ResponseWriter, r *http.Request) {
 `, ds.currentTranslation.Text_Analyzing(), ds.currentTranslation.Text_AnalyzingRefresh(pageUrl),
 	)
 
-	for _, lm := range ds.analyzingLogs {
+	ds.logsMutex.Lock()
+	logs := ds.analyzingLogs
+	ds.logsMutex.Unlock()
+
+	for _, lm := range logs {
 		fmt.Fprintf(w, `
 <code id="loading-message-%d">%s</code>`,
 			lm.ID, lm.Message,
@@ -142,14 +168,23 @@ func (ds *docServer) loadingPage(w http.ResponseWriter, r *http.Request) {
 func (ds *docServer) loadAPI(w http.ResponseWriter, r *http.Request) {
 	fromIndex, _ := strconv.Atoi(r.FormValue("from"))
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	if r.FormValue("stream") == "on" {
+		// EventSource sends the Last-Event-ID header when reconnecting.
+		if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+			fromIndex = id + 1
+		}
+		ds.streamLoadAPI(w, r, fromIndex)
+		return
+	}
 
+	ds.logsMutex.Lock()
 	if fromIndex > len(ds.analyzingLogs) {
 		fromIndex = len(ds.analyzingLogs)
 	}
+	logs := ds.analyzingLogs[fromIndex:]
+	ds.logsMutex.Unlock()
 
-	data, err := json.Marshal(ds.analyzingLogs[fromIndex:])
+	data, err := json.Marshal(logs)
 	if err != nil {
 		fmt.Fprintf(w, `{"error": "%s"}`, err.Error())
 		return
@@ -158,15 +193,76 @@ func (ds *docServer) loadAPI(w http.ResponseWriter, r *http.Request) {
 	w.Write(data)
 }
 
+// Server-sent event streams are finished periodically, so that the
+// handlers for gone clients will not be left waiting for long.
+// EventSource will reconnect automatically to continue the stream.
+const loadStreamDuration = time.Second * 4
+
+// api:load?stream=on
+func (ds *docServer) streamLoadAPI(w http.ResponseWriter, r *http.Request, fromIndex int) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusNotImplemented)
+		fmt.Fprint(w, "Streaming is not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	fmt.Fprint(w, "retry: 100\n\n")
+
+	timeout := time.After(loadStreamDuration)
+	for {
+		ds.logsMutex.Lock()
+		if fromIndex > len(ds.analyzingLogs) {
+			fromIndex = len(ds.analyzingLogs)
+		}
+		logs := ds.analyzingLogs[fromIndex:]
+		logsChanged := ds.analyzingLogsChanged
+		ds.logsMutex.Unlock()
+
+		for _, lm := range logs {
+			// A blank message means analyzing is done.
+			if lm.Message == "" {
+				fmt.Fprintf(w, "id: %d\nevent: analyzed\ndata: done\n\n", lm.ID)
+				flusher.Flush()
+				return
+			}
+
+			data, err := json.Marshal(lm)
+			if err != nil {
+				log.Println("marshal loading log message:", err)
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", lm.ID, data)
+		}
+		fromIndex += len(logs)
+		flusher.Flush()
+
+		select {
+		case <-logsChanged:
+		case <-timeout:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
 type LoadingLogMessage struct {
 	ID      int
 	Message string
 }
 
-func (ds *docServer) onAnalyzingSubTaskDone(task int, d time.Duration, args ...int32) {
+func (ds *docServer) onAnalyzingSubTaskDone(analyzer *code.CodeAnalyzer, task int, d time.Duration, args ...int32) {
 	getMsg := func() string {
 		var msg string
 		switch task {
+		case code.SubTask_ListModules:
+			msg = ds.currentTranslation.Text_Analyzing_ListModules(int(args[0]), int(args[1]), d)
+		case code.SubTask_DownloadModule:
+			mod := analyzer.ModuleAt(int(args[0]))
+			msg = ds.currentTranslation.Text_Analyzing_DownloadModule(mod.Root+"@"+mod.Version, int(args[1]), int(args[2]), args[3] != 0, d)
 		case code.SubTask_PreparationDone:
 			msg = ds.currentTranslation.Text_Analyzing_PreparationDone(d)
 		case code.SubTask_NFilesParsed:
@@ -193,6 +289,8 @@ func (ds *docServer) onAnalyzingSubTaskDone(task int, d time.Duration, args ...i
 			msg = ds.currentTranslation.Text_Analyzing_MakeStatistics(d)
 		case code.SubTask_CollectSourceFiles:
 			msg = ds.currentTranslation.Text_Analyzing_CollectSourceFiles(d)
+		case code.SubTask_FindUnreachableFunctions:
+			msg = ds.currentTranslation.Text_Analyzing_FindUnreachableFunctions(d)
 		}
 		return msg
 	}
@@ -209,12 +307,22 @@ func (ds *docServer) registerAnalyzingLogMessage(getMsg func() string) {
 		}
 	}()
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
-
+	// The current translation is protected by ds.mutex.
+	ds.mutex.RLock()
 	msg = getMsg()
-	ds.analyzingLogs = append(ds.analyzingLogs, LoadingLogMessage{len(ds.analyzingLogs), msg})
 	l = ds.analyzingLogger
+	ds.mutex.RUnlock()
+
+	ds.logsMutex.Lock()
+	defer ds.logsMutex.Unlock()
+
+	ds.analyzingLogs = append(ds.analyzingLogs, LoadingLogMessage{len(ds.analyzingLogs), msg})
+
+	// Notify the streaming api:load handlers.
+	if ds.analyzingLogsChanged != nil {
+		close(ds.analyzingLogsChanged)
+	}
+	ds.analyzingLogsChanged = make(chan struct{})
 	return
 
 }
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// reload page
+func (ds *docServer) reloadPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	if ds.phase < Phase_Analyzed || ds.reloading {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	} else if r.FormValue("js") != "" {
+		http.Redirect(w, r, ds.urlPrefix+"/reload", http.StatusTemporaryRedirect)
+		return
+	}
+
+	// The page is small and seldom visited, so it is not cached.
+	w.Write(ds.buildReloadPage())
+}
+
+func (ds *docServer) buildReloadPage() []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Reload(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, "reload"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
+`,
+		ds.currentTranslation.Text_Reload(),
+	)
+
+	if !ds.allowMutations {
+		fmt.Fprintf(page, "<pre><code>%s</code></pre>\n", ds.currentTranslation.Text_ReloadStatus("disabled"))
+	} else if ds.reloadFailed {
+		fmt.Fprintf(page, "<pre><code>%s</code></pre>\n", ds.currentTranslation.Text_ReloadStatus("failed"))
+	}
+
+	var options = ds.parseOptions
+	var checked string
+	if options.Tests {
+		checked = " checked"
+	}
+
+	fmt.Fprintf(page, `<form method="post" action="%s"><pre>
+<input type="hidden" name="page" value="on">
+<code>%s: <input type="text" name="patterns" size="64" value="%s"></code>
+<code>%s: <input type="text" name="tags" size="64" value="%s"></code>
+<code>%s: <input type="text" name="goos" size="16" value="%s"></code>
+<code>%s: <input type="text" name="goarch" size="16" value="%s"></code>
+<code><label><input type="checkbox" name="tests"%s> %s</label></code>
+
+<code><input type="submit" value="%s"></code>
+</pre></form>
+`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeAPI, "reload"}, nil, ""),
+		ds.currentTranslation.Text_ReloadOption("patterns"), html.EscapeString(strings.Join(ds.analyzedArgs, " ")),
+		ds.currentTranslation.Text_ReloadOption("tags"), html.EscapeString(strings.Join(options.BuildTags, ",")),
+		ds.currentTranslation.Text_ReloadOption("goos"), html.EscapeString(options.GOOS),
+		ds.currentTranslation.Text_ReloadOption("goarch"), html.EscapeString(options.GOARCH),
+		checked, ds.currentTranslation.Text_ReloadOption("tests"),
+		ds.currentTranslation.Text_ReloadOption("submit"),
+	)
+
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
+
+// api:reload
+// - GET: get the current reload status.
+// - POST: re-analyze with the posted arguments.
+// The current analysis result is still served until the new one is ready.
+func (ds *docServer) reloadAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		ds.mutex.RLock()
+		status := ds.reloadStatus()
+		ds.mutex.RUnlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"reloadStatus": "%s"}`, status)
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !ds.allowMutations {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error": "reloading is not enabled"}`)
+		return
+	}
+
+	ds.mutex.RLock()
+	options := ds.parseOptions
+	ds.mutex.RUnlock()
+
+	args := strings.Fields(r.FormValue("patterns"))
+	options.BuildTags = strings.FieldsFunc(r.FormValue("tags"), func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	options.GOOS = strings.TrimSpace(r.FormValue("goos"))
+	options.GOARCH = strings.TrimSpace(r.FormValue("goarch"))
+	options.Tests = r.FormValue("tests") != ""
+
+	if !ds.reload(args, options) {
+		w.WriteHeader(http.StatusConflict)
+		fmt.Fprint(w, `{"error": "analyzing is in progress"}`)
+		return
+	}
+
+	// Submitted from the reload page.
+	if r.FormValue("page") != "" {
+		http.Redirect(w, r, ds.urlPrefix+"/reload", http.StatusSeeOther)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	fmt.Fprint(w, `{"reloadStatus": "reloading"}`)
+}
+
+// Must be called when locking.
+func (ds *docServer) reloadStatus() string {
+	switch {
+	case ds.phase < Phase_Analyzed:
+		return "analyzing"
+	case ds.reloading:
+		return "reloading"
+	case ds.reloadFailed:
+		return "failed"
+	}
+	return "analyzed"
+}
+
+// reload starts re-analyzing in background. It returns false
+// if the initial analyzing or another reloading is in progress.
+func (ds *docServer) reload(args []string, options code.ParseOptions) bool {
+	ds.mutex.Lock()
+	if ds.phase < Phase_Analyzed || ds.reloading {
+		ds.mutex.Unlock()
+		return false
+	}
+	ds.reloading = true
+	ds.reloadFailed = false
+	// The loading page only shows the messages of the current analyzing.
+	// A new slice is used, for the old one might be still being read.
+	ds.logsMutex.Lock()
+	ds.analyzingLogs = make([]LoadingLogMessage, 0, 64)
+	ds.logsMutex.Unlock()
+	ds.mutex.Unlock()
+
+	go func() {
+		var succeeded bool
+		defer func() {
+			if succeeded {
+				return
+			}
+			// A panic in analyzing should not crash the server,
+			// which is still serving the old analysis result.
+			if v := recover(); v != nil {
+				log.Printf("reloading panicked: %v\n%s", v, debug.Stack())
+			}
+
+			ds.mutex.Lock()
+			ds.reloading = false
+			ds.reloadFailed = true
+			ds.mutex.Unlock()
+			ds.registerAnalyzingLogMessage(func() string {
+				return ds.currentTranslation.Text_ReloadStatus("failed")
+			})
+			ds.registerAnalyzingLogMessage(func() string { return "" })
+		}()
+
+		// The server should not exit for errors in the new arguments.
+		succeeded = ds.runAnalyzing(args, options, true)
+	}()
+
+	return true
+}
@@ -27,6 +27,12 @@ var UpdateTip2DivID = []string{
 
 // Must be called when locking.
 func (ds *docServer) confirmUpdateTip() {
+	if !ds.allowMutations {
+		// Not to show update tips which can't be followed.
+		ds.updateTip = UpdateTip_Nothing
+		return
+	}
+
 	if ds.updateTip == UpdateTip_Updating {
 		return
 	}
@@ -73,6 +79,13 @@ func (ds *docServer) updateAPI(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if r.Method == http.MethodPost {
+		if !ds.allowMutations {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"error": "updating is not enabled"}`)
+			return
+		}
+
 		w.WriteHeader(http.StatusAccepted)
 		w.Header().Set("Content-Type", "application/json")
 		if ds.updateTip == UpdateTip_ToUpdate {
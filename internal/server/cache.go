@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"errors"
+	"io/ioutil"
+	"log"
+	"sync"
+)
+
+// CacheOptions controls how built pages are cached.
+type CacheOptions struct {
+	// The memory budget of the cached pages (in bytes).
+	// Non-positive values mean not to keep built pages.
+	MemoryLimit int64
+
+	// Store cached pages compressed. Less memory
+	// is used but each cache hit needs uncompressing.
+	Compressed bool
+}
+
+// pageCache caches built pages and is concurrent safe.
+// If several requests for a page which is not cached yet come
+// at the same time, only one of them builds the page, the others
+// just wait for the building result.
+//
+// Least recently used pages are evicted when the total
+// size of the cached pages exceeds the memory limit.
+type pageCache struct {
+	options CacheOptions
+
+	mutex sync.Mutex
+	pages map[interface{}]*cachedPage
+	lru   *list.List // elements are *cachedPage, most recently used first.
+	size  int64
+
+	stats pageCacheStats
+}
+
+type pageCacheStats struct {
+	Hits      int64 `json:"hits"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+	Pages     int   `json:"pages"`
+	Size      int64 `json:"size"`
+	Limit     int64 `json:"limit"`
+}
+
+type cachedPage struct {
+	key     interface{}
+	built   chan struct{} // closed when the page is built
+	content []byte
+	err     error
+
+	compressed bool
+	element    *list.Element // nil if not in the lru list
+}
+
+func newPageCache(sizeHint int, options CacheOptions) *pageCache {
+	return &pageCache{
+		options: options,
+		pages:   make(map[interface{}]*cachedPage, sizeHint),
+		lru:     list.New(),
+	}
+}
+
+// getPage returns the cached page for the specified key.
+// If it is not cached yet, the build function is called to build it.
+// Failed buildings (including panicked ones) are not cached.
+func (c *pageCache) getPage(key interface{}, build func() ([]byte, error)) ([]byte, error) {
+	c.mutex.Lock()
+	page, ok := c.pages[key]
+	if ok {
+		c.stats.Hits++
+		if page.element != nil {
+			c.lru.MoveToFront(page.element)
+		}
+	} else {
+		c.stats.Misses++
+		page = &cachedPage{key: key, built: make(chan struct{})}
+		c.pages[key] = page
+	}
+	c.mutex.Unlock()
+
+	if ok {
+		<-page.built
+		return page.uncompressedContent()
+	}
+
+	var content []byte
+	defer func() {
+		if content == nil {
+			if page.err == nil {
+				page.err = errPageNotBuilt
+			}
+			c.mutex.Lock()
+			delete(c.pages, key)
+			c.mutex.Unlock()
+		} else {
+			c.keep(page)
+		}
+		close(page.built)
+	}()
+
+	content, page.err = build()
+	if page.err != nil {
+		content = nil
+		return nil, page.err
+	}
+
+	page.content = content
+	if c.options.Compressed {
+		if data, err := compress(content); err == nil {
+			page.content = data
+			page.compressed = true
+		} else {
+			log.Println("compress page error:", err)
+		}
+	}
+	return content, nil
+}
+
+// keep puts a just built page in the lru list and evicts
+// the least recently used pages if the memory limit is exceeded.
+func (c *pageCache) keep(page *cachedPage) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	page.element = c.lru.PushFront(page)
+	c.size += int64(len(page.content))
+	for c.size > c.options.MemoryLimit {
+		back := c.lru.Back()
+		if back == nil {
+			break
+		}
+		evicted := c.lru.Remove(back).(*cachedPage)
+		evicted.element = nil
+		c.size -= int64(len(evicted.content))
+		delete(c.pages, evicted.key)
+		c.stats.Evictions++
+	}
+}
+
+func (c *pageCache) statistics() pageCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := c.stats
+	stats.Pages = c.lru.Len()
+	stats.Size = c.size
+	stats.Limit = c.options.MemoryLimit
+	return stats
+}
+
+func (page *cachedPage) uncompressedContent() ([]byte, error) {
+	if page.err != nil || !page.compressed {
+		return page.content, page.err
+	}
+	return uncompress(page.content)
+}
+
+func compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(data) / 4)
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), buf.Bytes()...), nil
+}
+
+func uncompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+var errPageNotBuilt = errors.New("page is not built")
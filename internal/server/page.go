@@ -3,9 +3,10 @@ package server
 import (
 	"bytes"
 	"fmt"
-	"go/build"
+	"html"
 	"strings"
 
+	"go101.org/gold/code"
 	"go101.org/gold/internal/server/translations"
 )
 
@@ -20,6 +21,7 @@ const (
 	ResTypeImplementation pageResType = "imp"
 	ResTypeSource         pageResType = "src"
 	ResTypeUse            pageResType = "use"
+	ResTypeStatistics     pageResType = "sta"
 	ResTypeCSS            pageResType = "css"
 	ResTypeJS             pageResType = "jvs"
 	ResTypeSVG            pageResType = "svg"
@@ -64,7 +66,9 @@ func NewHtmlPage(goldVersion, title, themeName string, currentPageInfo pagePathI
 	return &page
 }
 
-func (page *htmlPage) Done(translation Translation) []byte {
+// buildConfig describes the build configuration used in analyzing.
+// See code.ParseOptions.BuildConfiguration.
+func (page *htmlPage) Done(translation Translation, buildConfig string) []byte {
 	//if genDocsMode {}
 
 	var qrImgLink string
@@ -78,7 +82,7 @@ func (page *htmlPage) Done(translation Translation) []byte {
 	fmt.Fprintf(page, `<pre id="footer">
 %s
 </pre>`,
-		translation.Text_GeneratedPageFooter(page.goldVersion, qrImgLink, build.Default.GOOS, build.Default.GOARCH),
+		translation.Text_GeneratedPageFooter(page.goldVersion, qrImgLink, html.EscapeString(buildConfig)),
 	)
 
 	page.WriteString(`
@@ -105,6 +109,42 @@ func (page *htmlPage) writePageLink(writeHref func(), linkText string, fragments
 	}
 }
 
+// moduleVersionLabel returns a label for packages in
+// modules specified by "path@version" arguments.
+func moduleVersionLabel(mod *code.Module) string {
+	if mod == nil || mod.Version == "" {
+		return ""
+	}
+	return fmt.Sprintf(` <span class="module-version">@%s</span>`, mod.Version)
+}
+
+// moduleTitle returns the text shown for a module in module sections and rows.
+// Packages without modules are viewed as in the main module (GOPATH mode).
+func (ds *docServer) moduleTitle(mod *code.Module) string {
+	switch {
+	case mod == nil:
+		return ds.currentTranslation.Text_MainModule()
+	case mod.Root == "":
+		return "std"
+	default:
+		return mod.Root + moduleVersionLabel(mod)
+	}
+}
+
+// groupPackagesByModules groups packages by their modules.
+// The modules are returned in the order they first appear.
+func groupPackagesByModules(pkgs []*code.Package) ([]*code.Module, map[*code.Module][]*code.Package) {
+	var modules []*code.Module
+	var modulePackages = make(map[*code.Module][]*code.Package)
+	for _, pkg := range pkgs {
+		if _, ok := modulePackages[pkg.Mod]; !ok {
+			modules = append(modules, pkg.Mod)
+		}
+		modulePackages[pkg.Mod] = append(modulePackages[pkg.Mod], pkg)
+	}
+	return modules, modulePackages
+}
+
 func addVersionToFilename(filename string, version string) string {
 	return filename + "-" + version
 }
@@ -0,0 +1,389 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// A builtinFunctionForm is one of the accepted forms of a built-in function.
+type builtinFunctionForm struct {
+	call  string // like "make(T, n)"
+	kinds string // the accepted kinds of the arguments, like "T: slice, map, chan"
+}
+
+type builtinFunctionInfo struct {
+	forms        []builtinFunctionForm
+	example      string
+	runtimeFuncs []string // the runtime functions implementing the function
+}
+
+// The built-in functions not listed here (if any are
+// added in later Go versions) are shown without details.
+var builtinFunctions = map[string]*builtinFunctionInfo{
+	"append": {
+		forms: []builtinFunctionForm{
+			{"append(s S, elems ...E) S", "S: slice of E"},
+			{"append(s S, str string...) S", "S: []byte"},
+		},
+		example: `s := []int{1, 2}
+s = append(s, 3, 4)         // [1 2 3 4]
+s = append(s, []int{5}...)  // [1 2 3 4 5]
+b := append([]byte("Go"), "101"...)`,
+		runtimeFuncs: []string{"growslice"},
+	},
+	"cap": {
+		forms: []builtinFunctionForm{
+			{"cap(v T) int", "T: slice, chan, array, *array"},
+		},
+		example: `s := make([]int, 2, 5)
+println(cap(s)) // 5
+var a [3]int
+println(cap(&a)) // 3 (a constant)`,
+	},
+	"clear": {
+		forms: []builtinFunctionForm{
+			{"clear(m M)", "M: map"},
+			{"clear(s S)", "S: slice"},
+		},
+		example: `m := map[string]int{"Go": 101}
+clear(m) // len(m) == 0
+s := []int{1, 2, 3}
+clear(s) // [0 0 0]`,
+		runtimeFuncs: []string{"mapclear", "memclrNoHeapPointers", "memclrHasPointers"},
+	},
+	"close": {
+		forms: []builtinFunctionForm{
+			{"close(c C)", "C: chan, chan<-"},
+		},
+		example: `c := make(chan int, 1)
+c <- 1
+close(c)
+v, ok := <-c // 1 true
+v, ok = <-c  // 0 false`,
+		runtimeFuncs: []string{"closechan"},
+	},
+	"complex": {
+		forms: []builtinFunctionForm{
+			{"complex(r, i float32) complex64", ""},
+			{"complex(r, i float64) complex128", "r, i: floats or untyped constants"},
+		},
+		example: `c := complex(1.0, 2.0) // (1+2i)
+var f float32 = 3
+c64 := complex(f, f) // complex64`,
+	},
+	"copy": {
+		forms: []builtinFunctionForm{
+			{"copy(dst, src S) int", "S: slice"},
+			{"copy(dst S, src string) int", "S: []byte"},
+		},
+		example: `dst := make([]int, 2)
+n := copy(dst, []int{1, 2, 3}) // n == 2
+b := make([]byte, 5)
+n = copy(b, "Go101")           // n == 5`,
+		runtimeFuncs: []string{"typedslicecopy", "slicecopy", "slicestringcopy"},
+	},
+	"delete": {
+		forms: []builtinFunctionForm{
+			{"delete(m M, key K)", "M: map with key type K"},
+		},
+		example: `m := map[string]int{"Go": 101, "C": 89}
+delete(m, "C")
+delete(m, "Rust") // no-op for absent keys`,
+		runtimeFuncs: []string{"mapdelete"},
+	},
+	"imag": {
+		forms: []builtinFunctionForm{
+			{"imag(c complex64) float32", ""},
+			{"imag(c complex128) float64", "c: complex or untyped constant"},
+		},
+		example: `println(imag(1 + 2i)) // +2.000000e+000`,
+	},
+	"len": {
+		forms: []builtinFunctionForm{
+			{"len(v T) int", "T: string, slice, map, chan, array, *array"},
+		},
+		example: `println(len("Go101"))        // 5
+println(len([]int{1, 2, 3})) // 3
+println(len(map[int]int{}))  // 0`,
+	},
+	"make": {
+		forms: []builtinFunctionForm{
+			{"make(T, length, capacity Integer) T", "T: slice"},
+			{"make(T, length Integer) T", "T: slice"},
+			{"make(T[, size Integer]) T", "T: map, chan"},
+		},
+		example: `s := make([]int, 3, 10) // len(s) == 3, cap(s) == 10
+m := make(map[string]int, 100)
+c := make(chan bool)    // an unbuffered channel`,
+		runtimeFuncs: []string{"makeslice", "makemap_small", "makemap", "makechan"},
+	},
+	"max": {
+		forms: []builtinFunctionForm{
+			{"max(x T, y ...T) T", "T: ordered (integer, float, string)"},
+		},
+		example: `println(max(3, 9, 5))       // 9
+println(max("Go", "101"))   // Go`,
+	},
+	"min": {
+		forms: []builtinFunctionForm{
+			{"min(x T, y ...T) T", "T: ordered (integer, float, string)"},
+		},
+		example: `println(min(3, 9, 5))       // 3
+println(min("Go", "101"))   // 101`,
+	},
+	"new": {
+		forms: []builtinFunctionForm{
+			{"new(T) *T", "T: any type"},
+		},
+		example: `p := new(int) // *p == 0
+*p = 101
+type Point struct{X, Y int}
+q := new(Point) // &Point{}`,
+		runtimeFuncs: []string{"newobject"},
+	},
+	"panic": {
+		forms: []builtinFunctionForm{
+			{"panic(v any)", ""},
+		},
+		example: `defer func() {
+	println(recover().(string)) // bye
+}()
+panic("bye")`,
+		runtimeFuncs: []string{"gopanic"},
+	},
+	"print": {
+		forms: []builtinFunctionForm{
+			{"print(args ...T)", "T: boolean, numeric, string, pointer, chan, map, func, interface"},
+		},
+		example: `print("Go", 101, true, "\n") // Go101true (to stderr)`,
+	},
+	"println": {
+		forms: []builtinFunctionForm{
+			{"println(args ...T)", "T: boolean, numeric, string, pointer, chan, map, func, interface"},
+		},
+		example: `println("Go", 101, true) // Go 101 true (to stderr)`,
+	},
+	"real": {
+		forms: []builtinFunctionForm{
+			{"real(c complex64) float32", ""},
+			{"real(c complex128) float64", "c: complex or untyped constant"},
+		},
+		example: `println(real(1 + 2i)) // +1.000000e+000`,
+	},
+	"recover": {
+		forms: []builtinFunctionForm{
+			{"recover() any", ""},
+		},
+		example: `func f() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered: %v", r)
+		}
+	}()
+	panic("oops")
+}`,
+		runtimeFuncs: []string{"gorecover"},
+	},
+}
+
+// buildBuiltinPackagePage builds a custom page for the builtin package.
+// Built-in functions are shown with their accepted forms, explanations,
+// examples and runtime implementations. Predeclared types are shown with
+// their method sets and the exported aliases of them in analyzed packages.
+func (ds *docServer) buildBuiltinPackagePage(pkg *PackageDetails) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Package(pkg.ImportPath), ds.currentTheme.Name(), pagePathInfo{ResTypePackage, pkg.ImportPath})
+
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">package <b>%s</b></span>
+`,
+		pkg.Name,
+	)
+
+	fmt.Fprintf(page, `
+<span class="title">%s</span>
+	<a href="%s#pkg-%s">%s</a>%s`,
+		ds.currentTranslation.Text_ImportPath(),
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, ""}, nil, ""),
+		pkg.ImportPath,
+		pkg.ImportPath,
+		ds.currentTranslation.Text_PackageDocsLinksOnOtherWebsites(pkg.ImportPath, pkg.IsStandard),
+	)
+
+	var funcs, values []code.ValueResource
+	for _, v := range pkg.ValueResources {
+		if _, ok := v.(*code.Function); ok {
+			funcs = append(funcs, v)
+		} else {
+			values = append(values, v)
+		}
+	}
+
+	if len(funcs) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_BuiltinFunctions(len(funcs)), `</span>`)
+		page.WriteByte('\n')
+		for _, f := range funcs {
+			page.WriteByte('\n')
+			fmt.Fprintf(page, `<div class="anchor" id="name-%s">`, f.Name())
+			page.WriteByte('\t')
+			ds.writeResourceIndexHTML(page, f, true, false)
+			if info := builtinFunctions[f.Name()]; info != nil {
+				ds.writeBuiltinFunctionDetails(page, f.Name(), info)
+			} else if doc := f.Documentation(); doc != "" {
+				page.WriteString("\n")
+				writePageText(page, "\t\t", doc, true)
+			}
+			page.WriteString("</div>")
+		}
+	}
+
+	if len(pkg.ExportedTypeNames) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_PredeclaredTypes(len(pkg.ExportedTypeNames)), `</span>`)
+		page.WriteByte('\n')
+		for _, et := range pkg.ExportedTypeNames {
+			page.WriteByte('\n')
+			fmt.Fprintf(page, `<div class="anchor" id="name-%s">`, et.TypeName.Name())
+			page.WriteByte('\t')
+			ds.writeResourceIndexHTML(page, et.TypeName, true, false)
+			if doc := et.TypeName.Documentation(); doc != "" {
+				page.WriteString("\n")
+				writePageText(page, "\t\t", doc, true)
+			}
+			ds.writePredeclaredTypeDetails(page, pkg.Package, et)
+			page.WriteString("</div>")
+		}
+	}
+
+	if len(values) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_PredeclaredValues(len(values)), `</span>`)
+		page.WriteByte('\n')
+		for _, v := range values {
+			page.WriteByte('\n')
+			fmt.Fprintf(page, `<div class="anchor" id="name-%s">`, v.Name())
+			page.WriteByte('\t')
+			ds.writeResourceIndexHTML(page, v, true, false)
+			if doc := v.Documentation(); doc != "" {
+				page.WriteString("\n")
+				writePageText(page, "\t\t", doc, true)
+			}
+			page.WriteString("</div>")
+		}
+	}
+
+	page.WriteString("</code></pre>")
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
+
+func (ds *docServer) writeBuiltinFunctionDetails(page *htmlPage, name string, info *builtinFunctionInfo) {
+	page.WriteString("\n")
+	writePageText(page, "\t\t", ds.currentTranslation.Text_BuiltinFunctionExplanation(name), true)
+
+	// The signature matrix.
+	var maxWidth = 0
+	for _, form := range info.forms {
+		if len(form.call) > maxWidth {
+			maxWidth = len(form.call)
+		}
+	}
+	page.WriteString("\n")
+	for _, form := range info.forms {
+		page.WriteString("\n\t\t")
+		WriteHtmlEscapedBytes(page, []byte(form.call))
+		if form.kinds != "" {
+			page.WriteString(strings.Repeat(" ", maxWidth-len(form.call)+2))
+			page.WriteString(`<span class="comment">// `)
+			WriteHtmlEscapedBytes(page, []byte(form.kinds))
+			page.WriteString(`</span>`)
+		}
+	}
+
+	if info.example != "" {
+		page.WriteString("\n\n\t\t<i>")
+		page.WriteString(ds.currentTranslation.Text_Example())
+		page.WriteString(":</i>")
+		for _, line := range buildLexicallyHighlightedGoLines([]byte(info.example)) {
+			page.WriteString("\n\t\t\t")
+			page.WriteString(line)
+		}
+	}
+
+	if runtimePkg := ds.analyzer.RuntimePackage(); runtimePkg != nil {
+		var written = false
+		for _, f := range info.runtimeFuncs {
+			pos := ds.analyzer.RuntimeFunctionCodePosition(f)
+			if !pos.IsValid() {
+				continue // not implemented in the current Go version
+			}
+			if written {
+				page.WriteString(", ")
+			} else {
+				fmt.Fprintf(page, "\n\n\t\t<i>%s:</i> ", ds.currentTranslation.Text_RuntimeImplementation())
+				written = true
+			}
+			ds.writeSrouceCodeLineLink(page, runtimePkg, pos, "runtime."+f, "", false)
+		}
+	}
+	page.WriteString("\n")
+}
+
+func (ds *docServer) writePredeclaredTypeDetails(page *htmlPage, pkg *code.Package, et *ExportedType) {
+	name := et.TypeName.Name()
+	page.WriteString("\n")
+	if count := len(et.Methods); count > 0 {
+		page.WriteString("\n\t\t")
+		writeNamedStatTitle(page, name, "methods",
+			ds.currentTranslation.Text_Methods(count),
+			func() {
+				methods := ds.sortMethodList(et.Methods)
+				for _, mthd := range methods {
+					page.WriteString("\n\t\t\t")
+					ds.writeMethodForListing(page, pkg, mthd, et.TypeName, true)
+				}
+			})
+	}
+	if count := len(et.ImplementedBys); count > 0 {
+		page.WriteString("\n\t\t")
+		writeNamedStatTitle(page, name, "impledby",
+			ds.currentTranslation.Text_ImplementedBy(count),
+			func() {
+				impledLys := ds.sortTypeList(et.ImplementedBys, pkg)
+				for _, by := range impledLys {
+					page.WriteString("\n\t\t\t")
+					ds.writeTypeForListing(page, by, pkg, "", DotMStyle_NotShow)
+				}
+			})
+	}
+	if aliases := exportedAliasesOfPredeclaredType(et.TypeName); len(aliases) > 0 {
+		page.WriteString("\n\t\t")
+		writeNamedStatTitle(page, name, "aliases",
+			ds.currentTranslation.Text_AliasedBy(len(aliases)),
+			func() {
+				for _, tn := range aliases {
+					page.WriteString("\n\t\t\t")
+					fmt.Fprintf(page, `<a href="%s#name-%s">%s.%s</a>`,
+						buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, tn.Pkg.Path()}, nil, ""),
+						tn.Name(), tn.Pkg.Path(), tn.Name())
+				}
+			})
+	}
+}
+
+// exportedAliasesOfPredeclaredType returns the exported aliases,
+// declared in analyzed non-builtin packages, of a predeclared type.
+func exportedAliasesOfPredeclaredType(tn *code.TypeName) []*code.TypeName {
+	var aliases []*code.TypeName
+	for _, alias := range tn.Denoting().Aliases {
+		if a := alias.TypeName; a != nil && a.Pkg != nil && a.Pkg.Path() != "builtin" && a.Exported() {
+			aliases = append(aliases, a)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if pi, pj := aliases[i].Pkg.Path(), aliases[j].Pkg.Path(); pi != pj {
+			return pi < pj
+		}
+		return aliases[i].Name() < aliases[j].Name()
+	})
+	return aliases
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"fmt"
+	"go/types"
+	"net/http"
+	"path/filepath"
+
+	"go101.org/gold/code"
+)
+
+func (ds *docServer) deprecatedUsesPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := deprecatedUsesPageKey{}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, _ := ds.pages.getPage(pageKey, func() ([]byte, error) {
+		return ds.buildDeprecatedUsesPage(), nil
+	})
+	ds.writePageContent(w, r, etag, content)
+}
+
+type deprecatedUsesPageKey struct{}
+
+// deprecatedObjectName returns "path.F" for package-level objects
+// and "path.T.M" for methods. Struct fields are shown as "path.F".
+func deprecatedObjectName(obj types.Object) string {
+	name := obj.Name()
+	if fn, ok := obj.(*types.Func); ok {
+		if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+			tt := recv.Type()
+			if ptr, ok := tt.(*types.Pointer); ok {
+				tt = ptr.Elem()
+			}
+			if named, ok := tt.(*types.Named); ok {
+				name = named.Obj().Name() + "." + name
+			}
+		}
+	}
+	return obj.Pkg().Path() + "." + name
+}
+
+func (ds *docServer) buildDeprecatedUsesPage() []byte {
+	uses := ds.analyzer.DeprecatedIdentifierUses(ds.analyzer.MainPackages())
+
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_DeprecatedUses(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, "deprecated"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code>
+<code>	<i>%s</i></code>
+</pre>
+`,
+		ds.currentTranslation.Text_DeprecatedUses(),
+		ds.currentTranslation.Text_DeprecatedUsesNote(len(uses)),
+	)
+
+	page.WriteString("<pre><code>")
+	var lastPkg *code.Package
+	for i := range uses {
+		use := &uses[i]
+		if use.Pkg != lastPkg {
+			if lastPkg != nil {
+				page.WriteString("\n")
+			}
+			lastPkg = use.Pkg
+			page.WriteString("\n\t")
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, use.Pkg.Path()}, page, use.Pkg.Path())
+		}
+		page.WriteString("\n\t\t")
+		ds.writeSrouceCodeLineLink(page, use.Pkg, use.Position, fmt.Sprintf("%s#L%d", filepath.Base(use.Position.Filename), use.Position.Line), "", false)
+		page.WriteString(" ")
+		name := deprecatedObjectName(use.Object)
+		if declPkg := ds.analyzer.PackageByPath(use.Object.Pkg().Path()); declPkg != nil {
+			ds.writeSrouceCodeLineLink(page, declPkg, declPkg.PPkg.Fset.PositionFor(use.Object.Pos(), false), name, "", false)
+		} else {
+			page.WriteString(name)
+		}
+		ds.writeDeprecationNote(page, use.Note)
+	}
+	page.WriteString("\n</code></pre>")
+
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
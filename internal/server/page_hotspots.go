@@ -0,0 +1,153 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"go101.org/gold/code"
+)
+
+// HotspotsN is the max number of functions listed on the hotspots page.
+const HotspotsN = 100
+
+// ComplexityBadgeThreshold is the cyclomatic complexity above which
+// functions are marked with badges on package details pages.
+const ComplexityBadgeThreshold = 10
+
+func (ds *docServer) hotspotsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	var sortBy = r.FormValue("sortby")
+	switch sortBy {
+	case "complexity", "statements", "nesting", "parameters":
+	default:
+		sortBy = "complexity"
+	}
+
+	pageKey := hotspotsPageKey{sortBy: sortBy}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, _ := ds.pages.getPage(pageKey, func() ([]byte, error) {
+		return ds.buildHotspotsPage(sortBy), nil
+	})
+	ds.writePageContent(w, r, etag, content)
+}
+
+type hotspotsPageKey struct {
+	sortBy string // "complexity", "statements", "nesting", "parameters"
+}
+
+// functionDisplayName returns "F" for functions and "T.M" for methods.
+func functionDisplayName(f *code.Function) string {
+	if f.IsMethod() {
+		if _, typeId, _ := f.ReceiverTypeName(); typeId != nil {
+			return typeId.Name + "." + f.Name()
+		}
+	}
+	return f.Name()
+}
+
+func (ds *docServer) buildHotspotsPage(sortBy string) []byte {
+	var funcs []*code.Function
+	for _, pkg := range ds.analyzer.MainPackages() {
+		for _, f := range pkg.AllFunctions {
+			if f.Metrics.Complexity > 0 {
+				funcs = append(funcs, f)
+			}
+		}
+	}
+
+	var metric = func(m *code.FunctionMetrics) int {
+		switch sortBy {
+		case "statements":
+			return m.Statements
+		case "nesting":
+			return m.Nesting
+		case "parameters":
+			return m.Parameters
+		default:
+			return m.Complexity
+		}
+	}
+	sort.SliceStable(funcs, func(i, j int) bool {
+		a, b := funcs[i], funcs[j]
+		if x, y := metric(&a.Metrics), metric(&b.Metrics); x != y {
+			return x > y
+		}
+		if a.Metrics.Complexity != b.Metrics.Complexity {
+			return a.Metrics.Complexity > b.Metrics.Complexity
+		}
+		if a.Pkg != b.Pkg {
+			return a.Pkg.Path() < b.Pkg.Path()
+		}
+		return functionDisplayName(a) < functionDisplayName(b)
+	})
+	numFuncs := len(funcs)
+	if len(funcs) > HotspotsN {
+		funcs = funcs[:HotspotsN]
+	}
+
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Hotspots(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, "hotspots"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code>
+<code>	<i>%s</i></code>
+</pre>
+`,
+		ds.currentTranslation.Text_Hotspots(),
+		ds.currentTranslation.Text_HotspotsNote(len(funcs), numFuncs),
+	)
+
+	// The column headers are also sorting links (except in generation mode).
+	page.WriteString("<pre><code>\t")
+	for _, item := range []string{"complexity", "statements", "nesting", "parameters"} {
+		text := ds.currentTranslation.Text_HotspotsItem(item)
+		page.WriteString(strings.Repeat(" ", 10-len([]rune(text))))
+		if genDocsMode || item == sortBy {
+			page.WriteString(text)
+		} else {
+			fmt.Fprintf(page, `<a href="?sortby=%s">%s</a>`, item, text)
+		}
+		page.WriteString("  ")
+	}
+	page.WriteString(ds.currentTranslation.Text_HotspotsItem("function"))
+
+	for _, f := range funcs {
+		m := &f.Metrics
+		fmt.Fprintf(page, "\n\t%10d  %10d  %10d  %10d  ", m.Complexity, m.Statements, m.Nesting, m.Parameters)
+		ds.writeSrouceCodeLineLink(page, f.Pkg, f.Position(), f.Pkg.Path()+"."+functionDisplayName(f), "", false)
+	}
+	page.WriteString("\n</code></pre>")
+
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
+
+// writeComplexityBadge writes a badge for a function
+// with a high cyclomatic complexity.
+func (ds *docServer) writeComplexityBadge(page *htmlPage, m *code.FunctionMetrics) {
+	if m.Complexity <= ComplexityBadgeThreshold {
+		return
+	}
+	class := "complexity-badge"
+	if m.Complexity > 2*ComplexityBadgeThreshold {
+		class += " high"
+	}
+	fmt.Fprintf(page, ` <span class="%s" title="%s">%s</span>`,
+		class,
+		ds.currentTranslation.Text_FunctionMetrics(m.Complexity, m.Statements, m.Nesting, m.Parameters),
+		ds.currentTranslation.Text_ComplexityBadge(m.Complexity),
+	)
+}
@@ -36,8 +36,8 @@ func (ds *docServer) identifierUsesPage(w http.ResponseWriter, r *http.Request,
 	// Cache the ever searcheds is ok.
 	//    map[*ast.Ident][]token.Pos
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -48,16 +48,24 @@ func (ds *docServer) identifierUsesPage(w http.ResponseWriter, r *http.Request,
 	// Pages for non-exported identifiers will not be cached.
 
 	useKey := usePageKey{pkg: pkgPath, id: identifier}
-	if ds.identifierUsePages[useKey] == nil {
+	etag := ds.pageETag(useKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, err := ds.pages.getPage(useKey, func() ([]byte, error) {
 		result, err := ds.buildUsesData(pkgPath, identifier)
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprint(w, "Find uses for (", identifier, ") in ", pkgPath, " error: ", err)
-			return
+			return nil, err
 		}
-		ds.identifierUsePages[useKey] = ds.buildUsesPage(result)
+		return ds.buildUsesPage(result), nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Find uses for (", identifier, ") in ", pkgPath, " error: ", err)
+		return
 	}
-	w.Write(ds.identifierUsePages[useKey])
+	ds.writePageContent(w, r, etag, content)
 }
 
 func (ds *docServer) buildUsesPage(result *UsesResult) []byte {
@@ -20,8 +20,8 @@ func (ds *docServer) methodImplementationPage(w http.ResponseWriter, r *http.Req
 
 	//log.Println(pkgPath, bareFilename)
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -30,16 +30,24 @@ func (ds *docServer) methodImplementationPage(w http.ResponseWriter, r *http.Req
 	}
 
 	pageKey := implPageKey{pkg: pkgPath, typ: typeName}
-	if ds.implPages[pageKey] == nil {
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, err := ds.pages.getPage(pageKey, func() ([]byte, error) {
 		result, err := ds.buildImplementationData(ds.analyzer, pkgPath, typeName)
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprint(w, "Build implementation info for (", typeName, ") in ", pkgPath, " error: ", err)
-			return
+			return nil, err
 		}
-		ds.implPages[pageKey] = ds.buildImplementationPage(result)
+		return ds.buildImplementationPage(result), nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Build implementation info for (", typeName, ") in ", pkgPath, " error: ", err)
+		return
 	}
-	w.Write(ds.implPages[pageKey])
+	ds.writePageContent(w, r, etag, content)
 }
 
 func (ds *docServer) buildImplementationPage(result *MethodImplementationResult) []byte {
@@ -99,7 +107,7 @@ func (ds *docServer) buildImplementationPage(result *MethodImplementationResult)
 	}
 
 	page.WriteString("</code></pre>")
-	return page.Done(ds.currentTranslation)
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
 }
 
 type MethodImplementationResult struct {
@@ -3,6 +3,7 @@ package server
 import (
 	"fmt"
 	"go/token"
+	"html"
 	"log"
 	"net/http"
 	"sort"
@@ -11,17 +12,22 @@ import (
 	"go101.org/gold/code"
 )
 
-type overviewPage struct {
-	content []byte
-
-	sortBy string // "alphabet", "importedbys"
+type overviewPageKey struct {
+	sortBy    string // "alphabet", "importedbys"
+	updateTip int
 }
 
 func (ds *docServer) overviewPage(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html")
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	if !genDocsMode {
+		ds.mutex.Lock()
+		ds.confirmUpdateTip()
+		ds.mutex.Unlock()
+	}
+
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	//if ds.phase < Phase_Parsed {
 	if ds.phase < Phase_Analyzed {
@@ -29,45 +35,65 @@ func (ds *docServer) overviewPage(w http.ResponseWriter, r *http.Request) {
 		ds.loadingPage(w, r)
 		return
 	} else if r.FormValue("js") != "" {
-		http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+		http.Redirect(w, r, ds.urlPrefix+"/", http.StatusTemporaryRedirect)
 		return
 	}
 
-	if !genDocsMode {
-		if ds.confirmUpdateTip(); ds.updateTip != ds.cachedUpdateTip {
-			ds.theOverviewPage = nil
-			ds.cachedUpdateTip = ds.updateTip
-		}
-	}
-
 	var sortBy = r.FormValue("sortby")
+	ds.optionsMutex.Lock()
 	switch sortBy {
 	case "alphabet", "importedbys":
+		ds.overviewSortBy = sortBy
 	default:
-		if ds.theOverviewPage != nil {
-			sortBy = ds.theOverviewPage.sortBy
+		if ds.overviewSortBy != "" {
+			sortBy = ds.overviewSortBy
 		} else {
 			sortBy = "alphabet"
 		}
 	}
+	ds.optionsMutex.Unlock()
 
-	if ds.theOverviewPage == nil || sortBy != ds.theOverviewPage.sortBy {
-		overview := ds.buildOverviewData(sortBy)
-		ds.theOverviewPage = &overviewPage{
-			content: ds.buildOverviewPage(overview, sortBy),
-			sortBy:  sortBy,
-		}
+	// The update tip is shown on the overview page.
+	pageKey := overviewPageKey{sortBy: sortBy, updateTip: ds.updateTip}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
 	}
-	w.Write(ds.theOverviewPage.content)
+
+	content, _ := ds.pages.getPage(pageKey, func() ([]byte, error) {
+		overview := ds.buildOverviewData(sortBy)
+		return ds.buildOverviewPage(overview, sortBy), nil
+	})
+	ds.writePageContent(w, r, etag, content)
 }
 
 func (ds *docServer) buildOverviewPage(overview *Overview, sortBy string) []byte {
 	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Overview(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, ""})
 	fmt.Fprintf(page, `
-<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
-`,
+<pre><code><span style="font-size:xx-large;">%s</span></code>`,
 		ds.currentTranslation.Text_Overview(),
 	)
+	for _, mod := range ds.analyzer.RequestedModules() {
+		fmt.Fprintf(page, `
+<code>	%s%s</code>`,
+			mod.Root,
+			moduleVersionLabel(mod),
+		)
+	}
+	fmt.Fprintf(page, `
+<code>	<i>%s%s%s</i></code>`,
+		ds.currentTranslation.Text_BuildConfiguration(),
+		ds.currentTranslation.Text_Colon(true),
+		html.EscapeString(ds.parseOptions.BuildConfiguration()),
+	)
+	if !genDocsMode && ds.allowMutations {
+		page.WriteString(`
+<code>	`)
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "reload"}, page, ds.currentTranslation.Text_Reload())
+		page.WriteString(`</code>`)
+	}
+	page.WriteString(`</pre>
+`)
 
 	if !genDocsMode {
 		ds.writeUpdateGoldBlock(page)
@@ -104,7 +130,7 @@ func (ds *docServer) buildOverviewPage(overview *Overview, sortBy string) []byte
 
 	page.WriteString("</pre>")
 
-	return page.Done(ds.currentTranslation)
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
 }
 
 func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*PackageForListing, writeAnchorTarget, inGenModeRootPages bool, sortBy string) {
@@ -166,6 +192,14 @@ func (ds *docServer) writePackagesForListing(page *htmlPage, packages []*Package
 			)
 
 		}
+		page.WriteString(moduleVersionLabel(pkg.Mod))
+		if pkg.NumErrors > 0 {
+			fmt.Fprintf(page,
+				` <a href="%s#diagnostics" class="error-badge">%s</a>`,
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path}, nil, ""),
+				ds.currentTranslation.Text_NumErrors(pkg.NumErrors),
+			)
+		}
 		if sortBy == "importedbys" {
 			fmt.Fprintf(page, ` <i>(%d)</i>`, pkg.NumImportedBys)
 		}
@@ -194,11 +228,15 @@ func (ds *docServer) writeSimpleStatsBlock(page *htmlPage, stats *code.Stats) {
 	text := ds.currentTranslation.Text_SimpleStats(stats)
 	text = strings.Replace(text, "\n", "\n\t", -1)
 	moreLink := buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "statistics"}, nil, "")
+	mainLink := ""
+	if len(ds.analyzer.MainPackages()) > 0 {
+		mainLink = buildPageHref(page.PathInfo, pagePathInfo{ResTypeStatistics, "main"}, nil, "")
+	}
 	fmt.Fprintf(page, `
 <pre><code><span class="title">%s</span></code>
 	%s
 </pre>`,
-		ds.currentTranslation.Text_StatisticsWithMoreLink(moreLink),
+		ds.currentTranslation.Text_StatisticsWithMoreLink(moreLink, mainLink),
 		text,
 	)
 }
@@ -213,6 +251,7 @@ type PackageForListing struct {
 	Package *code.Package
 
 	Index     int
+	NumErrors int
 	Mod       *code.Module
 	Name      string
 	Path      string // blank for not analyzed yet
@@ -240,6 +279,7 @@ func (ds *docServer) buildOverviewData(sortBy string) *Overview {
 		pkg.Remaining = p.Path()
 		pkg.Name = p.PPkg.Name
 		pkg.Index = p.Index
+		pkg.NumErrors = len(p.Errors)
 
 		pkg.DepLevel = int32(p.DepLevel)
 		pkg.NumImportedBys = int32(len(p.DepedBys))
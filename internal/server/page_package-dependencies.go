@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -9,8 +10,8 @@ import (
 func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Request, pkgPath string) {
 	w.Header().Set("Content-Type", "text/html")
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -18,19 +19,29 @@ func (ds *docServer) packageDependenciesPage(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if ds.dependencyPages[pkgPath] == nil {
-		// ToDo: not found
+	pageKey := dependencyPageKey{pkg: pkgPath}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
 
+	content, err := ds.pages.getPage(pageKey, func() ([]byte, error) {
 		depInfo := ds.buildPackageDependenciesData(pkgPath)
 		if depInfo == nil {
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "Package (%s) not found", pkgPath)
-			return
+			return nil, errors.New("not found")
 		}
-
-		ds.dependencyPages[pkgPath] = ds.buildPackageDependenciesPage(depInfo)
+		return ds.buildPackageDependenciesPage(depInfo), nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Package (%s) not found", pkgPath)
+		return
 	}
-	w.Write(ds.dependencyPages[pkgPath])
+	ds.writePageContent(w, r, etag, content)
+}
+
+type dependencyPageKey struct {
+	pkg string
 }
 
 type PackageDependencyInfo struct {
@@ -119,5 +130,5 @@ func (ds *docServer) buildPackageDependenciesPage(depInfo *PackageDependencyInfo
 		ds.writePackagesForListing(page, depInfo.ImportedBys, false, false, "")
 	}
 
-	return page.Done(ds.currentTranslation)
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
 }
@@ -8,6 +8,7 @@ import (
 	"go/ast"
 	"go/token"
 	"go/types"
+	"html"
 	"io"
 	"log"
 	"net/http"
@@ -21,24 +22,22 @@ import (
 
 var _ = log.Print
 
-type packagePage struct {
-	content []byte
-
+type packagePageKey struct {
+	pkg     string
 	options packagePageOptions
 }
 
 type packagePageOptions struct {
-	sortBy string // "alphabet", "popularity"
-	filter string // "all", "exported"
+	sortBy     string // "alphabet", "popularity"
+	filter     string // "all", "exported"
+	deprecated string // "show", "hide"
 }
 
 func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request, pkgPath string) {
 	w.Header().Set("Content-Type", "text/html")
 
-	// ToDo: create a custom "builtin" package page.
-
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -46,14 +45,15 @@ func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	page, ok := ds.packagePages[pkgPath]
+	ds.optionsMutex.Lock()
+	lastOptions, ok := ds.packagePageOptions[pkgPath]
 
 	var sortBy = r.FormValue("sortby")
 	switch sortBy {
 	case "alphabet", "popularity":
 	default:
 		if ok {
-			sortBy = page.options.sortBy
+			sortBy = lastOptions.sortBy
 		} else {
 			sortBy = "alphabet"
 		}
@@ -64,32 +64,62 @@ func (ds *docServer) packageDetailsPage(w http.ResponseWriter, r *http.Request,
 	case "all", "exporteds":
 	default:
 		if ok {
-			filter = page.options.filter
+			filter = lastOptions.filter
 		} else {
 			filter = "exporteds"
 		}
 	}
 
+	// Deprecated identifiers are hidden by default, except
+	// in generation mode, in which the toggle is not available.
+	var deprecated = r.FormValue("deprecated")
+	switch deprecated {
+	case "show", "hide":
+	default:
+		if ok {
+			deprecated = lastOptions.deprecated
+		} else if genDocsMode {
+			deprecated = "show"
+		} else {
+			deprecated = "hide"
+		}
+	}
+
 	options := packagePageOptions{
-		sortBy: sortBy,
-		filter: filter,
+		sortBy:     sortBy,
+		filter:     filter,
+		deprecated: deprecated,
+	}
+	ds.optionsMutex.Unlock()
+
+	pageKey := packagePageKey{pkg: pkgPath, options: options}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
 	}
 
-	if !ok || page.options != options {
+	content, err := ds.pages.getPage(pageKey, func() ([]byte, error) {
 		//details := ds.buildPackageDetailsData(pkgPath)
 		details := buildPackageDetailsData(ds.analyzer, pkgPath, options)
 		if details == nil {
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprintf(w, "Package (%s) not found", pkgPath)
-			return
+			return nil, errors.New("not found")
 		}
-
-		ds.packagePages[pkgPath] = packagePage{
-			content: ds.buildPackageDetailsPage(details, options),
-			options: options,
+		if pkgPath == "builtin" {
+			return ds.buildBuiltinPackagePage(details), nil
 		}
+		return ds.buildPackageDetailsPage(details, options), nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Package (%s) not found", pkgPath)
+		return
 	}
-	w.Write(ds.packagePages[pkgPath].content)
+
+	ds.optionsMutex.Lock()
+	ds.packagePageOptions[pkgPath] = options
+	ds.optionsMutex.Unlock()
+
+	ds.writePageContent(w, r, etag, content)
 }
 
 func (ds *docServer) buildPackageDetailsPage(pkg *PackageDetails, options packagePageOptions) []byte {
@@ -103,11 +133,12 @@ func (ds *docServer) buildPackageDetailsPage(pkg *PackageDetails, options packag
 
 	fmt.Fprintf(page, `
 <span class="title">%s</span>
-	<a href="%s#pkg-%s">%s</a>%s`,
+	<a href="%s#pkg-%s">%s</a>%s%s`,
 		ds.currentTranslation.Text_ImportPath(),
 		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, ""}, nil, ""),
 		pkg.ImportPath,
 		pkg.ImportPath,
+		moduleVersionLabel(pkg.Package.Mod),
 		ds.currentTranslation.Text_PackageDocsLinksOnOtherWebsites(pkg.ImportPath, pkg.IsStandard),
 	)
 
@@ -122,6 +153,36 @@ func (ds *docServer) buildPackageDetailsPage(pkg *PackageDetails, options packag
 		)
 	}
 
+	if lines, generatedLines := pkg.Package.Lines, pkg.Package.GeneratedLines; lines.Total()+generatedLines.Total() > 0 {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	%s`,
+			ds.currentTranslation.Text_StatisticsTitle("lines"),
+			ds.currentTranslation.Text_LineStatistics(lines, generatedLines),
+		)
+
+		var moduleStatsLink string
+		if mod := pkg.Package.Mod; mod != nil {
+			root := mod.Root
+			if ds.analyzer.IsStandardPackage(pkg.Package) {
+				root = "std"
+			}
+			moduleStatsLink = buildPageHref(page.PathInfo, pagePathInfo{ResTypeStatistics, "mod/" + root}, nil, "")
+		}
+		fmt.Fprintf(page, `
+	%s`,
+			ds.currentTranslation.Text_DetailedStatisticsLinks(
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypeStatistics, "pkg/" + pkg.ImportPath}, nil, ""),
+				moduleStatsLink,
+			),
+		)
+	}
+
+	if len(pkg.Package.Errors) > 0 {
+		ds.writePackageDiagnostics(page, pkg.Package)
+	}
+
 	if len(pkg.Files) > 0 {
 		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_InvolvedFiles(len(pkg.Files)), `</span>`)
 
@@ -154,6 +215,46 @@ func (ds *docServer) buildPackageDetailsPage(pkg *PackageDetails, options packag
 				page.WriteString("    ")
 			}
 			ds.writeSrouceCodeFileLink(page, pkg.Package, info.Filename)
+			ds.writeBuildConstraint(page, info.BuildConstraint)
+		}
+	}
+
+	if ignoreds := pkg.Package.IgnoredFiles; len(ignoreds) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_IgnoredFiles(len(ignoreds)), `</span>`)
+		for _, info := range ignoreds {
+			page.WriteString("\n\t")
+			ds.writeSrouceCodeFileLink(page, pkg.Package, info.BareFilename)
+			fmt.Fprintf(page, ` <i>(%s)</i>`, ds.currentTranslation.Text_IgnoredFileReason(info.Reason))
+			ds.writeBuildConstraint(page, info.BuildConstraint)
+		}
+	}
+
+	if symbols := pkg.Package.CgoSymbols; len(symbols) > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_CgoInterface(len(symbols)), `</span>`)
+		for _, sym := range symbols {
+			page.WriteString("\n\t")
+			if sym.Declaration != nil {
+				ds.writeSrouceCodeLineLink(page, pkg.Package, sym.Declaration.Position, "C."+sym.Name, "", false)
+			} else {
+				page.WriteString("C." + sym.Name)
+			}
+			fmt.Fprintf(page, ` <i>(%s)</i>`, ds.currentTranslation.Text_CgoSymbol(sym.Kind, sym.Uses, sym.Declaration != nil))
+		}
+	}
+
+	if pkg.NumDeprecateds > 0 {
+		fmt.Fprint(page, "\n\n", `<span class="title">`, ds.currentTranslation.Text_DeprecatedIdentifiers(pkg.NumDeprecateds), `</span>`)
+		// The toggle is not available in generation mode.
+		if !genDocsMode {
+			toggle := "show"
+			if options.deprecated == "show" {
+				toggle = "hide"
+			}
+			fmt.Fprintf(page, `
+	<a href="?deprecated=%s">%s</a>`,
+				toggle,
+				ds.currentTranslation.Text_DeprecatedIdentifiersShowOption(toggle == "show"),
+			)
 		}
 	}
 
@@ -349,18 +450,59 @@ WriteValues:
 			page.WriteString("\n")
 			writePageText(page, "\t\t", doc, true)
 		}
+		if f, ok := v.(*code.Function); ok {
+			if asm := f.AsmImplementation(); asm != nil {
+				fmt.Fprintf(page, "\n\t\t<i>%s: ", ds.currentTranslation.Text_ImplementedInAssembly())
+				ds.writeSrouceCodeLineLink(page, f.Pkg, asm.Position, fmt.Sprintf("%s#L%d", filepath.Base(asm.Position.Filename), asm.Position.Line), "", false)
+				page.WriteString("</i>")
+			}
+		}
 		page.WriteString("</div>")
 	}
 
 Done:
 	page.WriteString("</code></pre>")
-	return page.Done(ds.currentTranslation)
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
 }
 
 type FileInfo struct {
-	Filename     string
-	MainPosition *token.Position // for main packages only
-	HasDocs      bool
+	Filename        string
+	MainPosition    *token.Position // for main packages only
+	HasDocs         bool
+	BuildConstraint string
+}
+
+func (ds *docServer) writeBuildConstraint(page *htmlPage, constraint string) {
+	if constraint != "" {
+		page.WriteString(`  <span class="comment">`)
+		page.WriteString(html.EscapeString(constraint))
+		page.WriteString(`</span>`)
+	}
+}
+
+func (ds *docServer) writePackageDiagnostics(page *htmlPage, pkg *code.Package) {
+	fmt.Fprintf(page, `
+
+<span class="title" id="diagnostics">%s</span>`,
+		ds.currentTranslation.Text_Diagnostics(len(pkg.Errors)),
+	)
+	for _, e := range pkg.Errors {
+		page.WriteString("\n\t")
+		if e.Position.IsValid() && pkg.SourceFileInfoByFilePath(e.Position.Filename) != nil {
+			text := fmt.Sprintf("%s:%d", filepath.Base(e.Position.Filename), e.Position.Line)
+			if e.Position.Column > 0 {
+				text = fmt.Sprintf("%s:%d", text, e.Position.Column)
+			}
+			ds.writeSrouceCodeLineLink(page, pkg, e.Position, text, "", false)
+			page.WriteString(": ")
+		} else if e.Position.Filename != "" {
+			WriteHtmlEscapedBytes(page, []byte(e.Position.String()))
+			page.WriteString(": ")
+		}
+		page.WriteString(`<span class="error-message">`)
+		WriteHtmlEscapedBytes(page, []byte(e.Message))
+		page.WriteString(`</span>`)
+	}
 }
 
 type PackageDetails struct {
@@ -382,6 +524,10 @@ type PackageDetails struct {
 
 	HasHiddenTypeNames bool
 
+	// The number of deprecated package-level identifiers
+	// and listed methods, including the hidden ones.
+	NumDeprecateds int
+
 	// Line dismatches exist in some cgo generated files.
 	//FileLineNumberOffsets map[string][]int
 
@@ -453,6 +599,35 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	//analyzer.BuildCgoFileMappings(pkg)
 
 	alsoShowNonExporteds := options.filter == "all"
+	hideDeprecateds := options.deprecated == "hide"
+
+	var numDeprecateds int
+	var isHiddenDeprecated = func(res code.Resource) bool {
+		if analyzer.ResourceDeprecationNote(res) == "" {
+			return false
+		}
+		numDeprecateds++
+		return hideDeprecateds
+	}
+	var visibleMethods = func(methods []*code.Selector) []*code.Selector {
+		var visibles = methods[:0]
+		for _, sel := range methods {
+			if analyzer.MethodDeprecationNote(sel.Method) != "" {
+				numDeprecateds++
+				if hideDeprecateds {
+					continue
+				}
+			}
+			visibles = append(visibles, sel)
+		}
+		return visibles
+	}
+	var visibleValues = func(values []code.ValueResource) []code.ValueResource {
+		if hideDeprecateds {
+			return filterDeprecatedValues(analyzer, values)
+		}
+		return values
+	}
 
 	isBuiltin := pkgPath == "builtin"
 
@@ -464,8 +639,9 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 		info := &pkg.SourceFiles[i]
 		if info.OriginalFile != "" {
 			files = append(files, FileInfo{
-				Filename: info.BareFilename,
-				HasDocs:  info.AstFile != nil && info.AstFile.Doc != nil,
+				Filename:        info.BareFilename,
+				HasDocs:         info.AstFile != nil && info.AstFile.Doc != nil,
+				BuildConstraint: info.BuildConstraint,
 			})
 		}
 
@@ -506,17 +682,17 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 			len(pkg.PackageAnalyzeResult.AllVariables)+
 			len(pkg.PackageAnalyzeResult.AllFunctions))
 	for _, c := range pkg.PackageAnalyzeResult.AllConstants {
-		if c.Exported() {
+		if c.Exported() && !isHiddenDeprecated(c) {
 			valueResources = append(valueResources, c)
 		}
 	}
 	for _, v := range pkg.PackageAnalyzeResult.AllVariables {
-		if v.Exported() {
+		if v.Exported() && !isHiddenDeprecated(v) {
 			valueResources = append(valueResources, v)
 		}
 	}
 	for _, f := range pkg.PackageAnalyzeResult.AllFunctions {
-		if f.Exported() && !f.IsMethod() {
+		if f.Exported() && !f.IsMethod() && !isHiddenDeprecated(f) {
 			valueResources = append(valueResources, f)
 		}
 	}
@@ -543,7 +719,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 	var exportedTypesResources = make([]*ExportedType, 0, len(pkg.PackageAnalyzeResult.AllTypeNames))
 	//var unexportedTypesResources = make([]*code.TypeName, 0, len(pkg.PackageAnalyzeResult.AllTypeNames))
 	for _, tn := range pkg.PackageAnalyzeResult.AllTypeNames {
-		if alsoShowNonExporteds || tn.Exported() {
+		if (alsoShowNonExporteds || tn.Exported()) && !isHiddenDeprecated(tn) {
 			denoting := tn.Denoting()
 			et := &ExportedType{TypeName: tn}
 			exportedTypesResources = append(exportedTypesResources, et)
@@ -554,7 +730,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 			}
 
 			et.Fields = buildTypeFieldList(denoting, alsoShowNonExporteds)
-			et.Methods = buildTypeMethodsList(denoting, alsoShowNonExporteds)
+			et.Methods = visibleMethods(buildTypeMethodsList(denoting, alsoShowNonExporteds))
 			//et.ImplementedBys = make([]*code.TypeInfo, 0, len(denoting.ImplementedBys))
 			et.ImplementedBys = buildTypeImplementedByList(analyzer, denoting, alsoShowNonExporteds, tn)
 			//et.Implements = make([]code.Implementation, 0, len(denoting.Implements))
@@ -627,8 +803,8 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 			//et.AsOutputsOf = append(nil, denoting.AsOutputsOf...)
 
 			//et.Values = buildValueList(denoting.AsTypesOf)
-			et.AsInputsOf = buildValueList(denoting.AsInputsOf)
-			et.AsOutputsOf = buildValueList(denoting.AsOutputsOf)
+			et.AsInputsOf = buildValueList(visibleValues(denoting.AsInputsOf))
+			et.AsOutputsOf = buildValueList(visibleValues(denoting.AsOutputsOf))
 
 			var values []code.ValueResource
 			values = append(values, denoting.AsTypesOf...)
@@ -636,7 +812,7 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 			if t := analyzer.TryRegisteringType(types.NewPointer(denoting.TT), false); t != nil {
 				values = append(values, t.AsTypesOf...)
 			}
-			et.Values = buildValueList(values)
+			et.Values = buildValueList(visibleValues(values))
 		}
 	}
 	for _, et := range exportedTypesResources {
@@ -678,6 +854,8 @@ func buildPackageDetailsData(analyzer *code.CodeAnalyzer, pkgPath string, option
 
 		HasHiddenTypeNames: len(pkg.PackageAnalyzeResult.AllTypeNames) > len(exportedTypesResources),
 
+		NumDeprecateds: numDeprecateds,
+
 		//FileLineNumberOffsets: lineStartOffsets,
 
 		NumDeps:     uint32(len(pkg.Deps)),
@@ -744,6 +922,17 @@ type ValueForListing struct {
 	CommonPath   string
 }
 
+// filterDeprecatedValues returns the values which are not deprecated.
+func filterDeprecatedValues(analyzer *code.CodeAnalyzer, values []code.ValueResource) []code.ValueResource {
+	var kept = make([]code.ValueResource, 0, len(values))
+	for _, v := range values {
+		if analyzer.ResourceDeprecationNote(v) == "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
 func buildValueList(values []code.ValueResource) []ValueForListing {
 	listedValues := make([]ValueForListing, len(values))
 	for i := range listedValues {
@@ -833,6 +1022,11 @@ func (ds *docServer) writeValueForListing(page *htmlPage, v *ValueForListing, pk
 
 	//log.Println("   :", pos)
 
+	deprecationNote := ds.analyzer.ResourceDeprecationNote(v.ValueResource)
+	if deprecationNote != "" {
+		page.WriteString(`<span class="deprecated">`)
+	}
+
 	switch res := v.ValueResource.(type) {
 	default:
 		panic("should not")
@@ -924,6 +1118,16 @@ func (ds *docServer) writeValueForListing(page *htmlPage, v *ValueForListing, pk
 			ds.WriteAstType(page, res.AstFuncType(), res.AstPackage(), pkg, false, nil, forTypeName)
 		}
 	}
+
+	if deprecationNote != "" {
+		page.WriteString(`</span>`)
+		ds.writeDeprecationNote(page, deprecationNote)
+	}
+}
+
+// writeDeprecationNote writes the deprecation note of a deprecated resource.
+func (ds *docServer) writeDeprecationNote(page *htmlPage, note string) {
+	fmt.Fprintf(page, ` <i class="deprecation-note">%s</i>`, html.EscapeString(ds.currentTranslation.Text_DeprecationNote(note)))
 }
 
 type TypeForListing struct {
@@ -1203,6 +1407,10 @@ func (ds *docServer) writeMethodForListing(page *htmlPage, pkg *code.Package, se
 			page.WriteString(" (T) ")
 		}
 	}
+	deprecationNote := ds.analyzer.MethodDeprecationNote(setMethod)
+	if deprecationNote != "" {
+		page.WriteString(`<span class="deprecated">`)
+	}
 	pos := sel.Position()
 	//pos.Line += ds.analyzer.SourceFileLineOffset(pos.Filename)
 	ds.writeSrouceCodeLineLink(page, sel.Pkg(), pos, setMethod.Name, "", false)
@@ -1211,6 +1419,13 @@ func (ds *docServer) writeMethodForListing(page *htmlPage, pkg *code.Package, se
 	} else {
 		ds.WriteAstType(page, setMethod.AstField.Type, setMethod.Pkg, pkg, false, nil, forTypeName)
 	}
+	if deprecationNote != "" {
+		page.WriteString(`</span>`)
+		ds.writeDeprecationNote(page, deprecationNote)
+	}
+	if f := setMethod.Function; f != nil && setMethod.AstFunc != nil && f.AstDecl.Body != nil {
+		ds.writeComplexityBadge(page, &f.Metrics)
+	}
 }
 
 func writeKindText(page *htmlPage, tt types.Type) {
@@ -1269,6 +1484,11 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, res code.Resource, w
 
 	isBuiltin := res.Package().Path() == "builtin"
 
+	deprecationNote := ds.analyzer.ResourceDeprecationNote(res)
+	if deprecationNote != "" {
+		page.WriteString(`<span class="deprecated">`)
+	}
+
 	switch res := res.(type) {
 	default:
 		panic("should not")
@@ -1399,6 +1619,12 @@ func (ds *docServer) writeResourceIndexHTML(page *htmlPage, res code.Resource, w
 			ds.WriteAstType(page, res.AstDecl.Type, res.Pkg, res.Pkg, false, nil, nil)
 			//ds.writeValueTType(page, res.TType(), res.Pkg, false)
 		}
+		ds.writeComplexityBadge(page, &res.Metrics)
+	}
+
+	if deprecationNote != "" {
+		page.WriteString(`</span>`)
+		ds.writeDeprecationNote(page, deprecationNote)
 	}
 
 	if comment := res.Comment(); comment != "" {
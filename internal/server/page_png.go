@@ -38,8 +38,8 @@ func (ds *docServer) pngFile(w http.ResponseWriter, r *http.Request, pngFilename
 		}
 	}()
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	//if ds.phase < Phase_Analyzed {
 	//	pngData = []byte{}
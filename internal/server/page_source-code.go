@@ -6,13 +6,18 @@ import (
 	"errors"
 	"fmt"
 	"go/ast"
+	"go/scanner"
 	"go/token"
 	"go/types"
+	"html"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"path/filepath"
+	"sort"
 	"strconv"
+	"strings"
 
 	"go101.org/gold/code"
 )
@@ -27,8 +32,8 @@ func (ds *docServer) sourceCodePage(w http.ResponseWriter, r *http.Request, pkgP
 
 	//log.Println(pkgPath, bareFilename)
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -51,16 +56,24 @@ func (ds *docServer) sourceCodePage(w http.ResponseWriter, r *http.Request, pkgP
 	//w.Write(ds.sourcePages[srcPath])
 
 	pageKey := sourcePageKey{pkg: pkgPath, src: bareFilename}
-	if ds.sourcePages[pageKey] == nil {
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, err := ds.pages.getPage(pageKey, func() ([]byte, error) {
 		result, err := ds.analyzeSoureCode(pkgPath, bareFilename)
 		if err != nil {
-			w.WriteHeader(http.StatusNotFound)
-			fmt.Fprint(w, "Load file (", bareFilename, ") in ", pkgPath, " error: ", err)
-			return
+			return nil, err
 		}
-		ds.sourcePages[pageKey] = ds.buildSourceCodePage(result)
+		return ds.buildSourceCodePage(result), nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "Load file (", bareFilename, ") in ", pkgPath, " error: ", err)
+		return
 	}
-	w.Write(ds.sourcePages[pageKey])
+	ds.writePageContent(w, r, etag, content)
 }
 
 func (ds *docServer) buildSourceCodePage(result *SourceFileAnalyzeResult) []byte {
@@ -97,17 +110,37 @@ func (ds *docServer) buildSourceCodePage(result *SourceFileAnalyzeResult) []byte
 		}
 	}
 
+	if result.BuildConstraint != "" {
+		fmt.Fprintf(page, `
+
+<span class="title">%s</span>
+	<span class="comment">%s</span>`,
+			ds.currentTranslation.Text_BuildConstraint(),
+			html.EscapeString(result.BuildConstraint),
+		)
+	}
+
 	fmt.Fprintf(page, `
 
 <span class="title">%s</span>
-	<a href="%s">%s</a>
-</code></pre>
+	<a href="%s">%s</a>%s
 `,
 		ds.currentTranslation.Text_BelongingPackage(),
 		buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, result.PkgPath}, nil, ""),
 		result.PkgPath,
+		moduleVersionLabel(result.Mod),
 	)
 
+	if result.IgnoredReason != "" {
+		fmt.Fprintf(page, `
+<i>%s</i>
+`,
+			ds.currentTranslation.Text_IgnoredFileNote(result.IgnoredReason, html.EscapeString(ds.parseOptions.BuildConfiguration())),
+		)
+	}
+
+	page.WriteString("</code></pre>\n")
+
 	if result.NumRatios > 0 {
 		page.WriteString("<style>")
 		page.WriteString("input[type=radio] {display: none;}\n")
@@ -130,6 +163,7 @@ func (ds *docServer) buildSourceCodePage(result *SourceFileAnalyzeResult) []byte
 <pre class="line-numbers">`)
 
 	var outputNewLine = true
+	var unreachableLines = result.UnreachableLines
 	for i, line := range result.Lines {
 		//		fmt.Fprintf(page, `
 		//<span class="anchor" id="line-%d"><code>%s</code></span>`,
@@ -141,7 +175,14 @@ func (ds *docServer) buildSourceCodePage(result *SourceFileAnalyzeResult) []byte
 		if lineNumber == result.DocStartLine {
 			page.WriteString(`<div class="anchor" id="doc">`)
 		}
-		fmt.Fprintf(page, `<span class="codeline" id="line-%d"><code>%s</code></span>`, lineNumber, line)
+		for len(unreachableLines) > 0 && unreachableLines[0][1] < lineNumber {
+			unreachableLines = unreachableLines[1:]
+		}
+		if len(unreachableLines) > 0 && unreachableLines[0][0] <= lineNumber {
+			fmt.Fprintf(page, `<span class="codeline unreachable" id="line-%d" title="%s"><code>%s</code></span>`, lineNumber, ds.currentTranslation.Text_UnreachableDeclaration(), line)
+		} else {
+			fmt.Fprintf(page, `<span class="codeline" id="line-%d"><code>%s</code></span>`, lineNumber, line)
+		}
 		if lineNumber == result.DocEndLine {
 			page.WriteString(`</div>`)
 			outputNewLine = false
@@ -153,11 +194,12 @@ func (ds *docServer) buildSourceCodePage(result *SourceFileAnalyzeResult) []byte
 	page.WriteString(`
 </pre>`)
 
-	return page.Done(ds.currentTranslation)
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
 }
 
 type SourceFileAnalyzeResult struct {
 	PkgPath       string
+	Mod           *code.Module
 	BareFilename  string
 	OriginalPath  string
 	GeneratedPath string
@@ -165,6 +207,12 @@ type SourceFileAnalyzeResult struct {
 	NumRatios     int32
 	DocStartLine  int
 	DocEndLine    int
+
+	// Line ranges ([start, end]) of unreachable function declarations.
+	UnreachableLines [][2]int
+
+	BuildConstraint string
+	IgnoredReason   string // non-blank for files excluded by the build configuration
 }
 
 var (
@@ -442,6 +490,7 @@ type astVisitor struct {
 	fset         *token.FileSet
 	file         *token.File
 	info         *types.Info
+	sizes        types.Sizes // of the target architecture
 	content      []byte
 
 	// ToDo: Some Go files might contains line-repositions.
@@ -468,6 +517,15 @@ type astVisitor struct {
 
 	sameFileObjects map[types.Object]int32
 
+	cgoPreambles []*ast.CommentGroup
+
+	// For linking built-in operations to their runtime implementations.
+	builtinCallFuncs  map[*ast.Ident][]string
+	mapIndexModes     map[*ast.IndexExpr]string // "assign" or "access2"
+	concatenatedExprs map[*ast.BinaryExpr]bool
+	commaOkAssertions map[*ast.TypeAssertExpr]bool
+	returnResults     map[*ast.ReturnStmt]*types.Tuple
+
 	astNodeDepth int32
 
 	topLevelFuncNodeDepth int32
@@ -504,6 +562,22 @@ func (kw *KeywordToken) End() token.Pos {
 	return kw.pos + token.Pos(len(kw.keyword))
 }
 
+// A RuntimeOperation is a token which represents an operation
+// implemented by a runtime function.
+type RuntimeOperation struct {
+	token string
+	pos   token.Pos
+	funcs []string // candidates, the first implemented one is used
+}
+
+func (ro *RuntimeOperation) Pos() token.Pos {
+	return ro.pos
+}
+
+func (ro *RuntimeOperation) End() token.Pos {
+	return ro.pos + token.Pos(len(ro.token))
+}
+
 type ChanCommOprator struct {
 	send  bool
 	hasOK bool
@@ -546,7 +620,11 @@ func (v *astVisitor) tryToHandleSomeSpecialNodes(beforeNode ast.Node) {
 		default:
 			panic("should not")
 		case *ast.CommentGroup:
-			v.handleNode(node, "comment")
+			if v.isCgoPreamble(node) {
+				v.handleCgoPreamble(node)
+			} else {
+				v.handleNode(node, "comment")
+			}
 		case *KeywordToken:
 			v.handleKeywordToken(node.pos, node.keyword)
 		case *ChanCommOprator:
@@ -564,6 +642,10 @@ func (v *astVisitor) tryToHandleSomeSpecialNodes(beforeNode ast.Node) {
 				end := v.pkg.PPkg.Fset.PositionFor(node.End(), false)
 				v.buildText(start, end, "", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
 			}
+		case *RuntimeOperation:
+			if link := v.runtimeFunctionLink(node.funcs...); link != "" {
+				v.handleToken(node.pos, node.token, "", link)
+			}
 		}
 
 		// This line will clear the the prev and next elements of e.
@@ -1085,7 +1167,7 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 	case *ast.BranchStmt:
 		v.handleKeyword(node.TokPos, node.Tok)
 	case *ast.ReturnStmt:
-		v.handleKeyword(node.Return, token.RETURN)
+		v.handleToken(node.Return, token.RETURN.String(), "keyword", v.runtimeFunctionLink(v.returnConversionFuncs(node)...))
 	case *ast.IfStmt:
 		v.handleKeyword(node.If, token.IF)
 		if node.Else != nil {
@@ -1099,11 +1181,20 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		//v.pendingTokenPoses = append(v.pendingTokenPoses, v.findRangeToken(node))
 		v.addSpecialNode(v.findRangeToken(node))
 	case *ast.DeferStmt:
-		v.handleKeyword(node.Defer, token.DEFER)
+		v.handleToken(node.Defer, token.DEFER.String(), "keyword", v.runtimeFunctionLink("deferprocStack", "deferproc"))
 	case *ast.GoStmt:
-		v.handleKeyword(node.Go, token.GO)
+		v.handleToken(node.Go, token.GO.String(), "keyword", v.runtimeFunctionLink("newproc"))
 	case *ast.FuncDecl:
 		v.handleKeyword(node.Type.Func, token.FUNC)
+		if fn, ok := v.info.Defs[node.Name].(*types.Func); ok {
+			v.recordReturnResults(node.Body, fn.Type().(*types.Signature))
+		}
+	case *ast.FuncLit:
+		if sig, ok := v.info.TypeOf(node).(*types.Signature); ok {
+			v.recordReturnResults(node.Body, sig)
+		}
+	case *ast.ValueSpec:
+		v.handleValueSpec(node)
 	case *ast.GenDecl:
 		v.handleKeyword(node.TokPos, node.Tok)
 	case *ast.InterfaceType:
@@ -1127,6 +1218,22 @@ func (v *astVisitor) Visit(n ast.Node) (w ast.Visitor) {
 		if node.Name == nil {
 		}
 
+	// built-in operations
+	case *ast.CallExpr:
+		v.handleCallExpr(node)
+	case *ast.AssignStmt:
+		v.handleAssignStmt(node)
+	case *ast.IncDecStmt:
+		if indexExpr, ok := astParenExprRemoved(node.X).(*ast.IndexExpr); ok {
+			v.setMapIndexMode(indexExpr, "assign")
+		}
+	case *ast.IndexExpr:
+		v.handleIndexExpr(node)
+	case *ast.BinaryExpr:
+		v.handleStringConcatenation(node)
+	case *ast.TypeAssertExpr:
+		v.handleTypeAssertion(node, v.commaOkAssertions[node])
+
 	//...
 	case *ast.BasicLit:
 		v.handleBasicLit(node)
@@ -1148,6 +1255,48 @@ func (v *astVisitor) handleNode(node ast.Node, class string) {
 	v.buildText(start, end, class, "")
 }
 
+// bodylessFunctionAsmImplementation returns the assembly implementation
+// of the current top-level function if the function has no body.
+func (v *astVisitor) bodylessFunctionAsmImplementation() *code.AsmFunction {
+	if fd, ok := v.topLevelFuncInfo.Node.(*ast.FuncDecl); ok && fd.Body == nil && fd.Recv == nil {
+		return v.pkg.AsmFunctionByName(fd.Name.Name)
+	}
+	return nil
+}
+
+func (v *astVisitor) isCgoPreamble(cg *ast.CommentGroup) bool {
+	for _, preamble := range v.cgoPreambles {
+		if preamble == cg {
+			return true
+		}
+	}
+	return false
+}
+
+// handleCgoPreamble highlights the C code in a cgo preamble.
+func (v *astVisitor) handleCgoPreamble(cg *ast.CommentGroup) {
+	preamble, markers := code.CgoPreambleContent(v.content, v.file, cg)
+	segments := make([]highlightedSegment, 0, len(markers)/2+64)
+	for i := 0; i+1 < len(markers); i += 2 {
+		segments = append(segments, highlightedSegment{markers[i], markers[i+1], "comment", ""})
+	}
+	for _, t := range code.ScanCTokens(preamble) {
+		if class := cTokenClass(t.Kind); class != "" {
+			segments = append(segments, highlightedSegment{t.Start, t.End, class, ""})
+		}
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].start < segments[j].start
+	})
+
+	base := v.file.Offset(cg.Pos())
+	for _, seg := range segments {
+		start := v.fset.PositionFor(v.file.Pos(base+seg.start), false)
+		end := v.fset.PositionFor(v.file.Pos(base+seg.end), false)
+		v.buildText(start, end, seg.class, "")
+	}
+}
+
 func (v *astVisitor) handleBasicLit(basicLit *ast.BasicLit) {
 	class := "lit-number"
 	if basicLit.Kind == token.STRING {
@@ -1157,6 +1306,546 @@ func (v *astVisitor) handleBasicLit(basicLit *ast.BasicLit) {
 	v.handleNode(basicLit, class)
 }
 
+// runtimeFunctionLink returns the link to the first runtime function
+// which is implemented in the current Go version.
+func (v *astVisitor) runtimeFunctionLink(funcs ...string) string {
+	for _, f := range funcs {
+		if fPosition := v.dataAnalyzer.RuntimeFunctionCodePosition(f); fPosition.IsValid() {
+			return buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition)
+		}
+	}
+	return ""
+}
+
+func (v *astVisitor) addRuntimeOperation(tok string, pos token.Pos, funcs ...string) {
+	if pos.IsValid() && len(funcs) > 0 {
+		v.addSpecialNode(&RuntimeOperation{token: tok, pos: pos, funcs: funcs})
+	}
+}
+
+func astParenExprRemoved(e ast.Expr) ast.Expr {
+	for {
+		p, ok := e.(*ast.ParenExpr)
+		if !ok {
+			return e
+		}
+		e = p.X
+	}
+}
+
+func (v *astVisitor) setMapIndexMode(e *ast.IndexExpr, mode string) {
+	if v.mapIndexModes == nil {
+		v.mapIndexModes = make(map[*ast.IndexExpr]string)
+	}
+	v.mapIndexModes[e] = mode
+}
+
+func (v *astVisitor) underlyingTypeOf(e ast.Expr) types.Type {
+	if t := v.info.TypeOf(e); t != nil {
+		return t.Underlying()
+	}
+	return nil
+}
+
+func (v *astVisitor) isConstant(e ast.Expr) bool {
+	tv, ok := v.info.Types[e]
+	return ok && tv.Value != nil
+}
+
+// handleCallExpr handles calls to built-in functions, explicit
+// conversions from non-interface values to interfaces, and implicit
+// conversions of arguments to interface parameter types.
+func (v *astVisitor) handleCallExpr(call *ast.CallExpr) {
+	if tv, ok := v.info.Types[call.Fun]; ok && tv.IsType() {
+		if len(call.Args) == 1 {
+			v.addRuntimeOperation("(", call.Lparen, v.interfaceConversionFuncs(call.Args[0], tv.Type)...)
+		}
+		return
+	}
+
+	v.handleArgumentConversions(call)
+
+	ident, ok := astParenExprRemoved(call.Fun).(*ast.Ident)
+	if !ok {
+		return
+	}
+	if _, ok := v.info.Uses[ident].(*types.Builtin); !ok {
+		return
+	}
+
+	var funcs []string
+	switch ident.Name {
+	case "make":
+		if len(call.Args) == 0 {
+			return
+		}
+		switch v.underlyingTypeOf(call.Args[0]).(type) {
+		case *types.Map:
+			if len(call.Args) == 1 {
+				funcs = []string{"makemap_small", "makemap"}
+			} else {
+				funcs = []string{"makemap"}
+			}
+		case *types.Chan:
+			funcs = []string{"makechan"}
+		case *types.Slice:
+			funcs = []string{"makeslice"}
+		}
+	case "new":
+		funcs = []string{"newobject"}
+	case "append":
+		funcs = []string{"growslice"}
+	case "copy":
+		if len(call.Args) != 2 {
+			return
+		}
+		if basic, ok := v.underlyingTypeOf(call.Args[1]).(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+			funcs = []string{"slicestringcopy", "slicecopy"}
+		} else if slice, ok := v.underlyingTypeOf(call.Args[0]).(*types.Slice); ok && typeHasPointers(slice.Elem()) {
+			funcs = []string{"typedslicecopy"}
+		} else {
+			funcs = []string{"slicecopy"}
+		}
+	case "delete":
+		if len(call.Args) != 2 {
+			return
+		}
+		if m, ok := v.underlyingTypeOf(call.Args[0]).(*types.Map); ok {
+			funcs = mapRuntimeFunctions("mapdelete", m.Key(), false, v.sizes)
+		}
+	case "clear":
+		if len(call.Args) != 1 {
+			return
+		}
+		switch t := v.underlyingTypeOf(call.Args[0]).(type) {
+		case *types.Map:
+			funcs = []string{"mapclear"}
+		case *types.Slice:
+			if typeHasPointers(t.Elem()) {
+				funcs = []string{"memclrHasPointers"}
+			} else {
+				funcs = []string{"memclrNoHeapPointers"}
+			}
+		}
+	case "close":
+		funcs = []string{"closechan"}
+	case "panic":
+		funcs = []string{"gopanic"}
+	case "recover":
+		funcs = []string{"gorecover"}
+	}
+
+	if len(funcs) > 0 {
+		if v.builtinCallFuncs == nil {
+			v.builtinCallFuncs = make(map[*ast.Ident][]string)
+		}
+		v.builtinCallFuncs[ident] = funcs
+	}
+}
+
+// handleArgumentConversions handles the implicit conversions of the
+// arguments of a call to interface parameter types. Only the first
+// such conversion is linked, at the "(" token of the call.
+func (v *astVisitor) handleArgumentConversions(call *ast.CallExpr) {
+	sig, ok := v.underlyingTypeOf(call.Fun).(*types.Signature)
+	if !ok {
+		return
+	}
+	params := sig.Params()
+	for i, arg := range call.Args {
+		var param types.Type
+		if sig.Variadic() && i >= params.Len()-1 {
+			if call.Ellipsis.IsValid() {
+				return
+			}
+			slice, ok := params.At(params.Len() - 1).Type().Underlying().(*types.Slice)
+			if !ok {
+				return
+			}
+			param = slice.Elem()
+		} else if i < params.Len() {
+			param = params.At(i).Type()
+		} else {
+			return
+		}
+		if funcs := v.interfaceConversionFuncs(arg, param); len(funcs) > 0 {
+			v.addRuntimeOperation("(", call.Lparen, funcs...)
+			return
+		}
+	}
+}
+
+// handleValueSpec handles the implicit conversions in
+// variable declarations like "var x any = y".
+func (v *astVisitor) handleValueSpec(spec *ast.ValueSpec) {
+	if spec.Type == nil || len(spec.Values) != len(spec.Names) {
+		return
+	}
+	to := v.info.TypeOf(spec.Type)
+	for _, value := range spec.Values {
+		if funcs := v.interfaceConversionFuncs(value, to); len(funcs) > 0 {
+			assign := v.findTokenBetween(spec.Type.End(), spec.Values[0].Pos(), token.ASSIGN.String(), true)
+			v.addRuntimeOperation(token.ASSIGN.String(), assign.pos, funcs...)
+			return
+		}
+	}
+}
+
+// recordReturnResults records the result types of the return statements
+// in a function body, for handling the implicit conversions in them.
+// The return statements in nested function literals are not recorded.
+func (v *astVisitor) recordReturnResults(body *ast.BlockStmt, sig *types.Signature) {
+	if body == nil || sig == nil || sig.Results().Len() == 0 {
+		return
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if v.returnResults == nil {
+				v.returnResults = make(map[*ast.ReturnStmt]*types.Tuple)
+			}
+			v.returnResults[n] = sig.Results()
+		}
+		return true
+	})
+}
+
+// returnConversionFuncs returns the runtime functions of the first implicit
+// conversion to an interface result type in a return statement.
+func (v *astVisitor) returnConversionFuncs(ret *ast.ReturnStmt) []string {
+	results := v.returnResults[ret]
+	if results == nil || len(ret.Results) != results.Len() {
+		return nil
+	}
+	for i, r := range ret.Results {
+		if funcs := v.interfaceConversionFuncs(r, results.At(i).Type()); len(funcs) > 0 {
+			return funcs
+		}
+	}
+	return nil
+}
+
+// interfaceConversionFuncs returns the candidate runtime functions called
+// to convert the value of an expression to a type. Nil is returned if the
+// type is not an interface type, or no runtime functions are called for
+// the conversion, for example, when the value is already an interface,
+// a constant, or of a type parameter type.
+func (v *astVisitor) interfaceConversionFuncs(x ast.Expr, to types.Type) []string {
+	if to == nil {
+		return nil
+	}
+	itype, ok := to.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+	tv, ok := v.info.Types[x]
+	if !ok || tv.Type == nil || tv.Value != nil || tv.IsNil() || types.IsInterface(tv.Type) {
+		return nil
+	}
+	if _, ok := tv.Type.(*types.Tuple); ok || containsTypeParams(tv.Type) {
+		return nil
+	}
+	return convTFunctions(tv.Type, itype.NumMethods() == 0, v.sizes)
+}
+
+// targetSizes returns the sizes of types on the target architecture
+// of the analyzed packages, as the gc compiler lays them out.
+func (ds *docServer) targetSizes() types.Sizes {
+	_, goarch := ds.parseOptions.Target()
+	if sizes := types.SizesFor("gc", goarch); sizes != nil {
+		return sizes
+	}
+	return types.SizesFor("gc", "amd64")
+}
+
+// convTFunctions returns the candidate runtime functions called to
+// convert a non-interface value to an interface, by the size, alignment
+// and kind of its type, as the gc compiler does. Nil is returned if the
+// value is stored in the interface directly, or a preallocated value
+// is used (for zero-size, boolean and single-byte integer values).
+func convTFunctions(from types.Type, toEmpty bool, sizes types.Sizes) []string {
+	if isPointerShaped(from) {
+		return nil
+	}
+	size := sizes.Sizeof(from)
+	if size == 0 {
+		return nil
+	}
+	if basic, ok := from.Underlying().(*types.Basic); ok {
+		if basic.Info()&types.IsBoolean != 0 || size == 1 && basic.Info()&types.IsInteger != 0 {
+			return nil
+		}
+	}
+
+	var funcs []string
+	hasPointers := typeHasPointers(from)
+	switch align := sizes.Alignof(from); {
+	case size == 2 && align == 2:
+		funcs = []string{"convT16"}
+	case size == 4 && align == 4 && !hasPointers:
+		funcs = []string{"convT32"}
+	case size == 8 && align == sizes.Alignof(types.Typ[types.Uint64]) && !hasPointers:
+		funcs = []string{"convT64"}
+	default:
+		switch t := soleComponent(from).Underlying().(type) {
+		case *types.Basic:
+			if t.Info()&types.IsString != 0 {
+				funcs = []string{"convTstring"}
+			}
+		case *types.Slice:
+			funcs = []string{"convTslice"}
+		}
+	}
+
+	// The later ones are for old runtime versions.
+	suffix := "I"
+	if toEmpty {
+		suffix = "E"
+	}
+	if hasPointers {
+		funcs = append(funcs, "convT", "convT2"+suffix)
+	} else {
+		funcs = append(funcs, "convTnoptr", "convT2"+suffix+"noptr")
+	}
+	return funcs
+}
+
+// isPointerShaped reports whether or not the values of a type
+// are stored in interfaces directly.
+func isPointerShaped(t types.Type) bool {
+	switch t := t.Underlying().(type) {
+	case *types.Pointer, *types.Map, *types.Chan, *types.Signature:
+		return true
+	case *types.Basic:
+		return t.Kind() == types.UnsafePointer
+	case *types.Array:
+		return t.Len() == 1 && isPointerShaped(t.Elem())
+	case *types.Struct:
+		return t.NumFields() == 1 && isPointerShaped(t.Field(0).Type())
+	}
+	return false
+}
+
+// soleComponent returns the only non-struct non-array component
+// of a type, or the type itself if there is no such component.
+func soleComponent(t types.Type) types.Type {
+	for {
+		switch u := t.Underlying().(type) {
+		case *types.Struct:
+			if u.NumFields() != 1 {
+				return t
+			}
+			t = u.Field(0).Type()
+		case *types.Array:
+			if u.Len() != 1 {
+				return t
+			}
+			t = u.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+// containsTypeParams reports whether or not the size of a type
+// depends on type parameters.
+func containsTypeParams(t types.Type) bool {
+	switch t := t.Underlying().(type) {
+	case *types.TypeParam:
+		return true
+	case *types.Array:
+		return containsTypeParams(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if containsTypeParams(t.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleTypeAssertion handles type assertions to interface types.
+func (v *astVisitor) handleTypeAssertion(e *ast.TypeAssertExpr, commaOk bool) {
+	if e.Type == nil { // x.(type) in type switches
+		return
+	}
+	to := v.info.TypeOf(e.Type)
+	if to == nil || !types.IsInterface(to) {
+		return
+	}
+	from := v.underlyingTypeOf(e.X)
+	fromInterface, ok := from.(*types.Interface)
+	if !ok {
+		return
+	}
+
+	f := "assertI2I"
+	if fromInterface.NumMethods() == 0 {
+		f = "assertE2I"
+	}
+	if commaOk {
+		f += "2"
+	}
+	v.addRuntimeOperation("(", e.Lparen, f)
+}
+
+func (v *astVisitor) handleAssignStmt(assign *ast.AssignStmt) {
+	if assign.Tok == token.ADD_ASSIGN && len(assign.Lhs) == 1 && len(assign.Rhs) == 1 {
+		if basic, ok := v.underlyingTypeOf(assign.Lhs[0]).(*types.Basic); ok && basic.Info()&types.IsString != 0 {
+			v.addRuntimeOperation(token.ADD_ASSIGN.String(), assign.TokPos, "concatstring2", "concatstrings")
+		}
+	}
+
+	for _, lhs := range assign.Lhs {
+		if indexExpr, ok := astParenExprRemoved(lhs).(*ast.IndexExpr); ok {
+			v.setMapIndexMode(indexExpr, "assign")
+		}
+	}
+
+	// Implicit conversions to interface types.
+	if assign.Tok == token.ASSIGN && len(assign.Lhs) == len(assign.Rhs) {
+		for i, lhs := range assign.Lhs {
+			if funcs := v.interfaceConversionFuncs(assign.Rhs[i], v.info.TypeOf(lhs)); len(funcs) > 0 {
+				v.addRuntimeOperation(token.ASSIGN.String(), assign.TokPos, funcs...)
+				break
+			}
+		}
+	}
+
+	// v, ok = m[k] and v, ok = x.(I)
+	if len(assign.Lhs) == 2 && len(assign.Rhs) == 1 {
+		switch e := astParenExprRemoved(assign.Rhs[0]).(type) {
+		case *ast.IndexExpr:
+			v.setMapIndexMode(e, "access2")
+		case *ast.TypeAssertExpr:
+			if v.commaOkAssertions == nil {
+				v.commaOkAssertions = make(map[*ast.TypeAssertExpr]bool)
+			}
+			v.commaOkAssertions[e] = true
+		}
+	}
+}
+
+func (v *astVisitor) handleIndexExpr(e *ast.IndexExpr) {
+	m, ok := v.underlyingTypeOf(e.X).(*types.Map)
+	if !ok {
+		return
+	}
+	var funcs []string
+	switch v.mapIndexModes[e] {
+	case "assign":
+		funcs = mapRuntimeFunctions("mapassign", m.Key(), true, v.sizes)
+	case "access2":
+		funcs = mapRuntimeFunctions("mapaccess2", m.Key(), false, v.sizes)
+	default:
+		funcs = mapRuntimeFunctions("mapaccess1", m.Key(), false, v.sizes)
+	}
+	v.addRuntimeOperation("[", e.Lbrack, funcs...)
+}
+
+// handleStringConcatenation handles non-constant string concatenations.
+// The operands in a concatenation chain are concatenated by one call.
+func (v *astVisitor) handleStringConcatenation(e *ast.BinaryExpr) {
+	if e.Op != token.ADD || v.concatenatedExprs[e] || v.isConstant(e) {
+		return
+	}
+	if basic, ok := v.underlyingTypeOf(e).(*types.Basic); !ok || basic.Info()&types.IsString == 0 {
+		return
+	}
+
+	var opPoses []token.Pos
+	var numOperands = 0
+	var collect func(x ast.Expr)
+	collect = func(x ast.Expr) {
+		if b, ok := x.(*ast.BinaryExpr); ok && b.Op == token.ADD && !v.isConstant(b) {
+			if v.concatenatedExprs == nil {
+				v.concatenatedExprs = make(map[*ast.BinaryExpr]bool)
+			}
+			v.concatenatedExprs[b] = true
+			collect(b.X)
+			opPoses = append(opPoses, b.OpPos)
+			collect(b.Y)
+			return
+		}
+		numOperands++
+	}
+	collect(e)
+
+	var funcs []string
+	if numOperands <= 5 {
+		funcs = append(funcs, fmt.Sprintf("concatstring%d", numOperands))
+	}
+	funcs = append(funcs, "concatstrings")
+	for _, pos := range opPoses {
+		v.addRuntimeOperation(token.ADD.String(), pos, funcs...)
+	}
+}
+
+// mapRuntimeFunctions returns the candidate runtime functions of a map
+// operation, including the ones specialized for some key types. As the
+// gc compiler does, the specialized ones for memory-comparable keys are
+// picked by the key sizes on the target architecture, and the ones for
+// pointer keys are only used in assignments.
+func mapRuntimeFunctions(op string, key types.Type, forAssign bool, sizes types.Sizes) []string {
+	var suffix string
+	switch t := key.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsString != 0:
+			suffix = "_faststr"
+		case t.Info()&types.IsInteger != 0:
+			suffix = fastMapFunctionSuffix(sizes.Sizeof(t), false, forAssign)
+		case t.Kind() == types.UnsafePointer:
+			suffix = fastMapFunctionSuffix(sizes.Sizeof(t), true, forAssign)
+		}
+	case *types.Pointer, *types.Chan:
+		suffix = fastMapFunctionSuffix(sizes.Sizeof(t), true, forAssign)
+	}
+	if suffix == "" {
+		return []string{op}
+	}
+	return []string{op + suffix, op}
+}
+
+// fastMapFunctionSuffix returns the suffix of the specialized map runtime
+// functions for the keys with the specified size, or blank if there are none.
+func fastMapFunctionSuffix(size int64, hasPointers, forAssign bool) string {
+	var suffix string
+	switch size {
+	case 4:
+		suffix = "_fast32"
+	case 8:
+		suffix = "_fast64"
+	default:
+		return ""
+	}
+	if hasPointers && forAssign {
+		suffix += "ptr"
+	}
+	return suffix
+}
+
+// typeHasPointers reports whether or not the values of a type contain pointers.
+func typeHasPointers(t types.Type) bool {
+	switch t := t.Underlying().(type) {
+	case *types.Basic:
+		return t.Info()&types.IsString != 0 || t.Kind() == types.UnsafePointer
+	case *types.Array:
+		return t.Len() > 0 && typeHasPointers(t.Elem())
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if typeHasPointers(t.Field(i).Type()) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
 func (v *astVisitor) handleSelectKeyword(selectPos token.Pos, fPosition token.Position) {
 	v.handleToken(selectPos, token.SELECT.String(), "keyword", buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.dataAnalyzer.RuntimePackage(), fPosition))
 }
@@ -1189,6 +1878,14 @@ func (v *astVisitor) handleIdent(ident *ast.Ident) {
 		panic(fmt.Sprintf("start.Line != end.Line. %d : %d", start.Line, end.Line))
 	}
 
+	// C.xxx references have been rewritten by cgo as _Cfunc_xxx etc.
+	if name, _ := code.CgoSymbolOfIdent(ident.Name); name != "" {
+		if decl := v.pkg.CgoDeclarationByName(name); decl != nil {
+			v.buildIdentifier(start, end, -1, buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.pkg, decl.Position))
+			return
+		}
+	}
+
 	var obj types.Object
 	if use, ok := v.info.Uses[ident]; ok {
 		obj = use
@@ -1214,9 +1911,13 @@ func (v *astVisitor) handleIdent(ident *ast.Ident) {
 		if obj.Parent() == types.Universe {
 			//log.Println(fmt.Sprintf("ppkg for identifier %s (%v) is not found", ident.Name, obj))
 			//v.buildIdentifier(start, end, -1, "/pkg:builtin#name-"+obj.Name())
+			if funcs := v.builtinCallFuncs[ident]; len(funcs) > 0 {
+				if link := v.runtimeFunctionLink(funcs...); link != "" {
+					v.buildIdentifier(start, end, -1, link)
+					return
+				}
+			}
 			v.buildIdentifier(start, end, -1, buildPageHref(v.currentPathInfo, pagePathInfo{ResTypePackage, "builtin"}, nil, "")+"#name-"+obj.Name())
-
-			// ToDo: link to runtime.panic/recover/...
 			return
 		}
 
@@ -1283,6 +1984,8 @@ func (v *astVisitor) handleIdent(ident *ast.Ident) {
 					}
 					link = buildPageHref(v.currentPathInfo, pagePathInfo{ResTypeImplementation, v.pkg.Path() + "." + v.topLevelFuncInfo.RecvTypeName}, nil, "") + "#name-" + anchorName
 				}
+			} else if asm := v.bodylessFunctionAsmImplementation(); asm != nil {
+				link = buildSrouceCodeLineLink(v.currentPathInfo, v.dataAnalyzer, v.pkg, asm.Position)
 			} else if token.IsExported(funcName) {
 				link = buildPageHref(v.currentPathInfo, pagePathInfo{ResTypePackage, v.pkg.Path()}, nil, "") + "#name-" + funcName
 			} // else if !genDocsMode {
@@ -1530,6 +2233,9 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 
 	var fileInfo = pkg.SourceFileInfoByBareFilename(bareFilename)
 	if fileInfo == nil {
+		if ignored := pkg.IgnoredFileInfoByBareFilename(bareFilename); ignored != nil {
+			return ds.analyzeIgnoredSourceCode(pkg, ignored)
+		}
 		return nil, errors.New("file not found")
 	}
 
@@ -1557,34 +2263,14 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 	if fileInfo.AstFile == nil {
 		//log.Println("fileInfo == nil")
 
-		lineCount, _ := BuildLineOffsets(content, true)
-
 		result = &SourceFileAnalyzeResult{
-			PkgPath:       pkg.Path(),
-			BareFilename:  bareFilename,
-			OriginalPath:  fileInfo.OriginalFile,
-			GeneratedPath: generatedFilePath,
-			Lines:         make([]string, 0, lineCount),
-		}
-		var buf bytes.Buffer
-		buf.Grow(1024)
-		for data := content; len(data) > 0; {
-			i := bytes.IndexByte(data, '\n')
-			k := i
-			if k < 0 {
-				k = len(data)
-			}
-			if k > 0 && data[k-1] == '\r' {
-				k--
-			}
-			WriteHtmlEscapedBytes(&buf, data[:k])
-			result.Lines = append(result.Lines, buf.String())
-			buf.Reset()
-
-			if i < 0 {
-				break
-			}
-			data = data[i+1:]
+			PkgPath:         pkg.Path(),
+			Mod:             pkg.Mod,
+			BareFilename:    bareFilename,
+			OriginalPath:    fileInfo.OriginalFile,
+			GeneratedPath:   generatedFilePath,
+			BuildConstraint: fileInfo.BuildConstraint,
+			Lines:           ds.buildNonGoSourceLines(pkg, bareFilename, content),
 		}
 	} else {
 
@@ -1621,6 +2307,7 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			fset:         pkg.PPkg.Fset,
 			file:         file,
 			info:         pkg.PPkg.TypesInfo,
+			sizes:        ds.targetSizes(),
 			content:      content,
 
 			goFilePath: filePath,
@@ -1628,13 +2315,15 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			//goFileLineOffset:    fileInfo.GoFileLineOffset,
 
 			result: &SourceFileAnalyzeResult{
-				PkgPath:       pkg.Path(),
-				BareFilename:  bareFilename,
-				OriginalPath:  fileInfo.OriginalFile,
-				GeneratedPath: generatedFilePath,
-				Lines:         make([]string, 0, file.LineCount()),
-				DocStartLine:  docStartLine,
-				DocEndLine:    docEndLine,
+				PkgPath:         pkg.Path(),
+				Mod:             pkg.Mod,
+				BareFilename:    bareFilename,
+				OriginalPath:    fileInfo.OriginalFile,
+				GeneratedPath:   generatedFilePath,
+				BuildConstraint: fileInfo.BuildConstraint,
+				Lines:           make([]string, 0, file.LineCount()),
+				DocStartLine:    docStartLine,
+				DocEndLine:      docEndLine,
 			},
 
 			lineNumber: 1,
@@ -1648,6 +2337,8 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 			//pendingTokenPoses: make([]TokenPos, 0, 10),
 
 			sameFileObjects: make(map[types.Object]int32, 256),
+
+			cgoPreambles: fileInfo.CgoPreambles,
 		}
 		av.lineBuilder.Grow(1024)
 
@@ -1662,7 +2353,310 @@ func (ds *docServer) analyzeSoureCode(pkgPath, bareFilename string) (*SourceFile
 		}
 
 		result = av.result
+		result.UnreachableLines = unreachableFunctionLines(pkg, file)
 	}
 
 	return result, nil
 }
+
+// unreachableFunctionLines returns the line ranges (including doc comments)
+// of the unreachable functions declared in the specified file.
+func unreachableFunctionLines(pkg *code.Package, file *token.File) [][2]int {
+	var ranges [][2]int
+	for _, f := range pkg.AllFunctions {
+		if !f.Unreachable {
+			continue
+		}
+		start, end := f.AstDecl.Pos(), f.AstDecl.End()
+		if f.AstDecl.Doc != nil {
+			start = f.AstDecl.Doc.Pos()
+		}
+		if int(start) < file.Base() || int(start) > file.Base()+file.Size() {
+			continue
+		}
+		ranges = append(ranges, [2]int{file.Line(start), file.Line(end)})
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i][0] < ranges[j][0]
+	})
+	return ranges
+}
+
+// analyzeIgnoredSourceCode builds the result for a source file which is
+// excluded by the build configuration. Such files are not type checked,
+// so Go files are only highlighted lexically.
+func (ds *docServer) analyzeIgnoredSourceCode(pkg *code.Package, info *code.IgnoredFileInfo) (*SourceFileAnalyzeResult, error) {
+	content, err := ioutil.ReadFile(info.File)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SourceFileAnalyzeResult{
+		PkgPath:         pkg.Path(),
+		Mod:             pkg.Mod,
+		BareFilename:    info.BareFilename,
+		OriginalPath:    info.File,
+		BuildConstraint: info.BuildConstraint,
+		IgnoredReason:   info.Reason,
+	}
+	if strings.HasSuffix(info.BareFilename, ".go") {
+		result.Lines = buildLexicallyHighlightedGoLines(content)
+	} else {
+		result.Lines = ds.buildNonGoSourceLines(pkg, info.BareFilename, content)
+	}
+	return result, nil
+}
+
+func buildPlainSourceLines(content []byte) []string {
+	lineCount, _ := BuildLineOffsets(content, true)
+	lines := make([]string, 0, lineCount)
+
+	var buf bytes.Buffer
+	buf.Grow(1024)
+	for data := content; len(data) > 0; {
+		i := bytes.IndexByte(data, '\n')
+		k := i
+		if k < 0 {
+			k = len(data)
+		}
+		if k > 0 && data[k-1] == '\r' {
+			k--
+		}
+		WriteHtmlEscapedBytes(&buf, data[:k])
+		lines = append(lines, buf.String())
+		buf.Reset()
+
+		if i < 0 {
+			break
+		}
+		data = data[i+1:]
+	}
+	return lines
+}
+
+// A highlightedSegment is a range of source code rendered with a css class.
+// A segment with a link must not cross lines.
+type highlightedSegment struct {
+	start, end int
+	class      string
+	link       string
+}
+
+// buildHighlightedSourceLines builds the html lines of source code.
+// The segments must be sorted and not overlapped.
+func buildHighlightedSourceLines(content []byte, segments []highlightedSegment) []string {
+	lineCount, _ := BuildLineOffsets(content, true)
+	lines := make([]string, 0, lineCount)
+
+	var buf bytes.Buffer
+	buf.Grow(1024)
+	var writeSegment = func(data []byte, class string) {
+		for len(data) > 0 {
+			i := bytes.IndexByte(data, '\n')
+			k := i
+			if k < 0 {
+				k = len(data)
+			}
+			if line := bytes.TrimSuffix(data[:k], []byte{'\r'}); len(line) > 0 {
+				if class != "" {
+					fmt.Fprintf(&buf, `<span class="%s">`, class)
+				}
+				WriteHtmlEscapedBytes(&buf, line)
+				if class != "" {
+					buf.WriteString("</span>")
+				}
+			}
+			if i < 0 {
+				break
+			}
+			lines = append(lines, buf.String())
+			buf.Reset()
+			data = data[i+1:]
+		}
+	}
+
+	offset := 0
+	for _, seg := range segments {
+		writeSegment(content[offset:seg.start], "")
+		if seg.link != "" {
+			fmt.Fprintf(&buf, `<a href="%s">`, seg.link)
+			writeSegment(content[seg.start:seg.end], seg.class)
+			buf.WriteString("</a>")
+		} else {
+			writeSegment(content[seg.start:seg.end], seg.class)
+		}
+		offset = seg.end
+	}
+	writeSegment(content[offset:], "")
+	if len(content) == 0 || content[len(content)-1] != '\n' {
+		lines = append(lines, buf.String())
+	}
+	return lines
+}
+
+// buildLexicallyHighlightedGoLines highlights keywords, literals and
+// comments in Go source code. No type information is needed.
+func buildLexicallyHighlightedGoLines(content []byte) []string {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(content))
+	var s scanner.Scanner
+	s.Init(file, content, nil, scanner.ScanComments)
+
+	var segments []highlightedSegment
+	offset := 0
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		var class string
+		switch {
+		case tok == token.COMMENT:
+			class = "comment"
+		case tok == token.STRING || tok == token.CHAR:
+			class = "lit-string"
+		case tok == token.INT || tok == token.FLOAT || tok == token.IMAG:
+			class = "lit-number"
+		case tok.IsKeyword():
+			class = "keyword"
+		default:
+			continue
+		}
+
+		start := file.Offset(pos)
+		// Carriage returns are removed from the literals of
+		// comments and raw strings, so find their ends directly.
+		end := start + len(lit)
+		if strings.HasPrefix(lit, "/*") {
+			if i := bytes.Index(content[start+2:], []byte("*/")); i >= 0 {
+				end = start + 2 + i + 2
+			}
+		} else if strings.HasPrefix(lit, "`") {
+			if i := bytes.IndexByte(content[start+1:], '`'); i >= 0 {
+				end = start + 1 + i + 1
+			}
+		}
+		if start < offset || end > len(content) {
+			continue
+		}
+
+		segments = append(segments, highlightedSegment{start, end, class, ""})
+		offset = end
+	}
+	return buildHighlightedSourceLines(content, segments)
+}
+
+// The extensions of C (and C-like) source files.
+var cSourceFileExts = map[string]bool{
+	".c": true, ".h": true,
+	".cc": true, ".cpp": true, ".cxx": true,
+	".hh": true, ".hpp": true, ".hxx": true,
+	".m": true,
+}
+
+func cTokenClass(kind code.CTokenKind) string {
+	switch kind {
+	case code.CToken_Comment:
+		return "comment"
+	case code.CToken_String:
+		return "lit-string"
+	case code.CToken_Number:
+		return "lit-number"
+	case code.CToken_Keyword, code.CToken_Directive:
+		return "keyword"
+	}
+	return ""
+}
+
+// buildHighlightedCLines highlights C source code with a lightweight tokenizer.
+func buildHighlightedCLines(content []byte) []string {
+	var segments []highlightedSegment
+	for _, t := range code.ScanCTokens(content) {
+		if class := cTokenClass(t.Kind); class != "" {
+			segments = append(segments, highlightedSegment{t.Start, t.End, class, ""})
+		}
+	}
+	return buildHighlightedSourceLines(content, segments)
+}
+
+// buildNonGoSourceLines builds the lines of a non-Go source file.
+func (ds *docServer) buildNonGoSourceLines(pkg *code.Package, bareFilename string, content []byte) []string {
+	switch ext := filepath.Ext(bareFilename); {
+	case ext == ".s":
+		return ds.buildHighlightedAsmLines(pkg, bareFilename, content)
+	case cSourceFileExts[ext]:
+		return buildHighlightedCLines(content)
+	}
+	return buildPlainSourceLines(content)
+}
+
+func asmTokenClass(kind code.AsmTokenKind) string {
+	switch kind {
+	case code.AsmToken_Comment:
+		return "comment"
+	case code.AsmToken_String:
+		return "lit-string"
+	case code.AsmToken_Number:
+		return "lit-number"
+	case code.AsmToken_Instruction, code.AsmToken_Directive:
+		return "keyword"
+	case code.AsmToken_Register:
+		return "asm-register"
+	case code.AsmToken_PseudoRegister:
+		return "asm-pseudo-register"
+	case code.AsmToken_Symbol, code.AsmToken_Label:
+		return "ident"
+	}
+	return ""
+}
+
+// buildHighlightedAsmLines highlights Go assembly code. The symbols
+// are linked to their Go declarations or their TEXT directives.
+func (ds *docServer) buildHighlightedAsmLines(pkg *code.Package, bareFilename string, content []byte) []string {
+	currentPathInfo := pagePathInfo{ResTypeSource, pkg.Path() + "/" + bareFilename}
+	tokens := code.ScanAsmTokens(content)
+	segments := make([]highlightedSegment, 0, len(tokens))
+	for i, t := range tokens {
+		class := asmTokenClass(t.Kind)
+		if class == "" {
+			continue
+		}
+		var link string
+		if t.Kind == code.AsmToken_Symbol {
+			isText := i > 0 && tokens[i-1].Kind == code.AsmToken_Instruction && string(content[tokens[i-1].Start:tokens[i-1].End]) == "TEXT"
+			link = ds.asmSymbolLink(currentPathInfo, pkg, content[t.Start:t.End], isText)
+		}
+		segments = append(segments, highlightedSegment{t.Start, t.End, class, link})
+	}
+	return buildHighlightedSourceLines(content, segments)
+}
+
+// asmSymbolLink links an assembly symbol to its Go declaration, or to its
+// TEXT directive if it is not declared in Go. Blank is returned if the
+// symbol is unknown.
+func (ds *docServer) asmSymbolLink(currentPathInfo pagePathInfo, pkg *code.Package, symbol []byte, isText bool) string {
+	pkgPath, name := code.ParseAsmSymbol(symbol)
+	if name == "" {
+		return ""
+	}
+	symbolPkg := pkg
+	if pkgPath != "" && pkgPath != pkg.Path() {
+		if symbolPkg = ds.analyzer.PackageByPath(pkgPath); symbolPkg == nil {
+			return ""
+		}
+	}
+
+	if tpkg := symbolPkg.PPkg.Types; tpkg != nil {
+		if obj := tpkg.Scope().Lookup(name); obj != nil {
+			pos := symbolPkg.PPkg.Fset.PositionFor(obj.Pos(), false)
+			return buildSrouceCodeLineLink(currentPathInfo, ds.analyzer, symbolPkg, pos)
+		}
+	}
+	if !isText {
+		if f := symbolPkg.AsmFunctionByName(name); f != nil {
+			return buildSrouceCodeLineLink(currentPathInfo, ds.analyzer, symbolPkg, f.Position)
+		}
+	}
+	return ""
+}
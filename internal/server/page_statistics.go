@@ -5,13 +5,22 @@ import (
 	"math"
 	"net/http"
 	"reflect"
+	"sort"
+	"strings"
+
+	"go101.org/gold/code"
 )
 
-func (ds *docServer) statisticsPage(w http.ResponseWriter, r *http.Request) {
+// statisticsPage serves the statistics page of the specified scope.
+// A blank scope means all packages. Other scopes are
+//   - "main": the packages matched by the arguments,
+//   - "pkg/<import path>": a single package,
+//   - "mod/<root import path>": the packages in a module.
+func (ds *docServer) statisticsPage(w http.ResponseWriter, r *http.Request, scope string) {
 	w.Header().Set("Content-Type", "text/html")
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		w.WriteHeader(http.StatusTooEarly)
@@ -19,21 +28,155 @@ func (ds *docServer) statisticsPage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if ds.theStatisticsPage == nil {
-		ds.theStatisticsPage = ds.buildStatisticsData()
+	if _, _, ok := ds.statisticsOfScope(scope); !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(w, "Statistics scope %s not found", scope)
+		return
+	}
+
+	var sortBy = r.FormValue("sortby")
+	switch sortBy {
+	case "code", "comment", "blank", "generated", "path":
+	default:
+		sortBy = "code"
+	}
+
+	var view = r.FormValue("view")
+	if view != "whole" {
+		view = "exported"
+	}
+
+	pageKey := statisticsPageKey{scope: scope, view: view, linesSortBy: sortBy}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, _ := ds.pages.getPage(pageKey, func() ([]byte, error) {
+		return ds.buildStatisticsData(scope, view, sortBy), nil
+	})
+	ds.writePageContent(w, r, etag, content)
+}
+
+type statisticsPageKey struct {
+	scope       string
+	view        string // "exported", "whole"
+	linesSortBy string // "code", "comment", "blank", "generated", "path"
+}
+
+// statisticsQuery returns the query string of the statistics page
+// with the specified view and line sorting way.
+func statisticsQuery(view, linesSortBy string) string {
+	q := "?"
+	if view != "exported" {
+		q += "view=" + view
+	}
+	if linesSortBy != "code" {
+		if len(q) > 1 {
+			q += "&"
+		}
+		q += "sortby=" + linesSortBy
 	}
-	w.Write(ds.theStatisticsPage)
+	return q
 }
 
-func (ds *docServer) buildStatisticsData() []byte {
-	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Statistics(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, "statistics"})
+// statisticsOfScope returns the statistics and packages of a scope.
+// See statisticsPage for the scope formats.
+func (ds *docServer) statisticsOfScope(scope string) (stats code.Stats, pkgs []*code.Package, ok bool) {
+	switch {
+	case scope == "":
+		pkgs = make([]*code.Package, ds.analyzer.NumPackages())
+		for i := range pkgs {
+			pkgs[i] = ds.analyzer.PackageAt(i)
+		}
+		return ds.analyzer.Statistics(), pkgs, true
+	case scope == "main":
+		pkgs = ds.analyzer.MainPackages()
+	case strings.HasPrefix(scope, "pkg/"):
+		if pkg := ds.analyzer.PackageByPath(scope[len("pkg/"):]); pkg != nil {
+			pkgs = []*code.Package{pkg}
+		}
+	case strings.HasPrefix(scope, "mod/"):
+		if mod := ds.analyzer.ModuleByRoot(scope[len("mod/"):]); mod != nil {
+			pkgs = ds.analyzer.ModulePackages(mod)
+		}
+	}
+	if len(pkgs) == 0 {
+		return stats, nil, false
+	}
+	return code.StatisticsOf(pkgs), pkgs, true
+}
+
+// statisticsPagePathInfo returns the path info of the statistics page of a scope.
+func statisticsPagePathInfo(scope string) pagePathInfo {
+	if scope == "" {
+		return pagePathInfo{ResTypeNone, "statistics"}
+	}
+	return pagePathInfo{ResTypeStatistics, scope}
+}
+
+// statisticsChartPath returns the resource path of the chart of a scope.
+func statisticsChartPath(scope, chart string) string {
+	if scope == "" {
+		return chart
+	}
+	return scope + "/" + chart
+}
+
+func (ds *docServer) buildStatisticsData(scope, view, linesSortBy string) []byte {
+	stats, pkgs, _ := ds.statisticsOfScope(scope)
+
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_Statistics(), ds.currentTheme.Name(), statisticsPagePathInfo(scope))
 	fmt.Fprintf(page, `
-<pre><code><span style="font-size:xx-large;">%s</span></code></pre>
-`,
+<pre><code><span style="font-size:xx-large;">%s</span></code>`,
 		ds.currentTranslation.Text_Statistics(),
 	)
+	switch {
+	case scope == "main":
+		fmt.Fprintf(page, `
+<code>	<i>%s</i></code>`, ds.currentTranslation.Text_StatisticsScope("main", "", ""))
+	case strings.HasPrefix(scope, "pkg/"):
+		fmt.Fprintf(page, `
+<code>	<i>%s</i></code>`, ds.currentTranslation.Text_StatisticsScope("package", pkgs[0].Path(),
+			buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkgs[0].Path()}, nil, "")))
+	case strings.HasPrefix(scope, "mod/"):
+		fmt.Fprintf(page, `
+<code>	<i>%s</i></code>`, ds.currentTranslation.Text_StatisticsScope("module", scope[len("mod/"):], ""))
+	}
+	fmt.Fprintf(page, `
+<code>	<a href="%s">%s</a> | <a href="%s">%s</a> | <a href="%s">%s</a> | <a href="%s">%s</a></code>`,
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "hotspots"}, nil, ""),
+		ds.currentTranslation.Text_Hotspots(),
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "unused"}, nil, ""),
+		ds.currentTranslation.Text_UnusedIdentifiers(),
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "unreachable"}, nil, ""),
+		ds.currentTranslation.Text_UnreachableFunctions(),
+		buildPageHref(page.PathInfo, pagePathInfo{ResTypeNone, "deprecated"}, nil, ""),
+		ds.currentTranslation.Text_DeprecatedUses(),
+	)
+
+	// The view toggle is not available in generation mode.
+	if !genDocsMode {
+		page.WriteString(`
+<code>	`)
+		for i, v := range []string{"exported", "whole"} {
+			if i > 0 {
+				page.WriteString(" | ")
+			}
+			if v == view {
+				fmt.Fprintf(page, `<b>%s</b>`, ds.currentTranslation.Text_StatisticsView(v))
+			} else {
+				fmt.Fprintf(page, `<a href="%s">%s</a>`, statisticsQuery(v, linesSortBy), ds.currentTranslation.Text_StatisticsView(v))
+			}
+		}
+		page.WriteString(`</code>`)
+	}
+	page.WriteString(`</pre>
+`)
 
-	stats := ds.analyzer.Statistics()
+	var chartURL = func(chart string) string {
+		return buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, statisticsChartPath(scope, chart)}, nil, "")
+	}
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("packages"))
 	page.WriteString(ds.currentTranslation.Text_PackageStatistics(map[string]interface{}{
@@ -42,14 +185,32 @@ func (ds *docServer) buildStatisticsData() []byte {
 		"standardPackageCount":             stats.StdPackages,
 		"sourceFileCount":                  stats.FilesWithoutGenerateds,
 		"goSourceFileCount":                stats.AstFiles,
-		"averageSourceFileCountPerPackage": float64(stats.FilesWithGenerateds) / float64(stats.Packages),
-		"averageImportCountPerFile":        float64(stats.Imports) / float64(stats.AstFiles),
-		"averageDependencyCountPerPackage": float64(stats.AllPackageDeps) / float64(stats.Packages),
+		"averageSourceFileCountPerPackage": average(stats.FilesWithGenerateds, stats.Packages),
+		"averageImportCountPerFile":        average(stats.Imports, stats.AstFiles),
+		"averageDependencyCountPerPackage": average(stats.AllPackageDeps, stats.Packages),
+		"codeLineCount":                    stats.CodeLines,
+		"commentLineCount":                 stats.CommentCodeLines,
+		"blankLineCount":                   stats.BlankCodeLines,
+		"generatedLineCount":               stats.GeneratedCodeLines,
 
-		"gosourcefilesByImportsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "gosourcefiles-by-imports"}, nil, ""),
-		"packagesByDependenciesChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "packages-by-dependencies"}, nil, ""),
+		"gosourcefilesByImportsChartURL": chartURL("gosourcefiles-by-imports"),
+		"packagesByDependenciesChartURL": chartURL("packages-by-dependencies"),
 	}))
 
+	if view == "whole" {
+		ds.writeWholeCodeStatistics(page, &stats, chartURL)
+	} else {
+		ds.writeExportedAPIStatistics(page, &stats, chartURL)
+	}
+
+	ds.writeStatisticsRankings(page, ds.analyzer.RankingsOf(pkgs, TopN))
+	ds.writeLineStatistics(page, pkgs, view, linesSortBy)
+
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
+
+// writeExportedAPIStatistics writes the statistics of exported resources.
+func (ds *docServer) writeExportedAPIStatistics(page *htmlPage, stats *code.Stats, chartURL func(string) string) {
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("types"))
 	page.WriteString(ds.currentTranslation.Text_TypeStatistics(map[string]interface{}{
 		"exportedTypeNameCount":      stats.ExportedTypeNames,
@@ -59,29 +220,29 @@ func (ds *docServer) buildStatisticsData() []byte {
 		"exportedIntergerTypeNames":  stats.ExportedIntergerTypeNames,
 		"exportedUnsignedTypeNames":  stats.ExportedUnsignedTypeNames,
 
-		"exportedtypenamesByKindsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedtypenames-by-kinds"}, nil, ""),
+		"exportedtypenamesByKindsChartURL": chartURL("exportedtypenames-by-kinds"),
 
 		"exportedStructTypeNames":                     stats.ExportedTypeNamesByKind[reflect.Struct],
 		"exportedNamedStructTypesWithEmbeddingFields": stats.ExportedNamedStructTypesWithEmbeddingFields,
 		"exportedNamedStructTypesWithPromotedFields":  stats.ExportedNamedStructTypesWithPromotedFields,
 
-		"exportedstructtypesByEmbeddingfieldsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-embeddingfields"}, nil, ""),
+		"exportedstructtypesByEmbeddingfieldsChartURL": chartURL("exportedstructtypes-by-embeddingfields"),
 
-		"exportedNamedStructTypeFieldsPerExportedStruct":                 float64(stats.ExportedNamedStructTypeFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
-		"exportedNamedStructTypeExplicitFieldsPerExportedStruct":         float64(stats.ExportedNamedStructTypeExplicitFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
-		"exportedNamedStructTypeExportedFieldsPerExportedStruct":         float64(stats.ExportedNamedStructTypeExportedFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
-		"exportedNamedStructTypeExportedExplicitFieldsPerExportedStruct": float64(stats.ExportedNamedStructTypeExportedExplicitFields) / float64(stats.ExportedTypeNamesByKind[reflect.Struct]),
+		"exportedNamedStructTypeFieldsPerExportedStruct":                 average(stats.ExportedNamedStructTypeFields, stats.ExportedTypeNamesByKind[reflect.Struct]),
+		"exportedNamedStructTypeExplicitFieldsPerExportedStruct":         average(stats.ExportedNamedStructTypeExplicitFields, stats.ExportedTypeNamesByKind[reflect.Struct]),
+		"exportedNamedStructTypeExportedFieldsPerExportedStruct":         average(stats.ExportedNamedStructTypeExportedFields, stats.ExportedTypeNamesByKind[reflect.Struct]),
+		"exportedNamedStructTypeExportedExplicitFieldsPerExportedStruct": average(stats.ExportedNamedStructTypeExportedExplicitFields, stats.ExportedTypeNamesByKind[reflect.Struct]),
 
-		"exportedstructtypesByExplicitfieldsChartURL":         buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-explicitfields"}, nil, ""),
-		"exportedstructtypesByExportedexplicitfieldsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-exportedexplicitfields"}, nil, ""),
-		//"exportedstructtypesByExportedfieldsChartURL":         buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-exportedfields"}, nil, ""),
-		"exportedstructtypesByExportedpromotedfieldsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedstructtypes-by-exportedpromotedfields"}, nil, ""),
+		"exportedstructtypesByExplicitfieldsChartURL":         chartURL("exportedstructtypes-by-explicitfields"),
+		"exportedstructtypesByExportedexplicitfieldsChartURL": chartURL("exportedstructtypes-by-exportedexplicitfields"),
+		//"exportedstructtypesByExportedfieldsChartURL":         chartURL("exportedstructtypes-by-exportedfields"),
+		"exportedstructtypesByExportedpromotedfieldsChartURL": chartURL("exportedstructtypes-by-exportedpromotedfields"),
 
-		"exportedNamedNonInterfacesExportedMethodsPerExportedNonInterfaceType": float64(stats.ExportedNamedNonInterfacesExportedMethods) / float64(stats.ExportedNamedNonInterfacesWithExportedMethods),
-		"exportedNamedInterfacesExportedMethodsPerExportedInterfaceType":       float64(stats.ExportedNamedInterfacesExportedMethods) / float64(stats.ExportedTypeNamesByKind[reflect.Interface]),
+		"exportedNamedNonInterfacesExportedMethodsPerExportedNonInterfaceType": average(stats.ExportedNamedNonInterfacesExportedMethods, stats.ExportedNamedNonInterfacesWithExportedMethods),
+		"exportedNamedInterfacesExportedMethodsPerExportedInterfaceType":       average(stats.ExportedNamedInterfacesExportedMethods, stats.ExportedTypeNamesByKind[reflect.Interface]),
 
-		"exportednoninterfacetypesByExportedmethodsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportednoninterfacetypes-by-exportedmethods"}, nil, ""),
-		"exportedinterfacetypesByExportedmethodsChartURL":    buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedinterfacetypes-by-exportedmethods"}, nil, ""),
+		"exportednoninterfacetypesByExportedmethodsChartURL": chartURL("exportednoninterfacetypes-by-exportedmethods"),
+		"exportedinterfacetypesByExportedmethodsChartURL":    chartURL("exportedinterfacetypes-by-exportedmethods"),
 	}))
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("values"))
@@ -89,26 +250,199 @@ func (ds *docServer) buildStatisticsData() []byte {
 		"exportedVariables": stats.ExportedVariables,
 		"exportedConstants": stats.ExportedConstants,
 
-		"exportedvariablesByTypekindsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedvariables-by-typekinds"}, nil, ""),
-		"exportedconstantsByTypekindsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedconstants-by-typekinds"}, nil, ""),
+		"exportedvariablesByTypekindsChartURL": chartURL("exportedvariables-by-typekinds"),
+		"exportedconstantsByTypekindsChartURL": chartURL("exportedconstants-by-typekinds"),
 
 		"exportedFunctions":                             stats.ExportedFunctions,
 		"exportedMethods":                               stats.ExportedMethods,
-		"averageParameterCountPerExportedFunction":      float64(stats.ExportedFunctionParameters) / float64(stats.ExportedFunctions+stats.ExportedMethods),
-		"averageResultCountPerExportedFunction":         float64(stats.ExportedFunctionResults) / float64(stats.ExportedFunctions+stats.ExportedMethods),
+		"averageParameterCountPerExportedFunction":      average(stats.ExportedFunctionParameters, stats.ExportedFunctions+stats.ExportedMethods),
+		"averageResultCountPerExportedFunction":         average(stats.ExportedFunctionResults, stats.ExportedFunctions+stats.ExportedMethods),
 		"exportedFunctionWithLastErrorResult":           stats.ExportedFunctionWithLastErrorResult,
-		"exportedFunctionWithLastErrorResultPercentage": int(math.Round(100 * float64(stats.ExportedFunctionWithLastErrorResult) / float64(stats.ExportedFunctions+stats.ExportedMethods))),
+		"exportedFunctionWithLastErrorResultPercentage": int(math.Round(100 * average(stats.ExportedFunctionWithLastErrorResult, stats.ExportedFunctions+stats.ExportedMethods))),
 
-		"exportedfunctionsByParametersChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedfunctions-by-parameters"}, nil, ""),
-		"exportedfunctionsByResultsChartURL":    buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedfunctions-by-results"}, nil, ""),
+		"exportedfunctionsByParametersChartURL": chartURL("exportedfunctions-by-parameters"),
+		"exportedfunctionsByResultsChartURL":    chartURL("exportedfunctions-by-results"),
 	}))
 
 	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("others"))
 	page.WriteString(ds.currentTranslation.Text_Othertatistics(map[string]interface{}{
-		"averageIdentiferLength": float64(stats.ExportedIdentifersSumLength) / float64(stats.ExportedIdentifers),
+		"averageIdentiferLength": average(stats.ExportedIdentifersSumLength, stats.ExportedIdentifers),
 
-		"exportedidentifiersByLengthsChartURL": buildPageHref(page.PathInfo, pagePathInfo{ResTypeSVG, "exportedidentifiers-by-lengths"}, nil, ""),
+		"exportedidentifiersByLengthsChartURL": chartURL("exportedidentifiers-by-lengths"),
 	}))
+}
+
+// writeWholeCodeStatistics writes the statistics of unexported and local resources.
+func (ds *docServer) writeWholeCodeStatistics(page *htmlPage, stats *code.Stats, chartURL func(string) string) {
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("types"))
+	page.WriteString(ds.currentTranslation.Text_WholeCodeTypeStatistics(map[string]interface{}{
+		"exportedTypeNameCount":   stats.ExportedTypeNames,
+		"unexportedTypeNameCount": stats.UnexportedTypeNames,
+		"localTypeNameCount":      stats.LocalTypeNames,
+
+		"unexportedtypenamesByKindsChartURL": chartURL("unexportedtypenames-by-kinds"),
+		"localtypenamesByKindsChartURL":      chartURL("localtypenames-by-kinds"),
+	}))
+
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span></code>`, ds.currentTranslation.Text_StatisticsTitle("values"))
+	page.WriteString(ds.currentTranslation.Text_WholeCodeValueStatistics(map[string]interface{}{
+		"unexportedVariables":    stats.UnexportedVariables,
+		"unexportedConstants":    stats.UnexportedConstants,
+		"functionTypedVariables": stats.FunctionTypedVariables,
+
+		"unexportedFunctions":              stats.UnexportedFunctions,
+		"unexportedMethods":                stats.UnexportedMethods,
+		"methodsOfUnexportedTypes":         stats.MethodsOfUnexportedTypes,
+		"functionLiterals":                 stats.FunctionLiterals,
+		"allFunctions":                     stats.AllFunctions,
+		"averageParameterCountPerFunction": average(stats.AllFunctionParameters, stats.AllFunctions),
+		"averageResultCountPerFunction":    average(stats.AllFunctionResults, stats.AllFunctions),
+
+		"allfunctionsByParametersChartURL": chartURL("allfunctions-by-parameters"),
+		"allfunctionsByResultsChartURL":    chartURL("allfunctions-by-results"),
+	}))
+}
+
+// average returns sum/count, or 0 if count is 0.
+// Scoped statistics often have zero counts.
+func average(sum, count int32) float64 {
+	if count == 0 {
+		return 0
+	}
+	return float64(sum) / float64(count)
+}
 
-	return page.Done(ds.currentTranslation)
+// TopN is the length of each ranking list on the statistics page.
+const TopN = 10
+
+func (ds *docServer) writeStatisticsRankings(page *htmlPage, rankings *code.Rankings) {
+	fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`, ds.currentTranslation.Text_StatisticsTitle("rankings"))
+
+	var writeRanking = func(rankingName string, items []code.RankedItem) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(page, "\n\n\t<b>%s</b>", ds.currentTranslation.Text_RankingTitle(rankingName, len(items)))
+		for i, item := range items {
+			fmt.Fprintf(page, "\n\t%2d. ", i+1)
+			switch {
+			case item.Name == "":
+				fmt.Fprintf(page, `<a href="%s">%s</a>`,
+					buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, item.Pkg.Path()}, nil, ""),
+					item.Pkg.Path())
+			case strings.IndexByte(item.Name, '.') >= 0: // methods
+				ds.writeSrouceCodeLineLink(page, item.Pkg, item.Position, item.Pkg.Path()+"."+item.Name, "", false)
+			default:
+				fmt.Fprintf(page, `<a href="%s#name-%s">%s.%s</a>`,
+					buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, item.Pkg.Path()}, nil, ""),
+					item.Name, item.Pkg.Path(), item.Name)
+			}
+			fmt.Fprintf(page, " <i>(%d)</i>", item.Count)
+		}
+	}
+
+	writeRanking("most-implemented-interfaces", rankings.MostImplementedInterfaces)
+	writeRanking("types-with-most-methods", rankings.TypesWithMostMethods)
+	writeRanking("largest-packages-by-declarations", rankings.LargestPackagesByDecls)
+	writeRanking("largest-packages-by-lines", rankings.LargestPackagesByLines)
+	writeRanking("most-imported-packages", rankings.MostImportedPackages)
+	writeRanking("functions-with-most-parameters", rankings.FunctionsWithMostParameters)
+	writeRanking("most-referenced-identifiers", rankings.MostReferencedIdentifiers)
+
+	page.WriteString("\n</code></pre>")
+}
+
+// writeLineStatistics writes the line statistics tables of modules and packages.
+func (ds *docServer) writeLineStatistics(page *htmlPage, pkgs []*code.Package, view, sortBy string) {
+	type lineStatsRow struct {
+		path      string
+		link      string
+		lines     code.LineStats
+		generated code.LineStats
+	}
+
+	var modules = make(map[*code.Module]bool)
+	var moduleRows, packageRows []lineStatsRow
+	for _, pkg := range pkgs {
+		if pkg.Lines.Total()+pkg.GeneratedLines.Total() == 0 {
+			continue // builtin, unsafe, ...
+		}
+		packageRows = append(packageRows, lineStatsRow{
+			path:      pkg.Path(),
+			link:      buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, pkg.Path()}, nil, ""),
+			lines:     pkg.Lines,
+			generated: pkg.GeneratedLines,
+		})
+		if mod := pkg.Mod; mod != nil && !modules[mod] {
+			modules[mod] = true
+			moduleRows = append(moduleRows, lineStatsRow{
+				path:      ds.moduleTitle(mod),
+				lines:     mod.Lines,
+				generated: mod.GeneratedLines,
+			})
+		}
+	}
+
+	var less = func(a, b *lineStatsRow) bool {
+		switch sortBy {
+		case "comment":
+			if a.lines.Comment != b.lines.Comment {
+				return a.lines.Comment > b.lines.Comment
+			}
+		case "blank":
+			if a.lines.Blank != b.lines.Blank {
+				return a.lines.Blank > b.lines.Blank
+			}
+		case "generated":
+			if a.generated.Total() != b.generated.Total() {
+				return a.generated.Total() > b.generated.Total()
+			}
+		case "code":
+			if a.lines.Code != b.lines.Code {
+				return a.lines.Code > b.lines.Code
+			}
+		}
+		return a.path < b.path
+	}
+	sort.Slice(moduleRows, func(i, j int) bool { return less(&moduleRows[i], &moduleRows[j]) })
+	sort.Slice(packageRows, func(i, j int) bool { return less(&packageRows[i], &packageRows[j]) })
+
+	// The column headers are also sorting links (except in generation mode).
+	var header = func(lastColumn string) string {
+		var b strings.Builder
+		for _, item := range []string{"code", "comment", "blank", "generated", "path"} {
+			text := ds.currentTranslation.Text_LineStatsItem(item)
+			if item == "path" {
+				text = lastColumn
+			} else {
+				b.WriteString(strings.Repeat(" ", 10-len([]rune(text))))
+			}
+			if genDocsMode || item == sortBy {
+				b.WriteString(text)
+			} else {
+				fmt.Fprintf(&b, `<a href="%s#line-stats">%s</a>`, statisticsQuery(view, item), text)
+			}
+			b.WriteString("  ")
+		}
+		return b.String()
+	}
+	var writeRows = func(rows []lineStatsRow) {
+		for _, row := range rows {
+			fmt.Fprintf(page, "\n\t%10d  %10d  %10d  %10d  ", row.lines.Code, row.lines.Comment, row.lines.Blank, row.generated.Total())
+			if row.link != "" {
+				fmt.Fprintf(page, `<a href="%s">%s</a>`, row.link, row.path)
+			} else {
+				page.WriteString(row.path)
+			}
+		}
+	}
+
+	fmt.Fprintf(page, `<pre><code><span class="title" id="line-stats">%s</span>`, ds.currentTranslation.Text_StatisticsTitle("lines"))
+	if len(moduleRows) > 0 {
+		fmt.Fprintf(page, "\n\n\t%s", header(ds.currentTranslation.Text_LineStatsItem("module")))
+		writeRows(moduleRows)
+	}
+	fmt.Fprintf(page, "\n\n\t%s", header(ds.currentTranslation.Text_LineStatsItem("package")))
+	writeRows(packageRows)
+	page.WriteString("\n</code></pre>")
 }
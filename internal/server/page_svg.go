@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile string) {
@@ -27,8 +28,8 @@ func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile str
 		}
 	}()
 
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	if ds.phase < Phase_Analyzed {
 		svgData = []byte{}
@@ -70,9 +71,19 @@ func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile str
 		}
 	}
 
-	stats := ds.analyzer.Statistics()
-	chartTitle := ds.currentTranslation.Text_ChartTitle(svgFile)
-	switch svgFile {
+	// Charts of scoped statistics are prefixed with their scopes.
+	// See statisticsPage for the scope formats.
+	scope, chart := "", svgFile
+	if i := strings.LastIndexByte(svgFile, '/'); i >= 0 {
+		scope, chart = svgFile[:i], svgFile[i+1:]
+	}
+	stats, _, ok := ds.statisticsOfScope(scope)
+	if !ok {
+		return
+	}
+
+	chartTitle := ds.currentTranslation.Text_ChartTitle(chart)
+	switch chart {
 	case "gosourcefiles-by-imports":
 		svgData = createSourcefileImportsSVG(chartTitle, stats.FilesByImportCount[:], xName(len(stats.FilesByImportCount)-1))
 	case "packages-by-dependencies":
@@ -105,6 +116,14 @@ func (ds *docServer) svgFile(w http.ResponseWriter, r *http.Request, svgFile str
 		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedConstantsByTypeKind[1:], kindName)
 	case "exportedinterfacetypes-by-exportedmethods":
 		svgData = createSourcefileImportsSVG(chartTitle, stats.ExportedNamedInterfacesByExportedMethodCount[:], xName(len(stats.ExportedNamedInterfacesByExportedMethodCount)-1))
+	case "unexportedtypenames-by-kinds":
+		svgData = createSourcefileImportsSVG(chartTitle, stats.UnexportedTypeNamesByKind[1:], kindName)
+	case "localtypenames-by-kinds":
+		svgData = createSourcefileImportsSVG(chartTitle, stats.LocalTypeNamesByKind[1:], kindName)
+	case "allfunctions-by-parameters":
+		svgData = createSourcefileImportsSVG(chartTitle, stats.AllFunctionsByParameterCount[:], xName(len(stats.AllFunctionsByParameterCount)-1))
+	case "allfunctions-by-results":
+		svgData = createSourcefileImportsSVG(chartTitle, stats.AllFunctionsByResultCount[:], xName(len(stats.AllFunctionsByResultCount)-1))
 	default:
 	}
 }
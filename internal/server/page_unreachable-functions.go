@@ -0,0 +1,69 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"go101.org/gold/code"
+)
+
+func (ds *docServer) unreachableFunctionsPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	pageKey := unreachableFunctionsPageKey{}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, _ := ds.pages.getPage(pageKey, func() ([]byte, error) {
+		return ds.buildUnreachableFunctionsPage(), nil
+	})
+	ds.writePageContent(w, r, etag, content)
+}
+
+type unreachableFunctionsPageKey struct{}
+
+func (ds *docServer) buildUnreachableFunctionsPage() []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_UnreachableFunctions(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, "unreachable"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code>
+<code>	<i>%s</i></code>
+</pre>
+`,
+		ds.currentTranslation.Text_UnreachableFunctions(),
+		ds.currentTranslation.Text_UnreachableFunctionsNote(),
+	)
+
+	// Group the main packages by modules, in the package order.
+	modules, modulePackages := groupPackagesByModules(ds.analyzer.MainPackages())
+	for _, mod := range modules {
+		funcs := ds.analyzer.UnreachableFunctions(modulePackages[mod])
+		fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`,
+			ds.currentTranslation.Text_UnreachableFunctionsOfModule(ds.moduleTitle(mod), len(funcs)),
+		)
+
+		var lastPkg *code.Package
+		for _, f := range funcs {
+			if f.Pkg != lastPkg {
+				lastPkg = f.Pkg
+				page.WriteString("\n\n\t")
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, f.Pkg.Path()}, page, f.Pkg.Path())
+			}
+			page.WriteString("\n\t\t")
+			ds.writeSrouceCodeLineLink(page, f.Pkg, f.Position(), functionDisplayName(f), "", false)
+		}
+		page.WriteString("\n</code></pre>")
+	}
+
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"go101.org/gold/code"
+)
+
+func (ds *docServer) unusedIdentifiersPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	if ds.phase < Phase_Analyzed {
+		w.WriteHeader(http.StatusTooEarly)
+		ds.loadingPage(w, r)
+		return
+	}
+
+	// Test and main packages are viewed as users by default.
+	options := code.UnusedIdentifierOptions{
+		TestPackagesAsUsers: r.FormValue("tests") != "no",
+		MainPackagesAsUsers: r.FormValue("mains") != "no",
+	}
+
+	pageKey := unusedIdentifiersPageKey{options: options}
+	etag := ds.pageETag(pageKey)
+	if checkNotModified(w, r, etag) {
+		return
+	}
+
+	content, _ := ds.pages.getPage(pageKey, func() ([]byte, error) {
+		return ds.buildUnusedIdentifiersPage(options), nil
+	})
+	ds.writePageContent(w, r, etag, content)
+}
+
+type unusedIdentifiersPageKey struct {
+	options code.UnusedIdentifierOptions
+}
+
+// unusedIdentifiersQuery returns the query string of the
+// unused identifiers page with the specified options.
+func unusedIdentifiersQuery(options code.UnusedIdentifierOptions) string {
+	q := "?"
+	if !options.TestPackagesAsUsers {
+		q += "tests=no"
+	}
+	if !options.MainPackagesAsUsers {
+		if len(q) > 1 {
+			q += "&"
+		}
+		q += "mains=no"
+	}
+	return q
+}
+
+func (ds *docServer) buildUnusedIdentifiersPage(options code.UnusedIdentifierOptions) []byte {
+	page := NewHtmlPage(ds.goldVersion, ds.currentTranslation.Text_UnusedIdentifiers(), ds.currentTheme.Name(), pagePathInfo{ResTypeNone, "unused"})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code>
+<code>	<i>%s</i></code>`,
+		ds.currentTranslation.Text_UnusedIdentifiers(),
+		ds.currentTranslation.Text_UnusedIdentifiersNote(),
+	)
+
+	// The option toggles are not available in generation mode.
+	if !genDocsMode {
+		var writeToggle = func(option string, asUsers bool, toggled code.UnusedIdentifierOptions) {
+			fmt.Fprintf(page, `
+<code>	%s <a href="%s">%s</a></code>`,
+				ds.currentTranslation.Text_UnusedIdentifiersOption(option, asUsers),
+				unusedIdentifiersQuery(toggled),
+				ds.currentTranslation.Text_UnusedIdentifiersOptionToggle(),
+			)
+		}
+		// Toggling test files is meaningless if they are not loaded.
+		toggled := options
+		if ds.parseOptions.Tests {
+			toggled.TestPackagesAsUsers = !toggled.TestPackagesAsUsers
+			writeToggle("tests", options.TestPackagesAsUsers, toggled)
+		} else {
+			fmt.Fprintf(page, `
+<code>	%s</code>`,
+				ds.currentTranslation.Text_UnusedIdentifiersTestsNotLoaded(),
+			)
+		}
+		toggled = options
+		toggled.MainPackagesAsUsers = !toggled.MainPackagesAsUsers
+		writeToggle("mains", options.MainPackagesAsUsers, toggled)
+	}
+	page.WriteString(`</pre>
+`)
+
+	// Group the main packages by modules, in the package order.
+	modules, modulePackages := groupPackagesByModules(ds.analyzer.MainPackages())
+	for _, mod := range modules {
+		unuseds := ds.analyzer.UnusedExportedIdentifiers(modulePackages[mod], options)
+		fmt.Fprintf(page, `<pre><code><span class="title">%s</span>`,
+			ds.currentTranslation.Text_UnusedIdentifiersOfModule(ds.moduleTitle(mod), len(unuseds)),
+		)
+
+		var lastPkg *code.Package
+		for i := range unuseds {
+			item := &unuseds[i]
+			if item.Pkg != lastPkg {
+				lastPkg = item.Pkg
+				page.WriteString("\n\n\t")
+				buildPageHref(page.PathInfo, pagePathInfo{ResTypePackage, item.Pkg.Path()}, page, item.Pkg.Path())
+			}
+			fmt.Fprintf(page, "\n\t\t%6s ", item.Kind)
+			ds.writeSrouceCodeLineLink(page, item.Pkg, item.Position, item.Name, "", false)
+		}
+		page.WriteString("\n</code></pre>")
+	}
+
+	return page.Done(ds.currentTranslation, ds.parseOptions.BuildConfiguration())
+}
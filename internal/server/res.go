@@ -35,6 +35,8 @@ type Translation interface {
 	Text_Analyzing_Start() string
 	Text_Analyzing_Done(d time.Duration, memoryUse string) string
 
+	Text_Analyzing_ListModules(numModules, numToDownload int, d time.Duration) string
+	Text_Analyzing_DownloadModule(module string, numDones, numToDownload int, failed bool, d time.Duration) string
 	Text_Analyzing_PreparationDone(d time.Duration) string // ToDo: merge these into one?
 	Text_Analyzing_NFilesParsed(numFiles int, d time.Duration) string
 	Text_Analyzing_ParsePackagesDone(numFiles int, d time.Duration) string
@@ -48,16 +50,19 @@ type Translation interface {
 	Text_Analyzing_RegisterInterfaceMethodsForTypes(d time.Duration) string
 	Text_Analyzing_MakeStatistics(d time.Duration) string
 	Text_Analyzing_CollectSourceFiles(d time.Duration) string
+	Text_Analyzing_FindUnreachableFunctions(d time.Duration) string
 
 	// overview page
 	Text_Overview() string
 	Text_PackageList() string
-	Text_StatisticsWithMoreLink(detailedStatsLink string) string
+	Text_StatisticsWithMoreLink(detailedStatsLink, mainPackagesStatsLink string) string
 	Text_SimpleStats(stats *code.Stats) string
 	Text_Modules() string                                    // to use
 	Text_BelongingModule() string                            // to use
 	Text_RequireStat(numRequires, numRequiredBys int) string // to use
 	Text_UpdateTip(tipName string) string                    // tip names: "ToUpdate", "Updating", "Updated"
+	Text_NumErrors(num int) string                           // also used in package details page
+	Text_BuildConfiguration() string
 
 	Text_SortBy() string                // also used in other pages
 	Text_Filter() string                // also used in other pages
@@ -71,6 +76,14 @@ type Translation interface {
 	Text_ImportPath() string
 	Text_ImportStat(numImports, numImportedBys int, depPageURL string) string
 	Text_InvolvedFiles(num int) string
+	Text_IgnoredFiles(num int) string
+	Text_IgnoredFileReason(reason string) string // reason: "filename", "constraint", "cgo"
+	Text_CgoInterface(num int) string
+	Text_ImplementedInAssembly() string
+	Text_CgoSymbol(kind string, numUses int, declared bool) string // kind: "func", "type", "var", "const", "macro"
+	Text_LineStatistics(lines, generatedLines code.LineStats) string
+	Text_DetailedStatisticsLinks(packageStatsLink, moduleStatsLink string) string
+	Text_Diagnostics(numErrors int) string
 	Text_ExportedValues(num int) string
 	Text_ExportedTypeNames(num int) string
 	Text_AllPackageLevelTypeNames(num int) string
@@ -85,6 +98,15 @@ type Translation interface {
 	Text_AsTypesOf(num int) string
 	Text_References(num int) string
 
+	// builtin package page
+	Text_BuiltinFunctions(num int) string
+	Text_BuiltinFunctionExplanation(name string) string
+	Text_PredeclaredTypes(num int) string
+	Text_PredeclaredValues(num int) string
+	Text_Example() string
+	Text_RuntimeImplementation() string
+	Text_AliasedBy(num int) string
+
 	// package dependencies page
 	Text_DependencyRelations(pkgPath string) string // also used in package details page with a blank argument.
 	Text_Imports() string
@@ -98,23 +120,69 @@ type Translation interface {
 	Text_SourceCode(pkgPath, bareFilename string) string
 	Text_SourceFilePath() string
 	Text_GeneratedFrom() string
+	Text_BuildConstraint() string
+	Text_IgnoredFileNote(reason, buildConfig string) string
 
 	// statistics
 	Text_Statistics() string
 	Text_ChartTitle(chartName string) string
 	Text_StatisticsTitle(titleName string) string
+	Text_StatisticsScope(scope, name, link string) string // scope: "main", "package", "module"
 	Text_PackageStatistics(values map[string]interface{}) string
 	Text_TypeStatistics(values map[string]interface{}) string
 	Text_ValueStatistics(values map[string]interface{}) string
 	Text_Othertatistics(values map[string]interface{}) string
+	Text_StatisticsView(view string) string // view: "exported", "whole"
+	Text_WholeCodeTypeStatistics(values map[string]interface{}) string
+	Text_WholeCodeValueStatistics(values map[string]interface{}) string
+
+	// hotspots page
+	Text_Hotspots() string
+	Text_HotspotsNote(numListed, numAll int) string
+	Text_HotspotsItem(item string) string // item: "complexity", "statements", "nesting", "parameters", "function"
+	Text_FunctionMetrics(complexity, statements, nesting, parameters int) string
+	Text_ComplexityBadge(complexity int) string
+
+	// unused identifiers page
+	Text_UnusedIdentifiers() string
+	Text_UnusedIdentifiersNote() string
+	Text_UnusedIdentifiersOption(option string, asUsers bool) string // option: "tests", "mains"
+	Text_UnusedIdentifiersOptionToggle() string
+	Text_UnusedIdentifiersTestsNotLoaded() string
+	Text_UnusedIdentifiersOfModule(moduleName string, num int) string
+	Text_MainModule() string
+
+	// unreachable functions page
+	Text_UnreachableFunctions() string
+	Text_UnreachableFunctionsNote() string
+	Text_UnreachableFunctionsOfModule(moduleName string, num int) string
+	Text_UnreachableDeclaration() string // used on source code pages
+
+	// deprecated identifiers
+	Text_DeprecationNote(note string) string
+	Text_DeprecatedIdentifiers(num int) string
+	Text_DeprecatedIdentifiersShowOption(show bool) string
+	Text_DeprecatedUses() string
+	Text_DeprecatedUsesNote(num int) string
+	Text_RankingTitle(rankingName string, n int) string
+	Text_LineStatsItem(item string) string // item: "code", "comment", "blank", "generated", "module", "package"
+
+	// workspaces
+	Text_Workspaces() string
+	Text_WorkspaceStatus(status string) string // status: "unanalyzed", "analyzing", "analyzed", "failed"
+
+	// reload
+	Text_Reload() string
+	Text_ReloadOption(name string) string   // name: "patterns", "tags", "goos", "goarch", "tests", "submit"
+	Text_ReloadStatus(status string) string // status: "disabled", "failed"
 
 	// Footer
-	Text_GeneratedPageFooter(goldVersion, qrCodeLink, goOS, goArch string) string
+	Text_GeneratedPageFooter(goldVersion, qrCodeLink, buildConfig string) string
 }
 
 func (ds *docServer) currentSettings() (Theme, Translation) {
-	ds.mutex.Lock()
-	defer ds.mutex.Unlock()
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
 
 	return ds.currentTheme, ds.currentTranslation
 }
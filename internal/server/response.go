@@ -0,0 +1,197 @@
+package server
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The write timeout is applied to each write on a connection instead of
+// a whole response (as the http.Server.WriteTimeout field does), so that
+// large pages can be streamed through slow links without being cut off.
+const connWriteTimeout = 5 * time.Second
+
+type writeTimeoutListener struct {
+	net.Listener
+}
+
+func (l writeTimeoutListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return writeTimeoutConn{c}, nil
+}
+
+type writeTimeoutConn struct {
+	net.Conn
+}
+
+func (c writeTimeoutConn) Write(b []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(connWriteTimeout))
+	return c.Conn.Write(b)
+}
+
+// Pages are written chunk by chunk, to make each write short enough.
+const pageWriteChunkSize = 32 * 1024
+
+// Small pages are not worth compressing.
+const minCompressSize = 1024
+
+// pageETag derives an ETag from the analysis snapshot and the page key.
+// The current theme and translation are also involved, for pages are
+// built with them. The returned ETag can be used before the page is built.
+func (ds *docServer) pageETag(pageKey interface{}) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%#v|%s|%s|%s", pageKey, ds.goldVersion, ds.currentTheme.Name(), ds.currentTranslation.Name())
+	return fmt.Sprintf(`"%x-%x"`, ds.analyzedTime.UnixNano(), h.Sum64())
+}
+
+// checkNotModified writes a 304 response and returns true
+// if the If-None-Match request header matches the etag.
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag string) bool {
+	if genDocsMode {
+		return false
+	}
+
+	inm := r.Header.Get("If-None-Match")
+	if inm == "" {
+		return false
+	}
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// writePageContent writes a built page, compressed if the client accepts
+// it. The page is written chunk by chunk (see writeTimeoutConn).
+func (ds *docServer) writePageContent(w http.ResponseWriter, r *http.Request, etag string, content []byte) {
+	if genDocsMode {
+		w.Write(content)
+		return
+	}
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Last-Modified", ds.analyzedTime.UTC().Format(http.TimeFormat))
+	// Pages are always revalidated, for settings might be changed.
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "Accept-Encoding")
+
+	var out io.Writer = w
+	var encoding string
+	if len(content) >= minCompressSize {
+		encoding = negotiateContentEncoding(r.Header.Get("Accept-Encoding"))
+	}
+	switch encoding {
+	case "gzip":
+		gw := gzipWriterPool.Get().(*gzip.Writer)
+		gw.Reset(w)
+		defer func() {
+			gw.Close()
+			gzipWriterPool.Put(gw)
+		}()
+		out = gw
+	case "deflate":
+		zw := zlibWriterPool.Get().(*zlib.Writer)
+		zw.Reset(w)
+		defer func() {
+			zw.Close()
+			zlibWriterPool.Put(zw)
+		}()
+		out = zw
+	default:
+		header.Set("Content-Length", strconv.Itoa(len(content)))
+	}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	flusher, _ := w.(http.Flusher)
+	for len(content) > 0 {
+		n := len(content)
+		if n > pageWriteChunkSize {
+			n = pageWriteChunkSize
+		}
+		if _, err := out.Write(content[:n]); err != nil {
+			return // the client has gone
+		}
+		content = content[n:]
+		if flusher != nil && len(content) > 0 {
+			flusher.Flush()
+		}
+	}
+}
+
+var gzipWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return w
+	},
+}
+
+var zlibWriterPool = sync.Pool{
+	New: func() interface{} {
+		w, _ := zlib.NewWriterLevel(nil, zlib.BestSpeed)
+		return w
+	},
+}
+
+// negotiateContentEncoding returns "gzip", "deflate" or "" (identity)
+// by the Accept-Encoding request header. A "*" item applies to the
+// encodings not listed explicitly, and a zero quality value means
+// "not acceptable". gzip is preferred when the two encodings have
+// the same quality value.
+func negotiateContentEncoding(acceptEncoding string) string {
+	var gzipQ, deflateQ, anyQ = -1.0, -1.0, -1.0
+	for _, item := range strings.Split(acceptEncoding, ",") {
+		parts := strings.Split(item, ";")
+		coding := strings.ToLower(strings.TrimSpace(parts[0]))
+		q := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				v, err := strconv.ParseFloat(param[2:], 64)
+				if err != nil {
+					v = 0
+				}
+				q = v
+			}
+		}
+		switch coding {
+		case "gzip", "x-gzip":
+			gzipQ = q
+		case "deflate":
+			deflateQ = q
+		case "*":
+			anyQ = q
+		}
+	}
+	if gzipQ < 0 {
+		gzipQ = anyQ
+	}
+	if deflateQ < 0 {
+		deflateQ = anyQ
+	}
+	switch {
+	case gzipQ > 0 && gzipQ >= deflateQ:
+		return "gzip"
+	case deflateQ > 0:
+		return "deflate"
+	}
+	return ""
+}
@@ -5,7 +5,6 @@ import (
 	"io"
 	"log"
 	"math/rand"
-	"net"
 	"net/http"
 	"os"
 	"strconv"
@@ -30,7 +29,9 @@ const (
 )
 
 type docServer struct {
-	mutex sync.Mutex
+	// Pages are built when holding the read lock,
+	// so that they can be built concurrently.
+	mutex sync.RWMutex
 
 	goldVersion string
 
@@ -42,19 +43,34 @@ type docServer struct {
 
 	//
 	phase           int
+	analyzedTime    time.Time
+	analyzedArgs    []string
+	parseOptions    code.ParseOptions
 	analyzer        *code.CodeAnalyzer
 	analyzingLogger *log.Logger
-	analyzingLogs   []LoadingLogMessage
+
+	// Analyzing log messages are registered and read without
+	// holding the above mutex, so that the loading pages and
+	// api:load streams are never blocked by page building.
+	logsMutex     sync.Mutex
+	analyzingLogs []LoadingLogMessage
+
+	// Closed and renewed when a new analyzing log message is registered.
+	analyzingLogsChanged chan struct{}
+
+	// The current analysis result is still served when reloading.
+	reloading    bool
+	reloadFailed bool
 
 	// Cached pages
-	theCSSFile         cssFile
-	theOverviewPage    *overviewPage
-	theStatisticsPage  []byte
-	packagePages       map[string]packagePage
-	implPages          map[implPageKey][]byte
-	identifierUsePages map[usePageKey][]byte
-	sourcePages        map[sourcePageKey][]byte
-	dependencyPages    map[string][]byte
+	theCSSFile   cssFile
+	cacheOptions CacheOptions
+	pages        *pageCache
+
+	// The last used page options.
+	optionsMutex       sync.Mutex
+	overviewSortBy     string
+	packagePageOptions map[string]packagePageOptions
 
 	//
 	currentTheme       Theme
@@ -64,25 +80,50 @@ type docServer struct {
 	updateLogger          *log.Logger
 	roughBuildTime        func() time.Time
 	updateTip             int
-	cachedUpdateTip       int
 	newerVersionInstalled bool
 
 	//
 	generalLogger *log.Logger
 	visited       int32
+
+	// Mutating requests, such as updating Gold, are refused if it is false.
+	allowMutations bool
+
+	// Non-blank for servers of workspaces.
+	// urlPrefix is like "/w/name". It is only used in redirections
+	// and JavaScript code, for hrefs in pages are all relative.
+	workspaceName   string
+	urlPrefix       string
+	analyzingFailed bool
+}
+
+func Run(recommendedPort, lang string, args []string, options code.ParseOptions, cacheOptions CacheOptions, access util.ServerAccess, allowMutations bool, silentMode bool, goldVersion string, printUsage func(io.Writer), roughBuildTime func() time.Time) {
+	ds := newDocServer(lang, options, cacheOptions, allowMutations, goldVersion, roughBuildTime)
+
+	listenAndServe(recommendedPort, ds, access, silentMode, func(port string) {
+		go func() {
+			ds.analyze(args, printUsage)
+			ds.analyzingLogger.SetPrefix("")
+			serverStarted := ds.currentTranslationSafely().Text_Server_Started()
+			ds.analyzingLogger.Printf("%s %s\n", serverStarted, access.URL(port))
+		}()
+	})
 }
 
-func Run(recommendedPort, lang string, args []string, silentMode bool, goldVersion string, printUsage func(io.Writer), roughBuildTime func() time.Time) {
+func newDocServer(lang string, options code.ParseOptions, cacheOptions CacheOptions, allowMutations bool, goldVersion string, roughBuildTime func() time.Time) *docServer {
 	ds := &docServer{
 		goldVersion: goldVersion,
 
 		phase:           Phase_Unprepared,
-		analyzer:        &code.CodeAnalyzer{},
+		parseOptions:    options,
+		cacheOptions:    cacheOptions,
 		analyzingLogger: log.New(os.Stdout, "[Analyzing] ", 0),
 		analyzingLogs:   make([]LoadingLogMessage, 0, 64),
 
 		updateLogger:   log.New(os.Stdout, "[Update] ", 0),
 		roughBuildTime: roughBuildTime,
+
+		allowMutations: allowMutations,
 	}
 
 	ds.initSettings(os.Getenv("LANG"))
@@ -92,6 +133,12 @@ func Run(recommendedPort, lang string, args []string, silentMode bool, goldVersi
 		ds.changeTranslationByAcceptLanguage(lang)
 	}
 
+	return ds
+}
+
+// listenAndServe selects an available port (the recommended one is tried
+// firstly) to serve the handler. onListening is called before serving.
+func listenAndServe(recommendedPort string, handler http.Handler, access util.ServerAccess, silentMode bool, onListening func(port string)) {
 	port, delta := recommendedPort, -1
 	defaultPort, err := strconv.Atoi(recommendedPort)
 	if err != nil {
@@ -110,7 +157,7 @@ func Run(recommendedPort, lang string, args []string, silentMode bool, goldVersi
 	}
 
 NextTry:
-	l, err := net.Listen("tcp", fmt.Sprintf(":%v", port))
+	l, err := access.Listen(port)
 	if err != nil {
 		if strings.Index(err.Error(), "bind: address already in use") >= 0 {
 			defaultPort += delta
@@ -121,25 +168,20 @@ NextTry:
 		log.Fatal(err)
 	}
 
-	go func() {
-		ds.analyze(args, printUsage)
-		ds.analyzingLogger.SetPrefix("")
-		serverStarted := ds.currentTranslationSafely().Text_Server_Started()
-		ds.analyzingLogger.Printf("%s http://localhost:%v\n", serverStarted, port)
-	}()
+	onListening(port)
 
 	if !silentMode {
-		err = util.OpenBrowser(fmt.Sprintf("http://localhost:%v", port))
+		err = util.OpenBrowser(access.URL(port))
 		if err != nil {
 			log.Println(err)
 		}
 	}
 
+	// The write timeout is applied by writeTimeoutListener.
 	(&http.Server{
-		Handler:      ds,
-		WriteTimeout: 5 * time.Second,
-		ReadTimeout:  5 * time.Second,
-	}).Serve(l)
+		Handler:     access.Handler(handler),
+		ReadTimeout: 5 * time.Second,
+	}).Serve(writeTimeoutListener{l})
 }
 
 func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -164,10 +206,25 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			w.WriteHeader(http.StatusNotFound)
 			fmt.Fprint(w, "Invalid url")
 		case "update":
+			if !ds.allowMutations {
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, "Updating is not enabled. Please restart the server with the -allow-mutations flag to enable it.")
+				return
+			}
 			ds.startUpdatingGold()
-			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+			http.Redirect(w, r, ds.urlPrefix+"/", http.StatusTemporaryRedirect)
 		case "statistics":
-			ds.statisticsPage(w, r)
+			ds.statisticsPage(w, r, "")
+		case "hotspots":
+			ds.hotspotsPage(w, r)
+		case "unused":
+			ds.unusedIdentifiersPage(w, r)
+		case "unreachable":
+			ds.unreachableFunctionsPage(w, r)
+		case "deprecated":
+			ds.deprecatedUsesPage(w, r)
+		case "reload":
+			ds.reloadPage(w, r)
 		}
 		return
 	}
@@ -183,8 +240,12 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprint(w, "Invalid url")
 		case "update":
 			ds.updateAPI(w, r)
+		case "reload":
+			ds.reloadAPI(w, r)
 		case "load":
 			ds.loadAPI(w, r)
+		case "cache":
+			ds.cacheAPI(w, r)
 		}
 	case ResTypeCSS: // "css"
 		ds.cssFile(w, r, removeVersionFromFilename(resPath, ds.goldVersion))
@@ -216,6 +277,8 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		} else {
 			ds.methodImplementationPage(w, r, resPath[:index], resPath[index+1:])
 		}
+	case ResTypeStatistics: // "sta"
+		ds.statisticsPage(w, r, resPath)
 	case ResTypeUse: // "use"
 		index := strings.LastIndex(resPath, ".")
 		if index < 0 {
@@ -228,31 +291,55 @@ func (ds *docServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
+	if ds.runAnalyzing(args, ds.parseOptions, false) {
+		return
+	}
+
+	if ds.workspaceName == "" {
+		if printUsage != nil {
+			printUsage(os.Stdout)
+		}
+		os.Exit(1)
+	}
+
+	// The other workspaces are still being served.
+	ds.mutex.Lock()
+	ds.analyzingFailed = true
+	ds.mutex.Unlock()
+	ds.registerAnalyzingLogMessage(func() string {
+		return ds.currentTranslation.Text_WorkspaceStatus("failed")
+	})
+}
+
+// runAnalyzing analyzes the specified packages with a new analyzer.
+// The current analyzer (if it exists) is replaced only if analyzing succeeds.
+// If tolerateErrors is true, package errors are tolerated in this analyzing
+// only. It doesn't affect the options recorded for later analyzing.
+func (ds *docServer) runAnalyzing(args []string, options code.ParseOptions, tolerateErrors bool) bool {
 	var stopWatch = util.NewStopWatch()
-	defer func() {
-		d := stopWatch.Duration(false)
-		memUsed := util.MemoryUse()
-		ds.registerAnalyzingLogMessage(func() string {
-			return ds.currentTranslation.Text_Analyzing_Done(d, memUsed)
-		})
-		ds.registerAnalyzingLogMessage(func() string { return "" })
-	}()
 
 	if len(args) == 0 {
 		args = []string{"."}
-	} else if len(args) == 1 && args[0] == "std" {
-		os.Setenv("CGO_ENABLED", "0")
+	} else if len(args) == 1 && args[0] == "std" && options.CgoEnabled == "" {
+		// Cgo is not needed to show the docs of standard packages.
+		options.CgoEnabled = "0"
 	}
 
 	ds.registerAnalyzingLogMessage(func() string {
 		return ds.currentTranslationSafely().Text_Analyzing_Start()
 	})
 
-	if !ds.analyzer.ParsePackages(ds.onAnalyzingSubTaskDone, args...) {
-		if printUsage != nil {
-			printUsage(os.Stdout)
-		}
-		os.Exit(1)
+	var analyzer = &code.CodeAnalyzer{}
+	var onSubTaskDone = func(task int, d time.Duration, args ...int32) {
+		ds.onAnalyzingSubTaskDone(analyzer, task, d, args...)
+	}
+
+	parseOptions := options
+	if tolerateErrors {
+		parseOptions.TolerateErrors = true
+	}
+	if !analyzer.ParsePackages(onSubTaskDone, parseOptions, args...) {
+		return false
 	}
 
 	//{
@@ -261,16 +348,27 @@ func (ds *docServer) analyze(args []string, printUsage func(io.Writer)) {
 	//	ds.mutex.Unlock()
 	//}
 
-	ds.analyzer.AnalyzePackages(ds.onAnalyzingSubTaskDone)
+	analyzer.AnalyzePackages(onSubTaskDone)
 
 	{
 		ds.mutex.Lock()
+		ds.analyzer = analyzer
+		ds.analyzedArgs = args
+		ds.parseOptions = options
 		ds.phase = Phase_Analyzed
-		ds.packagePages = make(map[string]packagePage, ds.analyzer.NumPackages())
-		ds.implPages = make(map[implPageKey][]byte, ds.analyzer.RoughTypeNameCount())
-		ds.identifierUsePages = make(map[usePageKey][]byte, ds.analyzer.RoughExportedIdentifierCount())
-		ds.sourcePages = make(map[sourcePageKey][]byte, ds.analyzer.NumSourceFiles())
-		ds.dependencyPages = make(map[string][]byte, ds.analyzer.NumPackages())
+		ds.analyzedTime = time.Now()
+		ds.pages = newPageCache(analyzer.NumPackages()*2+analyzer.NumSourceFiles(), ds.cacheOptions)
+		ds.packagePageOptions = make(map[string]packagePageOptions, analyzer.NumPackages())
+		ds.reloading = false
 		ds.mutex.Unlock()
 	}
+
+	d := stopWatch.Duration(false)
+	memUsed := util.MemoryUse()
+	ds.registerAnalyzingLogMessage(func() string {
+		return ds.currentTranslation.Text_Analyzing_Done(d, memUsed)
+	})
+	ds.registerAnalyzingLogMessage(func() string { return "" })
+
+	return true
 }
@@ -11,6 +11,10 @@ body {color: #333; font-family: {{ .Fonts }};}
 a {color: #079;}
 a.path-duplicate {color: #9cd;}
 .module-version {color: #555; font-style: italic; font-size: smaller; text-decoration: none;}
+.error-badge {color: #fff; background: #c33; font-size: smaller; padding: 0 3px; text-decoration: none;}
+.error-message {color: #c33;}
+.complexity-badge {color: #fff; background: #c80; font-size: smaller; padding: 0 3px;}
+.complexity-badge.high {background: #c33;}
 ol.package-list {line-height: 139%;}
 h3 {background: #ddd;}
 
@@ -58,6 +62,10 @@ hr {color: #888;}
 
 .anchor {}
 .codeline {}
+.codeline.unreachable, .codeline.unreachable * {color: #ccc;}
+
+.deprecated {text-decoration: line-through;}
+.deprecation-note {color: #888;}
 
 .codeline:target, .anchor:target {border-top: 1px solid #d5ddbb; border-bottom: 1px solid #d5ddbb; background-color: #e5eecc;}
 
@@ -69,6 +77,8 @@ code .lit-number {color: #e66;}
 code .lit-string {color: #a66;}
 code .keyword {color: brown;}
 code .comment {color: green; font-style: italic;}
+code .asm-register {color: #a0a;}
+code .asm-pseudo-register {color: #a0a; font-weight: bold;}
 
 #header {
 	padding-bottom: 8px;
@@ -10,6 +10,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -27,7 +28,11 @@ var (
 	goldVersion    string
 	pageHrefList   *list.List // elements are *string
 	resHrefs       map[pageResType]map[string]int
-	pageHrefsMutex sync.Mutex // in fact, for the current implementation, the lock is not essential
+	pageHrefsMutex sync.Mutex
+
+	// Signaled when a page href is registered or a page is loaded.
+	pageHrefsCond   = sync.NewCond(&pageHrefsMutex)
+	numLoadingPages int
 )
 
 func enabledHtmlGenerationMod(goldVer string) {
@@ -52,16 +57,35 @@ func registerPageHref(info genPageInfo) {
 	pageHrefsMutex.Lock()
 	defer pageHrefsMutex.Unlock()
 	pageHrefList.PushBack(&info)
+	pageHrefsCond.Signal()
 }
 
+// nextPageToLoad returns nil when all pages are loaded.
+// Pages being loaded might register more pages to load,
+// so it waits for them when there are no pending pages.
+// A non-nil result must be followed by a pageLoaded call.
 func nextPageToLoad() (info *genPageInfo) {
 	pageHrefsMutex.Lock()
 	defer pageHrefsMutex.Unlock()
-	if front := pageHrefList.Front(); front != nil {
-		info = front.Value.(*genPageInfo)
-		pageHrefList.Remove(front)
+	for {
+		if front := pageHrefList.Front(); front != nil {
+			info = front.Value.(*genPageInfo)
+			pageHrefList.Remove(front)
+			numLoadingPages++
+			return
+		}
+		if numLoadingPages == 0 {
+			return nil
+		}
+		pageHrefsCond.Wait()
 	}
-	return
+}
+
+func pageLoaded() {
+	pageHrefsMutex.Lock()
+	defer pageHrefsMutex.Unlock()
+	numLoadingPages--
+	pageHrefsCond.Broadcast()
 }
 
 // Return the id and whether or not the id is just registered.
@@ -90,6 +114,7 @@ var resType2ExtTable = map[pageResType]string{
 	ResTypeSource:         ".html",
 	ResTypeModule:         ".html",
 	ResTypeUse:            ".html",
+	ResTypeStatistics:     ".html",
 	ResTypeCSS:            ".css",
 	ResTypeJS:             ".js",
 	ResTypeSVG:            ".svg",
@@ -194,6 +219,21 @@ func PreviousVersion(version string) string {
 //
 // src:handledPath will be hashed as the generated path, or not.
 
+// docsRootHref returns the relative href from the current page to the docs root.
+// It is "./" for pages at the root level, to avoid "pkg:xxx" alike hrefs
+// being viewed as urls with schemes.
+func docsRootHref(currentPageInfo pagePathInfo) string {
+	n := strings.Count(currentPageInfo.resPath, "/")
+	if n == 0 {
+		return "./"
+	}
+	return DotDotSlashes(n)
+}
+
+func writeDocsRootHref(page *htmlPage, currentPageInfo pagePathInfo) {
+	page.WriteString(docsRootHref(currentPageInfo))
+}
+
 // If page is not nil, write the href directly into page (write the full <a...</a> if linkText is not blank).
 // Otherwise, build the href as a string and return it (only the href part).
 // inRootPage is for generation mode only. inRootPage==false means in "pages/xxx" pages.
@@ -205,25 +245,27 @@ func buildPageHref(currentPageInfo, linkedPageInfo pagePathInfo, page *htmlPage,
 		goto Generate
 	}
 
+	// The hrefs are relative to the docs root, so that the docs of a
+	// workspace can be served under a url prefix, such as "/w/name/".
 	if linkedPageInfo.resType == ResTypeNone {
 		if page != nil {
 			page.writePageLink(func() {
-				page.WriteByte('/')
+				writeDocsRootHref(page, currentPageInfo)
 				page.WriteString(linkedPageInfo.resPath)
 			}, linkText, fragments...)
 		} else {
-			r = "/" + linkedPageInfo.resPath
+			r = docsRootHref(currentPageInfo) + linkedPageInfo.resPath
 		}
 	} else {
 		if page != nil {
 			page.writePageLink(func() {
-				page.WriteByte('/')
+				writeDocsRootHref(page, currentPageInfo)
 				page.WriteString(string(linkedPageInfo.resType))
 				page.WriteByte(':')
 				page.WriteString(linkedPageInfo.resPath)
 			}, linkText, fragments...)
 		} else {
-			r = "/" + string(linkedPageInfo.resType) + ":" + linkedPageInfo.resPath
+			r = docsRootHref(currentPageInfo) + string(linkedPageInfo.resType) + ":" + linkedPageInfo.resPath
 		}
 	}
 
@@ -293,16 +335,18 @@ Generate:
 	return
 }
 
-func GenDocs(outputDir string, args []string, lang string, silent bool, goldVersion string, printUsage func(io.Writer), viewDocsCommand func(string) string) {
+func GenDocs(outputDir string, args []string, options code.ParseOptions, lang string, silent bool, goldVersion string, printUsage func(io.Writer), viewDocsCommand func(string) string) {
 	enabledHtmlGenerationMod(goldVersion)
 	forTesting := outputDir == ""
 	silent = silent || forTesting
 	//
 
+	// Each page is only visited once in generation mode,
+	// so the zero CacheOptions (not to keep pages) is used.
 	ds := &docServer{
-		goldVersion: goldVersion,
-		phase:       Phase_Unprepared,
-		analyzer:    &code.CodeAnalyzer{},
+		goldVersion:  goldVersion,
+		phase:        Phase_Unprepared,
+		parseOptions: options,
 	}
 	ds.initSettings(lang)
 	ds.analyze(args, printUsage)
@@ -323,34 +367,44 @@ func GenDocs(outputDir string, args []string, lang string, silent bool, goldVers
 
 	buildPageHref(pagePathInfo{ResTypeNone, ""}, pagePathInfo{ResTypeNone, ""}, nil, "") // the overview page
 
-	// page loader
-	go func() {
-		for {
-			info := nextPageToLoad()
-			if info == nil {
-				break
-			}
+	// page loaders (pages are built concurrently)
+	var numLoaders = runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	wg.Add(numLoaders)
+	for range make([]struct{}, numLoaders) {
+		go func() {
+			defer wg.Done()
+			for {
+				info := nextPageToLoad()
+				if info == nil {
+					break
+				}
 
-			res, err := http.Get(fakeServer.URL + info.HrefPath)
-			if err != nil {
-				log.Fatal(err)
-			}
+				res, err := http.Get(fakeServer.URL + info.HrefPath)
+				if err != nil {
+					log.Fatal(err)
+				}
 
-			if res.StatusCode != http.StatusOK {
-				log.Fatalf("visit %s, get non-ok status code: %d", info.HrefPath, res.StatusCode)
-			}
+				if res.StatusCode != http.StatusOK {
+					log.Fatalf("visit %s, get non-ok status code: %d", info.HrefPath, res.StatusCode)
+				}
 
-			content, err := ioutil.ReadAll(res.Body)
-			res.Body.Close()
-			if err != nil {
-				log.Fatal(err)
-			}
+				content, err := ioutil.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					log.Fatal(err)
+				}
 
-			pages <- Page{
-				FilePath: info.FilePath,
-				Content:  content,
+				pages <- Page{
+					FilePath: info.FilePath,
+					Content:  content,
+				}
+				pageLoaded()
 			}
-		}
+		}()
+	}
+	go func() {
+		wg.Wait()
 		close(pages)
 	}()
 
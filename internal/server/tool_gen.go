@@ -4,9 +4,11 @@ import (
 	"io"
 	"log"
 	"os"
+
+	"go101.org/gold/code"
 )
 
-func Gen(intent, outputDir, lang string, args []string, silent bool, goldVersion string, printUsage func(io.Writer), viewDocsCommand func(string) string) {
+func Gen(intent, outputDir, lang string, args []string, options code.ParseOptions, silent bool, goldVersion string, printUsage func(io.Writer), viewDocsCommand func(string) string) {
 	log.SetFlags(0)
 
 	// ...
@@ -18,7 +20,7 @@ func Gen(intent, outputDir, lang string, args []string, silent bool, goldVersion
 		log.Println("Unknown gen intent:", intent)
 		printUsage(os.Stdout)
 	case "docs":
-		GenDocs(outputDir, args, lang, silent, goldVersion, printUsage, viewDocsCommand)
+		GenDocs(outputDir, args, options, lang, silent, goldVersion, printUsage, viewDocsCommand)
 	case "testdata":
 		GenTestData(outputDir, args, silent, goldVersion, printUsage)
 	}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+
+	"go101.org/gold/code"
+)
+
+// StatisticsReport is the output of the statistics exporting mode.
+type StatisticsReport struct {
+	GoldVersion string
+	Args        []string
+	Stats       code.Stats
+	Packages    []code.PackageMetrics
+}
+
+// ExportStatistics analyzes the packages specified by args and
+// writes the statistics in the specified format ("json" or "csv")
+// to out. No servers are started.
+func ExportStatistics(format string, out io.Writer, args []string, options code.ParseOptions, goldVersion string) error {
+	switch format {
+	case "json", "csv":
+	default:
+		return fmt.Errorf("unknown statistics format: %s (json or csv is expected)", format)
+	}
+
+	// Analyzing logs are not shown, so that
+	// the output is not polluted.
+	ds := &docServer{
+		goldVersion:  goldVersion,
+		phase:        Phase_Unprepared,
+		parseOptions: options,
+	}
+	ds.initSettings("")
+	if !ds.runAnalyzing(args, options, false) {
+		return errors.New("failed to analyze packages")
+	}
+
+	report := StatisticsReport{
+		GoldVersion: goldVersion,
+		Args:        ds.analyzedArgs,
+		Stats:       ds.analyzer.Statistics(),
+		Packages:    ds.analyzer.PackageMetricsList(),
+	}
+	if format == "json" {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "\t")
+		return encoder.Encode(report)
+	}
+	return writeStatisticsCSV(out, &report)
+}
+
+// writeStatisticsCSV writes two CSV tables separated by a blank line.
+// The first one lists the global statistics as name-value rows
+// (array elements are named as "Name[i]"). The second one lists
+// the metrics of each package, one package per row.
+func writeStatisticsCSV(out io.Writer, report *StatisticsReport) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"Statistic", "Value"})
+	stats := reflect.ValueOf(report.Stats)
+	for i := 0; i < stats.NumField(); i++ {
+		field, f := stats.Type().Field(i), stats.Field(i)
+		if field.PkgPath != "" {
+			continue // unexported
+		}
+		switch name := field.Name; f.Kind() {
+		case reflect.Int32:
+			w.Write([]string{name, strconv.FormatInt(f.Int(), 10)})
+		case reflect.Array:
+			for k := 0; k < f.Len(); k++ {
+				w.Write([]string{fmt.Sprintf("%s[%d]", name, k), strconv.FormatInt(f.Index(k).Int(), 10)})
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(out, "\n"); err != nil {
+		return err
+	}
+
+	metricsType := reflect.TypeOf(code.PackageMetrics{})
+	header := make([]string, metricsType.NumField())
+	for i := range header {
+		header[i] = metricsType.Field(i).Name
+	}
+	w.Write(header)
+	for _, m := range report.Packages {
+		v := reflect.ValueOf(m)
+		row := make([]string, v.NumField())
+		for i := range row {
+			row[i] = fmt.Sprint(v.Field(i).Interface())
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
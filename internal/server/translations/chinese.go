@@ -51,6 +51,20 @@ func (*Chinese) Text_Analyzing_Start() string {
 	return "开始分析......"
 }
 
+func (*Chinese) Text_Analyzing_ListModules(numModules, numToDownload int, d time.Duration) string {
+	if numToDownload == 0 {
+		return fmt.Sprintf("列出了%d个模块，它们均已在本地模块缓存中：%s", numModules, d)
+	}
+	return fmt.Sprintf("列出了%d个模块，其中%d个需要下载：%s", numModules, numToDownload, d)
+}
+
+func (*Chinese) Text_Analyzing_DownloadModule(module string, numDones, numToDownload int, failed bool, d time.Duration) string {
+	if failed {
+		return fmt.Sprintf("（%d/%d）模块%s下载失败：%s", numDones, numToDownload, module, d)
+	}
+	return fmt.Sprintf("（%d/%d）模块%s下载完毕：%s", numDones, numToDownload, module, d)
+}
+
 func (*Chinese) Text_Analyzing_PreparationDone(d time.Duration) string {
 	return fmt.Sprintf("准备完毕：%s", d)
 }
@@ -103,6 +117,10 @@ func (*Chinese) Text_Analyzing_CollectSourceFiles(d time.Duration) string {
 	return fmt.Sprintf("搜集源文件：%s", d)
 }
 
+func (*Chinese) Text_Analyzing_FindUnreachableFunctions(d time.Duration) string {
+	return fmt.Sprintf("查找不可达函数：%s", d)
+}
+
 func (*Chinese) Text_Analyzing_Done(d time.Duration, memoryUse string) string {
 	return fmt.Sprintf("分析完毕（共用时%s，最终消耗内存%s）", d, memoryUse)
 }
@@ -117,8 +135,11 @@ func (*Chinese) Text_PackageList() string {
 	return "代码包列表"
 }
 
-func (*Chinese) Text_StatisticsWithMoreLink(detailedStatsLink string) string {
-	return fmt.Sprintf(`统计信息（<a href="%s">更多详细信息</a>）`, detailedStatsLink)
+func (*Chinese) Text_StatisticsWithMoreLink(detailedStatsLink, mainPackagesStatsLink string) string {
+	if mainPackagesStatsLink == "" {
+		return fmt.Sprintf(`统计信息（<a href="%s">更多详细信息</a>）`, detailedStatsLink)
+	}
+	return fmt.Sprintf(`统计信息（<a href="%s">更多详细信息</a>，<a href="%s">仅主代码包</a>）`, detailedStatsLink, mainPackagesStatsLink)
 }
 
 func (*Chinese) Text_SimpleStats(stats *code.Stats) string {
@@ -145,6 +166,8 @@ func (*Chinese) Text_SimpleStats(stats *code.Stats) string {
 
 func (*Chinese) Text_Modules() string { return "模块列表" }
 
+func (*Chinese) Text_BuildConfiguration() string { return "构建配置" }
+
 func (*Chinese) Text_BelongingModule() string { return "所属模块" }
 
 func (*Chinese) Text_RequireStat(numRequires, numRequiredBys int) string {
@@ -154,7 +177,7 @@ func (*Chinese) Text_RequireStat(numRequires, numRequiredBys int) string {
 func (*Chinese) Text_UpdateTip(tipName string) string {
 	switch tipName {
 	case "ToUpdate":
-		return `<b>Gold</b>已经有一个多月没有更新了，运行<b>go get -u go101.org/gold</b>或者<b><a href="/update">点击</a></b>来更新它。`
+		return `<b>Gold</b>已经有一个多月没有更新了，运行<b>go get -u go101.org/gold</b>或者<b><a href="update">点击</a></b>来更新它。`
 	case "Updating":
 		return `<b>Gold</b>正在被更新中.....`
 	case "Updated":
@@ -163,6 +186,10 @@ func (*Chinese) Text_UpdateTip(tipName string) string {
 	return ""
 }
 
+func (*Chinese) Text_NumErrors(num int) string {
+	return fmt.Sprintf("%d个错误", num)
+}
+
 func (*Chinese) Text_SortBy() string {
 	return "排序依据："
 }
@@ -233,6 +260,125 @@ func (*Chinese) Text_ImportStat(numImports, numImportedBys int, depPageURL strin
 
 func (*Chinese) Text_InvolvedFiles(num int) string { return "相关源文件" }
 
+func (*Chinese) Text_IgnoredFiles(num int) string { return "被忽略的源文件" }
+
+func (*Chinese) Text_CgoInterface(num int) string {
+	return fmt.Sprintf("cgo接口（使用了%d个C符号）", num)
+}
+
+func (*Chinese) Text_CgoSymbol(kind string, numUses int, declared bool) string {
+	switch kind {
+	case "func":
+		kind = "函数"
+	case "type":
+		kind = "类型"
+	case "var":
+		kind = "变量"
+	case "const":
+		kind = "常量"
+	case "macro":
+		kind = "宏"
+	}
+	var s = fmt.Sprintf("%s，使用了%d次", kind, numUses)
+	if !declared {
+		s += "，声明在系统头文件中或由cgo提供"
+	}
+	return s
+}
+
+func (*Chinese) Text_ImplementedInAssembly() string { return "由汇编实现" }
+
+func (*Chinese) Text_IgnoredFileReason(reason string) string {
+	switch reason {
+	case "filename":
+		return "文件名后缀不匹配"
+	case "constraint":
+		return "构建约束不满足"
+	case "cgo":
+		return "cgo被禁用"
+	}
+	return reason
+}
+
+func (*Chinese) Text_LineStatistics(lines, generatedLines code.LineStats) string {
+	text := fmt.Sprintf("%d行代码，%d行注释，%d个空行", lines.Code, lines.Comment, lines.Blank)
+	if n := generatedLines.Total(); n > 0 {
+		text += fmt.Sprintf("（另有生成的文件中的%d行）", n)
+	}
+	return text
+}
+
+func (*Chinese) Text_DetailedStatisticsLinks(packageStatsLink, moduleStatsLink string) string {
+	if moduleStatsLink == "" {
+		return fmt.Sprintf(`详细统计：<a href="%s">此代码包</a>`, packageStatsLink)
+	}
+	return fmt.Sprintf(`详细统计：<a href="%s">此代码包</a>，<a href="%s">所属模块</a>`, packageStatsLink, moduleStatsLink)
+}
+
+func (*Chinese) Text_Diagnostics(numErrors int) string {
+	return fmt.Sprintf("诊断信息（%d个错误）", numErrors)
+}
+
+func (*Chinese) Text_BuiltinFunctions(num int) string {
+	return fmt.Sprintf("内置函数（%d个）", num)
+}
+
+func (*Chinese) Text_BuiltinFunctionExplanation(name string) string {
+	switch name {
+	case "append":
+		return "将若干元素添加到一个切片的尾部并返回结果切片。如果此切片的容量不足，一个新的底层数组将被开辟。"
+	case "cap":
+		return "返回一个切片、通道或者数组的容量。如果实参为一个数组或者数组指针并且实参表达式中不含通道接收操作和函数调用，则结果为一个常量。"
+	case "clear":
+		return "删除一个映射中的所有条目，或者将一个切片中的所有元素置为零值。"
+	case "close":
+		return "关闭一个通道。关闭一个nil通道或者已关闭的通道将产生恐慌。向一个已关闭的通道发送数据将产生恐慌，从中接收数据则永不阻塞。"
+	case "complex":
+		return "使用两个同类型的浮点数值构造一个复数值。"
+	case "copy":
+		return "将一个源切片（或者字符串）中的元素复制到一个目标切片中。返回被复制的元素的个数，此个数为两个实参的长度中的较小者。"
+	case "delete":
+		return "从一个映射中删除指定键值对应的条目。如果此映射为nil或者此条目不存在，则此调用为一个空操作。"
+	case "imag":
+		return "返回一个复数值的虚部。"
+	case "len":
+		return "返回一个字符串、切片、映射、通道或者数组的长度。如果实参为一个常量字符串或者一个不含通道接收操作和函数调用的数组（或数组指针）表达式，则结果为一个常量。"
+	case "make":
+		return "开辟并初始化一个切片、映射或者通道。尺寸实参可以为任何整数类型的值或者无类型常量，并且它们必须为非负数。"
+	case "max":
+		return "返回实参中的最大者。所有实参必须为同一个有序类型。"
+	case "min":
+		return "返回实参中的最小者。所有实参必须为同一个有序类型。"
+	case "new":
+		return "开辟一个指定类型的零值并返回指向它的指针。"
+	case "panic":
+		return "停止当前协程的正常执行。被延迟的函数调用仍将被执行，在其中此恐慌可以被恢复。"
+	case "print", "println":
+		return "将实参输出到标准错误中。它们用于调试和自举，并不保证将来会一直保留在语言中。"
+	case "real":
+		return "返回一个复数值的实部。"
+	case "recover":
+		return "恢复当前处于恐慌状态的协程。只有在一个延迟函数调用中被直接调用时它才有效，否则它返回nil。"
+	}
+	return ""
+}
+
+func (*Chinese) Text_PredeclaredTypes(num int) string {
+	return fmt.Sprintf("预声明类型（%d个）", num)
+}
+
+func (*Chinese) Text_PredeclaredValues(num int) string {
+	return fmt.Sprintf("预声明值（%d个）", num)
+}
+
+func (*Chinese) Text_Example() string { return "例子" }
+
+func (*Chinese) Text_RuntimeImplementation() string { return "实现于" }
+
+func (*Chinese) Text_AliasedBy(num int) string {
+	return fmt.Sprintf("%d个导出类型别名", num)
+}
+
 func (*Chinese) Text_ExportedValues(num int) string {
 	return "导出值"
 }
@@ -332,6 +478,23 @@ func (*Chinese) Text_SourceFilePath() string { return "源文件：" }
 
 func (*Chinese) Text_GeneratedFrom() string { return "从此文件生成" }
 
+func (*Chinese) Text_BuildConstraint() string { return "构建约束" }
+
+func (*Chinese) Text_IgnoredFileNote(reason, buildConfig string) string {
+	var why string
+	switch reason {
+	case "filename":
+		why = "它的GOOS/GOARCH文件名后缀不匹配"
+	case "constraint":
+		why = "它的构建约束不满足"
+	case "cgo":
+		why = "它使用了cgo，但cgo被禁用"
+	default:
+		why = reason
+	}
+	return fmt.Sprintf("此文件在当前构建配置（%s）下被忽略，因为%s。它的显示不包含类型信息。", buildConfig, why)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -374,6 +537,14 @@ func (*Chinese) Text_ChartTitle(chartName string) string {
 		return "导出的非接口类型名数量按照导出方法数的分布"
 	case "exportedinterfacetypes-by-exportedmethods":
 		return "导出的接口类型名数量按照导出方法数的分布"
+	case "unexportedtypenames-by-kinds":
+		return "非导出的包级类型名数量按照类型种类的分布"
+	case "localtypenames-by-kinds":
+		return "局部类型名数量按照类型种类的分布"
+	case "allfunctions-by-parameters":
+		return "所有函数（包括方法和闭包）数量按照参数个数的分布"
+	case "allfunctions-by-results":
+		return "所有函数（包括方法和闭包）数量按照返回结果个数的分布"
 	default:
 		panic("unknown char name: " + chartName)
 	}
@@ -389,15 +560,33 @@ func (*Chinese) Text_StatisticsTitle(titleName string) string {
 		return "值（变量/常量/函数）"
 	case "others":
 		return "其它"
+	case "rankings":
+		return "排行榜"
+	case "lines":
+		return "代码行数"
 	default:
 		panic("unknown statistics tile: " + titleName)
 	}
 }
 
+func (*Chinese) Text_StatisticsScope(scope, name, link string) string {
+	switch scope {
+	case "main":
+		return "仅统计了命令行参数匹配的代码包。"
+	case "package":
+		return fmt.Sprintf(`仅统计了代码包<a href="%s">%s</a>。`, link, name)
+	case "module":
+		return fmt.Sprintf("仅统计了模块%s中的代码包。", name)
+	default:
+		panic("unknown statistics scope: " + scope)
+	}
+}
+
 func (*Chinese) Text_PackageStatistics(values map[string]interface{}) string {
 	return fmt.Sprintf(`
 	共<a href="%s">%d个库包</a>，其中%d个是标准库包。
 	共%d个源文件，其中%d个为Go源文件。
+	这些Go源文件中共有%d行代码、%d行注释和%d个空行（不包括生成的文件中的%d行）。
 	平均说来：
 	- 每个库包含有%.2f个源文件；
 	- 每个Go源文件引入了%.2f个库包；
@@ -411,6 +600,10 @@ func (*Chinese) Text_PackageStatistics(values map[string]interface{}) string {
 		values["standardPackageCount"],
 		values["sourceFileCount"],
 		values["goSourceFileCount"],
+		values["codeLineCount"],
+		values["commentLineCount"],
+		values["blankLineCount"],
+		values["generatedLineCount"],
 		values["averageSourceFileCountPerPackage"],
 		values["averageImportCountPerFile"],
 		values["averageDependencyCountPerPackage"],
@@ -526,23 +719,277 @@ func (*Chinese) Text_Othertatistics(values map[string]interface{}) string {
 	)
 }
 
+func (*Chinese) Text_Hotspots() string { return "热点函数" }
+
+func (*Chinese) Text_HotspotsNote(numListed, numAll int) string {
+	return fmt.Sprintf("主代码包中声明的%d个函数和方法中排名前%d的。", numAll, numListed)
+}
+
+func (*Chinese) Text_HotspotsItem(item string) string {
+	switch item {
+	case "complexity":
+		return "圈复杂度"
+	case "statements":
+		return "语句数"
+	case "nesting":
+		return "嵌套深度"
+	case "parameters":
+		return "参数数"
+	case "function":
+		return "函数"
+	default:
+		panic("unknown hotspots item: " + item)
+	}
+}
+
+func (*Chinese) Text_FunctionMetrics(complexity, statements, nesting, parameters int) string {
+	return fmt.Sprintf("圈复杂度：%d，语句数：%d，最大嵌套深度：%d，参数数：%d", complexity, statements, nesting, parameters)
+}
+
+func (*Chinese) Text_ComplexityBadge(complexity int) string {
+	return fmt.Sprintf("复杂度%d", complexity)
+}
+
+func (*Chinese) Text_UnusedIdentifiers() string {
+	return "未被其它代码包使用的导出标识符"
+}
+
+func (*Chinese) Text_UnusedIdentifiersNote() string {
+	return "主代码包中声明的但未被任何其它被分析的代码包引用的导出标识符。"
+}
+
+func (*Chinese) Text_UnusedIdentifiersOption(option string, asUsers bool) string {
+	var pkgs string
+	switch option {
+	case "tests":
+		pkgs = "测试文件"
+	case "mains":
+		pkgs = "main代码包"
+	default:
+		panic("unknown option: " + option)
+	}
+	if asUsers {
+		return pkgs + "被视为使用者。"
+	}
+	return pkgs + "不被视为使用者。"
+}
+
+func (*Chinese) Text_UnusedIdentifiersOptionToggle() string { return "（切换）" }
+
+func (*Chinese) Text_UnusedIdentifiersTestsNotLoaded() string {
+	return "测试文件未被加载。可以在重新分析页面中加载它们。"
+}
+
+func (*Chinese) Text_UnusedIdentifiersOfModule(moduleName string, num int) string {
+	return fmt.Sprintf("模块%s（%d个未被使用）", moduleName, num)
+}
+
+func (*Chinese) Text_MainModule() string { return "主模块" }
+
+func (*Chinese) Text_UnreachableFunctions() string {
+	return "不可达函数"
+}
+
+func (*Chinese) Text_UnreachableFunctionsNote() string {
+	return "主代码包中声明的但从main、init、测试和导出函数均不可达的函数和方法。仅通过反射调用的函数也将被列出。"
+}
+
+func (*Chinese) Text_UnreachableFunctionsOfModule(moduleName string, num int) string {
+	return fmt.Sprintf("模块%s（%d个不可达）", moduleName, num)
+}
+
+func (*Chinese) Text_UnreachableDeclaration() string { return "不可达" }
+
+func (*Chinese) Text_DeprecationNote(note string) string {
+	return "已弃用：" + note
+}
+
+func (*Chinese) Text_DeprecatedIdentifiers(num int) string {
+	return fmt.Sprintf("已弃用的标识符（%d）", num)
+}
+
+func (*Chinese) Text_DeprecatedIdentifiersShowOption(show bool) string {
+	if show {
+		return "显示已弃用的标识符"
+	} else {
+		return "隐藏已弃用的标识符"
+	}
+}
+
+func (*Chinese) Text_DeprecatedUses() string {
+	return "对已弃用API的使用"
+}
+
+func (*Chinese) Text_DeprecatedUsesNote(num int) string {
+	return fmt.Sprintf("主代码包中共有%d处使用了声明在其它代码包中的已弃用标识符。", num)
+}
+
+func (*Chinese) Text_StatisticsView(view string) string {
+	switch view {
+	case "exported":
+		return "导出的API"
+	case "whole":
+		return "全部代码"
+	default:
+		panic("unknown statistics view: " + view)
+	}
+}
+
+func (*Chinese) Text_WholeCodeTypeStatistics(values map[string]interface{}) string {
+	return fmt.Sprintf(`
+	除了%d个导出类型名之外，另有%d个非导出的包级类型名和%d个局部类型名。
+
+	<img src="%s"></image>
+	<img src="%s"></image>
+`,
+		values["exportedTypeNameCount"],
+		values["unexportedTypeNameCount"],
+		values["localTypeNameCount"],
+
+		values["unexportedtypenamesByKindsChartURL"],
+		values["localtypenamesByKindsChartURL"],
+	)
+}
+
+func (*Chinese) Text_WholeCodeValueStatistics(values map[string]interface{}) string {
+	return fmt.Sprintf(`
+	除了导出的之外，另有%d个非导出的包级变量和%d个非导出的包级常量。
+	其中%d个变量（包级或局部）的类型为函数类型。
+
+	除了导出的之外，另有%d个非导出函数、%d个导出类型的非导出方法、
+	%d个非导出类型的方法和%d个函数字面量（闭包）。
+	平均说来，这全部%d个函数、方法和闭包中的每个拥有%.2f个参数和%.2f个输出结果。
+
+	<img src="%s"></image>
+	<img src="%s"></image>
+`,
+		values["unexportedVariables"],
+		values["unexportedConstants"],
+		values["functionTypedVariables"],
+
+		values["unexportedFunctions"],
+		values["unexportedMethods"],
+		values["methodsOfUnexportedTypes"],
+		values["functionLiterals"],
+		values["allFunctions"],
+		values["averageParameterCountPerFunction"],
+		values["averageResultCountPerFunction"],
+
+		values["allfunctionsByParametersChartURL"],
+		values["allfunctionsByResultsChartURL"],
+	)
+}
+
+func (*Chinese) Text_LineStatsItem(item string) string {
+	switch item {
+	case "code":
+		return "代码"
+	case "comment":
+		return "注释"
+	case "blank":
+		return "空行"
+	case "generated":
+		return "生成的"
+	case "module":
+		return "模块"
+	case "package":
+		return "库包"
+	default:
+		panic("unknown line stats item: " + item)
+	}
+}
+
+func (*Chinese) Text_RankingTitle(rankingName string, n int) string {
+	switch rankingName {
+	case "most-implemented-interfaces":
+		return fmt.Sprintf("被实现次数最多的%d个导出接口类型", n)
+	case "types-with-most-methods":
+		return fmt.Sprintf("方法数最多的%d个导出非接口类型", n)
+	case "largest-packages-by-declarations":
+		return fmt.Sprintf("包级声明数最多的%d个库包", n)
+	case "largest-packages-by-lines":
+		return fmt.Sprintf("Go源代码行数最多的%d个库包", n)
+	case "most-imported-packages":
+		return fmt.Sprintf("被引入次数最多的%d个库包", n)
+	case "functions-with-most-parameters":
+		return fmt.Sprintf("输入参数最多的%d个导出函数/方法", n)
+	case "most-referenced-identifiers":
+		return fmt.Sprintf("在其它库包中被使用次数最多的%d个导出标识符", n)
+	default:
+		panic("unknown ranking: " + rankingName)
+	}
+}
+
+///////////////////////////////////////////////////////////////////
+// workspaces
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Workspaces() string { return "工作区" }
+
+func (*Chinese) Text_WorkspaceStatus(status string) string {
+	switch status {
+	case "unanalyzed":
+		return "尚未分析（首次访问时开始分析）"
+	case "analyzing":
+		return "分析中"
+	case "analyzed":
+		return "已分析"
+	case "failed":
+		return "分析失败（请查看服务日志以了解详情）"
+	}
+	return status
+}
+
+///////////////////////////////////////////////////////////////////
+// reload
+///////////////////////////////////////////////////////////////////
+
+func (*Chinese) Text_Reload() string { return "重新分析" }
+
+func (*Chinese) Text_ReloadOption(name string) string {
+	switch name {
+	case "patterns":
+		return "代码包模式"
+	case "tags":
+		return "构建标签"
+	case "goos":
+		return "GOOS"
+	case "goarch":
+		return "GOARCH"
+	case "tests":
+		return "包含测试"
+	case "submit":
+		return "重新分析"
+	}
+	return name
+}
+
+func (*Chinese) Text_ReloadStatus(status string) string {
+	switch status {
+	case "disabled":
+		return "重新分析未被启用。请使用 -allow-mutations 选项重启服务以启用它。"
+	case "failed":
+		return "上次重新分析失败（请查看服务日志以了解详情）。当前仍在使用之前的分析结果。"
+	}
+	return status
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
 
-func (*Chinese) Text_GeneratedPageFooter(goldVersion, qrCodeLink, goOS, goArch string) string {
+func (*Chinese) Text_GeneratedPageFooter(goldVersion, qrCodeLink, buildConfig string) string {
 	var qrImg string
 	if qrCodeLink != "" {
 		qrImg = fmt.Sprintf(`<img src="%s">`, qrCodeLink)
 	}
 	return fmt.Sprintf(`<table><tr><td>%s</td>
-<td>本页面由 <a href="https://go101.org/article/tool-gold.html"><b>Gold</b></a> <i>%s</i> 生成。（GOOS=%s GOARCH=%s）。
+<td>本页面由 <a href="https://go101.org/article/tool-gold.html"><b>Gold</b></a> <i>%s</i> 生成。（%s）。
 <b>Gold</b> 是由<a href="https://gfw.tapirgames.com">老貘</a>创建的一个 <a href="https://go101.org">Go 101</a>项目。
 欢迎在 <a href="https://github.com/go101/gold">Gold 项目</a>中提交 PR 和 bug 报告。
 请关注 “Go 101” 微信公众号（扫描左边的二维码）以获取 <b>Gold</b> 的最新消息以及各种 Go 细节和事实。</td></tr></table>`,
 		qrImg,
 		goldVersion,
-		goOS,
-		goArch,
+		buildConfig,
 	)
 }
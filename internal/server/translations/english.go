@@ -61,6 +61,20 @@ func (*English) Text_Analyzing_Start() string {
 	return "Start analyzing ..."
 }
 
+func (*English) Text_Analyzing_ListModules(numModules, numToDownload int, d time.Duration) string {
+	if numToDownload == 0 {
+		return fmt.Sprintf("List %d modules, all of them are in the local module cache: %s", numModules, d)
+	}
+	return fmt.Sprintf("List %d modules, %d of them need to be downloaded: %s", numModules, numToDownload, d)
+}
+
+func (*English) Text_Analyzing_DownloadModule(module string, numDones, numToDownload int, failed bool, d time.Duration) string {
+	if failed {
+		return fmt.Sprintf("(%d/%d) Failed to download module %s: %s", numDones, numToDownload, module, d)
+	}
+	return fmt.Sprintf("(%d/%d) Module %s downloaded: %s", numDones, numToDownload, module, d)
+}
+
 func (*English) Text_Analyzing_PreparationDone(d time.Duration) string {
 	return fmt.Sprintf("Preparation done: %s", d)
 }
@@ -122,6 +136,10 @@ func (*English) Text_Analyzing_CollectSourceFiles(d time.Duration) string {
 	return fmt.Sprintf("Collect Source Files: %s", d)
 }
 
+func (*English) Text_Analyzing_FindUnreachableFunctions(d time.Duration) string {
+	return fmt.Sprintf("Find unreachable functions: %s", d)
+}
+
 func (*English) Text_Analyzing_Done(d time.Duration, memoryUse string) string {
 	return fmt.Sprintf("Done. (Total time: %s, used memory: %s)", d, memoryUse)
 }
@@ -136,8 +154,11 @@ func (*English) Text_PackageList() string {
 	return "All Packages"
 }
 
-func (*English) Text_StatisticsWithMoreLink(detailedStatsLink string) string {
-	return fmt.Sprintf(`Statistics (<a href="%s">detailed ones</a>)`, detailedStatsLink)
+func (*English) Text_StatisticsWithMoreLink(detailedStatsLink, mainPackagesStatsLink string) string {
+	if mainPackagesStatsLink == "" {
+		return fmt.Sprintf(`Statistics (<a href="%s">detailed ones</a>)`, detailedStatsLink)
+	}
+	return fmt.Sprintf(`Statistics (<a href="%s">detailed ones</a>, <a href="%s">main packages only</a>)`, detailedStatsLink, mainPackagesStatsLink)
 }
 
 func (*English) Text_SimpleStats(stats *code.Stats) string {
@@ -164,6 +185,8 @@ On average,
 
 func (*English) Text_Modules() string { return "Modules" }
 
+func (*English) Text_BuildConfiguration() string { return "Build Configuration" }
+
 func (*English) Text_BelongingModule() string { return "Belonging Module" }
 
 func (*English) Text_RequireStat(numRequires, numRequiredBys int) string {
@@ -173,7 +196,7 @@ func (*English) Text_RequireStat(numRequires, numRequiredBys int) string {
 func (*English) Text_UpdateTip(tipName string) string {
 	switch tipName {
 	case "ToUpdate":
-		return `<b>Gold</b> has not been updated for more than one month. You may run <b>go get -u go101.org/gold</b> or <b><a href="/update">click here</a></b> to update it.`
+		return `<b>Gold</b> has not been updated for more than one month. You may run <b>go get -u go101.org/gold</b> or <b><a href="update">click here</a></b> to update it.`
 	case "Updating":
 		return `<b>Gold</b> is being updated.`
 	case "Updated":
@@ -182,6 +205,13 @@ func (*English) Text_UpdateTip(tipName string) string {
 	return ""
 }
 
+func (*English) Text_NumErrors(num int) string {
+	if num == 1 {
+		return "one error"
+	}
+	return fmt.Sprintf("%d errors", num)
+}
+
 func (*English) Text_SortBy() string {
 	return "sort by "
 }
@@ -262,6 +292,124 @@ func (*English) Text_ImportStat(numImports, numImportedBys int, depPageURL strin
 
 func (*English) Text_InvolvedFiles(num int) string { return "Involved Source Files" }
 
+func (*English) Text_IgnoredFiles(num int) string { return "Ignored Source Files" }
+
+func (*English) Text_CgoInterface(num int) string {
+	return fmt.Sprintf("cgo Interface (%d C symbols used)", num)
+}
+
+func (*English) Text_CgoSymbol(kind string, numUses int, declared bool) string {
+	var s = kind
+	if numUses == 1 {
+		s += ", used once"
+	} else {
+		s += fmt.Sprintf(", used %d times", numUses)
+	}
+	if !declared {
+		s += ", declared in system headers or provided by cgo"
+	}
+	return s
+}
+
+func (*English) Text_ImplementedInAssembly() string { return "implemented in assembly" }
+
+func (*English) Text_IgnoredFileReason(reason string) string {
+	switch reason {
+	case "filename":
+		return "file name suffix not matched"
+	case "constraint":
+		return "build constraint not satisfied"
+	case "cgo":
+		return "cgo disabled"
+	}
+	return reason
+}
+
+func (*English) Text_LineStatistics(lines, generatedLines code.LineStats) string {
+	text := fmt.Sprintf("%d code lines, %d comment lines, %d blank lines", lines.Code, lines.Comment, lines.Blank)
+	if n := generatedLines.Total(); n > 0 {
+		text += fmt.Sprintf(" (and %d lines in generated files)", n)
+	}
+	return text
+}
+
+func (*English) Text_DetailedStatisticsLinks(packageStatsLink, moduleStatsLink string) string {
+	if moduleStatsLink == "" {
+		return fmt.Sprintf(`Detailed statistics: <a href="%s">this package</a>`, packageStatsLink)
+	}
+	return fmt.Sprintf(`Detailed statistics: <a href="%s">this package</a>, <a href="%s">the belonging module</a>`, packageStatsLink, moduleStatsLink)
+}
+
+func (*English) Text_Diagnostics(numErrors int) string {
+	if numErrors == 1 {
+		return "Diagnostics (one error)"
+	}
+	return fmt.Sprintf("Diagnostics (%d errors)", numErrors)
+}
+
+func (*English) Text_BuiltinFunctions(num int) string {
+	return fmt.Sprintf("Built-in Functions (%d)", num)
+}
+
+func (*English) Text_BuiltinFunctionExplanation(name string) string {
+	switch name {
+	case "append":
+		return "Appends elements to the end of a slice and returns the result slice. If the capacity of the slice is not large enough, a new underlying array will be allocated."
+	case "cap":
+		return "Returns the capacity of a slice, channel or array. The result is a constant if the argument is an array or a pointer to an array and the argument expression contains no channel receives or function calls."
+	case "clear":
+		return "Deletes all entries of a map, or sets all elements of a slice to zero values."
+	case "close":
+		return "Closes a channel. Closing a nil or closed channel panics. Sending values to a closed channel panics, receiving from it never blocks."
+	case "complex":
+		return "Constructs a complex value from two floating-point values of the same type."
+	case "copy":
+		return "Copies elements from a source slice (or string) to a destination slice. The number of copied elements, which is the minimum of the lengths of the two arguments, is returned."
+	case "delete":
+		return "Deletes the entry with the specified key from a map. It is a no-op if the map is nil or no such entry exists."
+	case "imag":
+		return "Returns the imaginary part of a complex value."
+	case "len":
+		return "Returns the length of a string, slice, map, channel or array. The result is a constant if the argument is a constant string, or an array (or a pointer to an array) expression containing no channel receives or function calls."
+	case "make":
+		return "Allocates and initializes a slice, map or channel. The size arguments may be values of any integer types or untyped constants, and they must be non-negative."
+	case "max":
+		return "Returns the largest one of the arguments, which must be of the same ordered type."
+	case "min":
+		return "Returns the smallest one of the arguments, which must be of the same ordered type."
+	case "new":
+		return "Allocates a zero value of the specified type and returns a pointer to it."
+	case "panic":
+		return "Stops the normal execution of the current goroutine. Deferred function calls are still executed, in which the panic may be recovered."
+	case "print", "println":
+		return "Writes the arguments to the standard error. It is used for debugging and bootstrapping, and it is not guaranteed to stay in the language."
+	case "real":
+		return "Returns the real part of a complex value."
+	case "recover":
+		return "Recovers the panicking goroutine. It only takes effect when it is called directly in a deferred function call, otherwise it returns nil."
+	}
+	return ""
+}
+
+func (*English) Text_PredeclaredTypes(num int) string {
+	return fmt.Sprintf("Predeclared Types (%d)", num)
+}
+
+func (*English) Text_PredeclaredValues(num int) string {
+	return fmt.Sprintf("Predeclared Values (%d)", num)
+}
+
+func (*English) Text_Example() string { return "Example" }
+
+func (*English) Text_RuntimeImplementation() string { return "Implemented by" }
+
+func (*English) Text_AliasedBy(num int) string {
+	if num == 1 {
+		return "aliased by one exported type"
+	}
+	return fmt.Sprintf("aliased by %d exported types", num)
+}
+
 func (*English) Text_ExportedValues(num int) string {
 	return "Exported Values"
 }
@@ -371,6 +519,23 @@ func (*English) Text_SourceFilePath() string { return "Source File" }
 
 func (*English) Text_GeneratedFrom() string { return "Generated From" }
 
+func (*English) Text_BuildConstraint() string { return "Build Constraint" }
+
+func (*English) Text_IgnoredFileNote(reason, buildConfig string) string {
+	var why string
+	switch reason {
+	case "filename":
+		why = "its GOOS/GOARCH file name suffix doesn't match"
+	case "constraint":
+		why = "its build constraint is not satisfied"
+	case "cgo":
+		why = "it uses cgo but cgo is disabled"
+	default:
+		why = reason
+	}
+	return fmt.Sprintf("The file is ignored in the current build configuration (%s), for %s. It is shown without type information.", buildConfig, why)
+}
+
 ///////////////////////////////////////////////////////////////////
 // statistics
 ///////////////////////////////////////////////////////////////////
@@ -413,6 +578,14 @@ func (*English) Text_ChartTitle(chartName string) string {
 		return "Numbers of Exported Non-Interface Types by Exported Method Counts"
 	case "exportedinterfacetypes-by-exportedmethods":
 		return "Numbers of Exported Interface Types by Exported Method Counts"
+	case "unexportedtypenames-by-kinds":
+		return "Numbers of Unexported Package-Level Type Names by Kinds"
+	case "localtypenames-by-kinds":
+		return "Numbers of Local Type Names by Kinds"
+	case "allfunctions-by-parameters":
+		return "Numbers of All Functions (Including Methods and Closures) by Parameter Counts"
+	case "allfunctions-by-results":
+		return "Numbers of All Functions (Including Methods and Closures) by Result Counts"
 	default:
 		panic("unknown char name: " + chartName)
 	}
@@ -428,15 +601,34 @@ func (*English) Text_StatisticsTitle(titleName string) string {
 		return "Values"
 	case "others":
 		return "Others"
+	case "rankings":
+		return "Rankings"
+	case "lines":
+		return "Lines of Code"
 	default:
 		panic("unknown statistics tile: " + titleName)
 	}
 }
 
+func (*English) Text_StatisticsScope(scope, name, link string) string {
+	switch scope {
+	case "main":
+		return "Only the packages matched by the arguments are counted."
+	case "package":
+		return fmt.Sprintf(`Only package <a href="%s">%s</a> is counted.`, link, name)
+	case "module":
+		return fmt.Sprintf("Only the packages in module %s are counted.", name)
+	default:
+		panic("unknown statistics scope: " + scope)
+	}
+}
+
 func (*English) Text_PackageStatistics(values map[string]interface{}) string {
 	return fmt.Sprintf(`
 	Total <a href="%s">%d packages</a>, %d of them are standard packages.
 	Total %d source files, %d of them are Go source files.
+	The Go source files contain %d code lines, %d comment lines and %d blank lines,
+	not including the %d lines in generated files.
 	Averagely,
 	- each package contains %.2f source files,
 	- each Go source file imports %.2f packages,
@@ -450,6 +642,10 @@ func (*English) Text_PackageStatistics(values map[string]interface{}) string {
 		values["standardPackageCount"],
 		values["sourceFileCount"],
 		values["goSourceFileCount"],
+		values["codeLineCount"],
+		values["commentLineCount"],
+		values["blankLineCount"],
+		values["generatedLineCount"],
 		values["averageSourceFileCountPerPackage"],
 		values["averageImportCountPerFile"],
 		values["averageDependencyCountPerPackage"],
@@ -568,23 +764,299 @@ func (*English) Text_Othertatistics(values map[string]interface{}) string {
 	)
 }
 
+func (*English) Text_Hotspots() string { return "Hotspots" }
+
+func (*English) Text_HotspotsNote(numListed, numAll int) string {
+	return fmt.Sprintf("The top %d of the %d functions and methods declared in the main packages.", numListed, numAll)
+}
+
+func (*English) Text_HotspotsItem(item string) string {
+	switch item {
+	case "complexity":
+		return "complexity"
+	case "statements":
+		return "statements"
+	case "nesting":
+		return "nesting"
+	case "parameters":
+		return "parameters"
+	case "function":
+		return "function"
+	default:
+		panic("unknown hotspots item: " + item)
+	}
+}
+
+func (*English) Text_FunctionMetrics(complexity, statements, nesting, parameters int) string {
+	return fmt.Sprintf("cyclomatic complexity: %d, statements: %d, max nesting depth: %d, parameters: %d", complexity, statements, nesting, parameters)
+}
+
+func (*English) Text_ComplexityBadge(complexity int) string {
+	return fmt.Sprintf("complexity %d", complexity)
+}
+
+func (*English) Text_UnusedIdentifiers() string {
+	return "Exported Identifiers Unused by Other Packages"
+}
+
+func (*English) Text_UnusedIdentifiersNote() string {
+	return "The exported identifiers declared in the main packages but not referenced by any other analyzed packages."
+}
+
+func (*English) Text_UnusedIdentifiersOption(option string, asUsers bool) string {
+	var pkgs string
+	switch option {
+	case "tests":
+		pkgs = "Test files"
+	case "mains":
+		pkgs = "Main packages"
+	default:
+		panic("unknown option: " + option)
+	}
+	if asUsers {
+		return pkgs + " are viewed as users."
+	}
+	return pkgs + " are not viewed as users."
+}
+
+func (*English) Text_UnusedIdentifiersOptionToggle() string { return "(toggle)" }
+
+func (*English) Text_UnusedIdentifiersTestsNotLoaded() string {
+	return "Test files are not loaded. They can be loaded on the reload page."
+}
+
+func (*English) Text_UnusedIdentifiersOfModule(moduleName string, num int) string {
+	switch num {
+	case 0:
+		return fmt.Sprintf("Module %s (no unused ones)", moduleName)
+	case 1:
+		return fmt.Sprintf("Module %s (one unused)", moduleName)
+	}
+	return fmt.Sprintf("Module %s (%d unused)", moduleName, num)
+}
+
+func (*English) Text_MainModule() string { return "main module" }
+
+func (*English) Text_UnreachableFunctions() string {
+	return "Unreachable Functions"
+}
+
+func (*English) Text_UnreachableFunctionsNote() string {
+	return "The functions and methods declared in the main packages but not reachable from main, init, test and exported functions. Functions only called through reflection are also listed."
+}
+
+func (*English) Text_UnreachableFunctionsOfModule(moduleName string, num int) string {
+	switch num {
+	case 0:
+		return fmt.Sprintf("Module %s (no unreachable ones)", moduleName)
+	case 1:
+		return fmt.Sprintf("Module %s (one unreachable)", moduleName)
+	}
+	return fmt.Sprintf("Module %s (%d unreachable)", moduleName, num)
+}
+
+func (*English) Text_UnreachableDeclaration() string { return "unreachable" }
+
+func (*English) Text_DeprecationNote(note string) string {
+	return "Deprecated: " + note
+}
+
+func (*English) Text_DeprecatedIdentifiers(num int) string {
+	return fmt.Sprintf("Deprecated Identifiers (%d)", num)
+}
+
+func (*English) Text_DeprecatedIdentifiersShowOption(show bool) string {
+	if show {
+		return "show deprecated identifiers"
+	} else {
+		return "hide deprecated identifiers"
+	}
+}
+
+func (*English) Text_DeprecatedUses() string {
+	return "Uses of Deprecated APIs"
+}
+
+func (*English) Text_DeprecatedUsesNote(num int) string {
+	switch num {
+	case 0:
+		return "No deprecated identifiers declared in other packages are used in the main packages."
+	case 1:
+		return "One use of a deprecated identifier declared in other packages is found in the main packages."
+	}
+	return fmt.Sprintf("%d uses of deprecated identifiers declared in other packages are found in the main packages.", num)
+}
+
+func (*English) Text_StatisticsView(view string) string {
+	switch view {
+	case "exported":
+		return "Exported API"
+	case "whole":
+		return "Whole Code"
+	default:
+		panic("unknown statistics view: " + view)
+	}
+}
+
+func (*English) Text_WholeCodeTypeStatistics(values map[string]interface{}) string {
+	return fmt.Sprintf(`
+	Besides the %d exported type names, there are
+	%d unexported package-level type names and %d local type names.
+
+	<img src="%s"></image>
+	<img src="%s"></image>
+`,
+		values["exportedTypeNameCount"],
+		values["unexportedTypeNameCount"],
+		values["localTypeNameCount"],
+
+		values["unexportedtypenamesByKindsChartURL"],
+		values["localtypenamesByKindsChartURL"],
+	)
+}
+
+func (*English) Text_WholeCodeValueStatistics(values map[string]interface{}) string {
+	return fmt.Sprintf(`
+	Besides the exported ones, there are %d unexported package-level
+	variables and %d unexported package-level constants.
+	%d variables (package-level or local) are of function types.
+
+	Besides the exported ones, there are %d unexported functions,
+	%d unexported methods of exported types, %d methods of unexported
+	types and %d function literals (closures).
+	On average, each of all the %d functions, methods and closures
+	has %.2f parameters and %.2f results.
+
+	<img src="%s"></image>
+	<img src="%s"></image>
+`,
+		values["unexportedVariables"],
+		values["unexportedConstants"],
+		values["functionTypedVariables"],
+
+		values["unexportedFunctions"],
+		values["unexportedMethods"],
+		values["methodsOfUnexportedTypes"],
+		values["functionLiterals"],
+		values["allFunctions"],
+		values["averageParameterCountPerFunction"],
+		values["averageResultCountPerFunction"],
+
+		values["allfunctionsByParametersChartURL"],
+		values["allfunctionsByResultsChartURL"],
+	)
+}
+
+func (*English) Text_LineStatsItem(item string) string {
+	switch item {
+	case "code":
+		return "code"
+	case "comment":
+		return "comment"
+	case "blank":
+		return "blank"
+	case "generated":
+		return "generated"
+	case "module":
+		return "module"
+	case "package":
+		return "package"
+	default:
+		panic("unknown line stats item: " + item)
+	}
+}
+
+func (*English) Text_RankingTitle(rankingName string, n int) string {
+	switch rankingName {
+	case "most-implemented-interfaces":
+		return fmt.Sprintf("Top %d Exported Interfaces by Implementation Counts", n)
+	case "types-with-most-methods":
+		return fmt.Sprintf("Top %d Exported Non-Interface Types by Method Counts", n)
+	case "largest-packages-by-declarations":
+		return fmt.Sprintf("Top %d Packages by Package-Level Declaration Counts", n)
+	case "largest-packages-by-lines":
+		return fmt.Sprintf("Top %d Packages by Go Source Line Counts", n)
+	case "most-imported-packages":
+		return fmt.Sprintf("Top %d Packages by Imported-By Counts", n)
+	case "functions-with-most-parameters":
+		return fmt.Sprintf("Top %d Exported Functions/Methods by Parameter Counts", n)
+	case "most-referenced-identifiers":
+		return fmt.Sprintf("Top %d Exported Identifiers by Use Counts in Other Packages", n)
+	default:
+		panic("unknown ranking: " + rankingName)
+	}
+}
+
+///////////////////////////////////////////////////////////////////
+// workspaces
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Workspaces() string { return "Workspaces" }
+
+func (*English) Text_WorkspaceStatus(status string) string {
+	switch status {
+	case "unanalyzed":
+		return "not analyzed yet (analyzing starts on the first visit)"
+	case "analyzing":
+		return "analyzing"
+	case "analyzed":
+		return "analyzed"
+	case "failed":
+		return "failed to analyze (please view the server logs for details)"
+	}
+	return status
+}
+
+///////////////////////////////////////////////////////////////////
+// reload
+///////////////////////////////////////////////////////////////////
+
+func (*English) Text_Reload() string { return "Reload" }
+
+func (*English) Text_ReloadOption(name string) string {
+	switch name {
+	case "patterns":
+		return "Package patterns"
+	case "tags":
+		return "Build tags"
+	case "goos":
+		return "GOOS"
+	case "goarch":
+		return "GOARCH"
+	case "tests":
+		return "Include tests"
+	case "submit":
+		return "Re-analyze"
+	}
+	return name
+}
+
+func (*English) Text_ReloadStatus(status string) string {
+	switch status {
+	case "disabled":
+		return "Reloading is not enabled. Please restart the server with the -allow-mutations flag to enable it."
+	case "failed":
+		return "The last reloading failed (please view the server logs for details). The former analysis result is still being served."
+	}
+	return status
+}
+
 ///////////////////////////////////////////////////////////////////
 // footer
 ///////////////////////////////////////////////////////////////////
 
-func (*English) Text_GeneratedPageFooter(goldVersion, qrCodeLink, goOS, goArch string) string {
+func (*English) Text_GeneratedPageFooter(goldVersion, qrCodeLink, buildConfig string) string {
 	var qrImg string
 	if qrCodeLink != "" {
 		qrImg = fmt.Sprintf(`<img src="%s">`, qrCodeLink)
 	}
 	return fmt.Sprintf(`<table><tr><td>%s</td>
-<td>Generated with <a href="https://go101.org/article/tool-gold.html"><b>Gold</b></a> <i>%s</i>. (GOOS=%s GOARCH=%s).
+<td>Generated with <a href="https://go101.org/article/tool-gold.html"><b>Gold</b></a> <i>%s</i>. (%s).
 <b>Gold</b> is a <a href="https://go101.org">Go 101</a> project started by <a href="https://tapirgames.com">TapirLiu</a>.
 PR and bug reports are welcomed and can be submitted <a href="https://github.com/go101/gold">here</a>.
 Please follow <a href="https://twitter.com/go100and1">@Go100and1</a> (reachable from the left QR code) to get the latest news of <b>Gold</b>.</td></tr></table`,
 		qrImg,
 		goldVersion,
-		goOS,
-		goArch,
+		buildConfig,
 	)
 }
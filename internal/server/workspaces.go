@@ -0,0 +1,221 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go101.org/gold/code"
+	"go101.org/gold/internal/util"
+)
+
+// A WorkspaceConfig specifies a named argument set to analyze.
+// The config file of the multi-workspace mode is a JSON array of them.
+type WorkspaceConfig struct {
+	Name string   `json:"name"`
+	Dir  string   `json:"dir"`  // relative to the config file, "~/" is the home dir
+	Args []string `json:"args"` // default to ["."]
+}
+
+func LoadWorkspaceConfigs(configFile string) ([]WorkspaceConfig, error) {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var configs []WorkspaceConfig
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("parse %s: %s", configFile, err)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no workspaces are specified in %s", configFile)
+	}
+
+	configDir, err := filepath.Abs(filepath.Dir(configFile))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(configs))
+	for i := range configs {
+		c := &configs[i]
+		if c.Name == "" || strings.ContainsAny(c.Name, `/\?#%`) {
+			return nil, fmt.Errorf("invalid workspace name: %q", c.Name)
+		}
+		if names[c.Name] {
+			return nil, fmt.Errorf("duplicated workspace name: %s", c.Name)
+		}
+		names[c.Name] = true
+
+		if c.Dir == "~" || strings.HasPrefix(c.Dir, "~/") {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			c.Dir = filepath.Join(home, c.Dir[1:])
+		}
+		if c.Dir == "" {
+			c.Dir = configDir
+		} else if !filepath.IsAbs(c.Dir) {
+			c.Dir = filepath.Join(configDir, c.Dir)
+		}
+	}
+	return configs, nil
+}
+
+type workspace struct {
+	WorkspaceConfig
+
+	ds      *docServer
+	analyze sync.Once
+}
+
+// workspacesServer serves each workspace under the "/w/name/" url prefix.
+// A workspace is analyzed when it is visited the first time.
+type workspacesServer struct {
+	// Serves the landing page and the resource files.
+	// It analyzes nothing.
+	root *docServer
+
+	workspaces []*workspace
+	byName     map[string]*workspace
+}
+
+// RunWorkspaces serves several workspaces in one process.
+// The memory budget of the page cache is divided equally among the workspaces.
+func RunWorkspaces(recommendedPort, lang string, configs []WorkspaceConfig, options code.ParseOptions, cacheOptions CacheOptions, access util.ServerAccess, allowMutations bool, silentMode bool, goldVersion string, roughBuildTime func() time.Time) {
+	wss := &workspacesServer{
+		root:   newDocServer(lang, options, cacheOptions, allowMutations, goldVersion, roughBuildTime),
+		byName: make(map[string]*workspace, len(configs)),
+	}
+
+	cacheOptions.MemoryLimit /= int64(len(configs))
+	// Errors in one workspace should not stop serving the others.
+	options.TolerateErrors = true
+
+	for _, c := range configs {
+		wsOptions := options
+		wsOptions.Dir = c.Dir
+		ds := newDocServer(lang, wsOptions, cacheOptions, allowMutations, goldVersion, roughBuildTime)
+		ds.workspaceName = c.Name
+		ds.urlPrefix = "/w/" + c.Name
+		ds.analyzingLogger.SetPrefix("[Analyzing " + c.Name + "] ")
+
+		ws := &workspace{WorkspaceConfig: c, ds: ds}
+		wss.workspaces = append(wss.workspaces, ws)
+		wss.byName[c.Name] = ws
+	}
+
+	listenAndServe(recommendedPort, wss, access, silentMode, func(port string) {
+		serverStarted := wss.root.currentTranslationSafely().Text_Server_Started()
+		log.Printf("%s %s\n", serverStarted, access.URL(port))
+	})
+}
+
+func (wss *workspacesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var path = r.URL.Path
+	if path == "/" {
+		wss.landingPage(w, r)
+		return
+	}
+
+	if strings.HasPrefix(path, "/w/") {
+		name, rest := path[3:], ""
+		if i := strings.IndexByte(name, '/'); i >= 0 {
+			name, rest = name[:i], name[i:]
+		}
+		ws := wss.byName[name]
+		if ws == nil {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, "Workspace (%s) not found", name)
+			return
+		}
+		if rest == "" {
+			http.Redirect(w, r, "/w/"+name+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		ws.analyze.Do(func() {
+			go ws.ds.analyze(ws.Args, nil)
+		})
+		http.StripPrefix(ws.ds.urlPrefix, ws.ds).ServeHTTP(w, r)
+		return
+	}
+
+	// Resource files used by the landing page.
+	if len(path) > 5 && path[4] == ':' {
+		switch pageResType(path[1:4]) {
+		case ResTypeCSS, ResTypeJS, ResTypePNG:
+			wss.root.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+	fmt.Fprint(w, "Invalid url")
+}
+
+func (wss *workspacesServer) landingPage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+
+	ds := wss.root
+	if atomic.SwapInt32(&ds.visited, 1) == 0 {
+		ds.changeTranslationByAcceptLanguage(r.Header.Get("Accept-Language"))
+	}
+
+	theme, translation := ds.currentSettings()
+	page := NewHtmlPage(ds.goldVersion, translation.Text_Workspaces(), theme.Name(), pagePathInfo{ResTypeNone, ""})
+	fmt.Fprintf(page, `
+<pre><code><span style="font-size:xx-large;">%s</span></code>
+`,
+		translation.Text_Workspaces(),
+	)
+
+	for _, ws := range wss.workspaces {
+		args := ws.Args
+		if len(args) == 0 {
+			args = []string{"."}
+		}
+		fmt.Fprintf(page, `
+<code>	<a href="w/%[1]s/">%[1]s</a>: <i>%[2]s</i>
+		%[3]s: %[4]s</code>
+`,
+			html.EscapeString(ws.Name),
+			translation.Text_WorkspaceStatus(ws.ds.analyzingStatus()),
+			html.EscapeString(ws.Dir),
+			html.EscapeString(strings.Join(args, " ")),
+		)
+	}
+	page.WriteString(`</pre>`)
+
+	w.Write(page.Done(translation, ds.parseOptions.BuildConfiguration()))
+}
+
+// analyzingStatus returns "unanalyzed", "analyzing", "analyzed" or "failed".
+func (ds *docServer) analyzingStatus() string {
+	ds.mutex.RLock()
+	defer ds.mutex.RUnlock()
+
+	switch {
+	case ds.analyzingFailed:
+		return "failed"
+	case ds.phase >= Phase_Analyzed:
+		return "analyzed"
+	}
+
+	ds.logsMutex.Lock()
+	defer ds.logsMutex.Unlock()
+	if len(ds.analyzingLogs) > 0 {
+		return "analyzing"
+	}
+	return "unanalyzed"
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ServerAccess specifies how the servers (both the docs server
+// and the file server) are accessed.
+type ServerAccess struct {
+	// The host name or IP to listen on. Blank means localhost.
+	// Use 0.0.0.0 (or ::) to listen on all interfaces.
+	ListenHost string
+
+	// If it is not blank, every request must provide it, as
+	// * the "token" query parameter (then it is saved in a cookie),
+	// * the "gold-token" cookie,
+	// * a bearer token in the Authorization header,
+	// * or the password of a basic authentication.
+	Token string
+}
+
+const tokenCookieName = "gold-token"
+
+func (sa ServerAccess) host() string {
+	if sa.ListenHost == "" {
+		return "localhost"
+	}
+	return sa.ListenHost
+}
+
+// AllInterfaces returns whether or not the listen host means all interfaces.
+func (sa ServerAccess) AllInterfaces() bool {
+	ip := net.ParseIP(sa.ListenHost)
+	return ip != nil && ip.IsUnspecified()
+}
+
+func (sa ServerAccess) Listen(port string) (net.Listener, error) {
+	return net.Listen("tcp", net.JoinHostPort(sa.host(), port))
+}
+
+// URL returns the url to open in browsers. It contains the token.
+func (sa ServerAccess) URL(port string) string {
+	host := sa.host()
+	if sa.AllInterfaces() {
+		host = "localhost"
+	}
+	return sa.urlWithHost(host, port)
+}
+
+func (sa ServerAccess) urlWithHost(host, port string) string {
+	u := "http://" + net.JoinHostPort(host, port)
+	if sa.Token != "" {
+		u += "/?token=" + url.QueryEscape(sa.Token)
+	}
+	return u
+}
+
+// Handler wraps h so that requests without the token are refused.
+func (sa ServerAccess) Handler(h http.Handler) http.Handler {
+	if sa.Token == "" {
+		return h
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if query := r.URL.Query(); query.Get("token") != "" {
+			if !sa.validToken(query.Get("token")) {
+				sa.refuse(w)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     tokenCookieName,
+				Value:    sa.Token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			// Remove the token from the address bar.
+			query.Del("token")
+			target := *r.URL
+			target.RawQuery = query.Encode()
+			http.Redirect(w, r, target.RequestURI(), http.StatusSeeOther)
+			return
+		}
+
+		if !sa.authorized(r) {
+			sa.refuse(w)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (sa ServerAccess) authorized(r *http.Request) bool {
+	if c, err := r.Cookie(tokenCookieName); err == nil && sa.validToken(c.Value) {
+		return true
+	}
+	if _, password, ok := r.BasicAuth(); ok && sa.validToken(password) {
+		return true
+	}
+	auth := r.Header.Get("Authorization")
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		return sa.validToken(strings.TrimSpace(auth[7:]))
+	}
+	return false
+}
+
+func (sa ServerAccess) validToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(sa.Token)) == 1
+}
+
+func (sa ServerAccess) refuse(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="Gold"`)
+	http.Error(w, "An access token is required", http.StatusUnauthorized)
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseByteSize parses sizes like "512MB", "1.5G", "800KB" and "4096".
+// The units are powers of 1024.
+func ParseByteSize(s string) (int64, error) {
+	var str = strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "B")
+
+	var unit int64 = 1
+	if n := len(str); n > 0 {
+		switch str[n-1] {
+		case 'K':
+			unit = 1 << 10
+		case 'M':
+			unit = 1 << 20
+		case 'G':
+			unit = 1 << 30
+		case 'T':
+			unit = 1 << 40
+		}
+		if unit > 1 {
+			str = str[:n-1]
+		}
+	}
+
+	v, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid byte size: %s", s)
+	}
+	return int64(v * float64(unit)), nil
+}
@@ -1,7 +1,6 @@
 package util
 
 import (
-	"fmt"
 	"log"
 	"math/rand"
 	"net"
@@ -16,7 +15,7 @@ func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-func ServeFiles(dir, recommendedPort string, silentMode bool, goldVersion string) {
+func ServeFiles(dir, recommendedPort string, access ServerAccess, silentMode bool, goldVersion string) {
 	dir, err := filepath.Abs(dir)
 	if err != nil {
 		log.Fatal(err)
@@ -40,7 +39,7 @@ func ServeFiles(dir, recommendedPort string, silentMode bool, goldVersion string
 	}
 
 NextTry:
-	l, err := net.Listen("tcp", fmt.Sprintf(":%v", port))
+	l, err := access.Listen(port)
 	if err != nil {
 		if strings.Index(err.Error(), "bind: address already in use") >= 0 {
 			defaultPort += delta
@@ -89,25 +88,27 @@ NextTry:
 		log.Println("Serving directory:")
 		log.Print("   ", dir)
 		log.Println("Running at:")
-		log.Print("   http://localhost:", port)
+		log.Print("   ", access.URL(port))
 
 		// ToDo: show the list in every html page.
-		if addrs, err := net.InterfaceAddrs(); err == nil {
-			for _, a := range addrs {
-				if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil {
-					log.Println("   http://" + ipnet.IP.String() + ":" + port)
+		if access.AllInterfaces() {
+			if addrs, err := net.InterfaceAddrs(); err == nil {
+				for _, a := range addrs {
+					if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil && !ipnet.IP.IsLoopback() {
+						log.Println("   " + access.urlWithHost(ipnet.IP.String(), port))
+					}
 				}
 			}
 		}
 
 		if !silentMode {
-			if err = OpenBrowser("http://localhost:" + port); err != nil {
+			if err = OpenBrowser(access.URL(port)); err != nil {
 				log.Println(err)
 			}
 		}
 	}()
 
-	handler := NoCacheHandler(http.FileServer(http.Dir(dir)))
+	handler := access.Handler(NoCacheHandler(http.FileServer(http.Dir(dir))))
 	if err = http.Serve(l, handler); err != nil {
 		log.Printf("Failed to start server: %v\n", err)
 	}